@@ -0,0 +1,129 @@
+package mapbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.opencensus.io/trace"
+)
+
+const defaultBatchConcurrency = 4
+
+var errBatchPoint = errors.New("mapbox: expecting each point as a [lon, lat] pair")
+
+// BatchError is returned by BatchReverse when some of
+// the lookups failed, keyed by the index of their point.
+type BatchError map[int]error
+
+func (be BatchError) Error() string {
+	indices := make([]int, 0, len(be))
+	for i := range be {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+
+	msgs := make([]string, len(indices))
+	for j, i := range indices {
+		msgs[j] = fmt.Sprintf("#%d: %v", i, be[i])
+	}
+	return fmt.Sprintf("mapbox: %d lookups failed: %s", len(be), strings.Join(msgs, "; "))
+}
+
+// BatchReverse reverse geocodes the [lon, lat] points using up to
+// concurrency lookups at a time, or 4 if concurrency isn't positive.
+// The responses are in the same order as the points. If some lookups
+// fail, the responses of those that succeeded are returned alongside
+// a BatchError. Unless the client was created WithBatchContinueOnError,
+// the first error that retrying wouldn't fix e.g. a bad API key cancels
+// all the outstanding lookups, which then fail with context.Canceled.
+func (c *Client) BatchReverse(ctx context.Context, points []*LatLonPair, concurrency int) ([]*GeocodeResponse, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).BatchReverse")
+	defer span.End()
+
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+	span.Annotate([]trace.Attribute{
+		trace.Int64Attribute("points", int64(len(points))),
+		trace.Int64Attribute("concurrency", int64(concurrency)),
+	}, "Reverse geocoding")
+
+	parentCtx := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	indices := make(chan int)
+	go func() {
+		defer close(indices)
+		for i := range points {
+			select {
+			case indices <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	failFast := !c.batchContinueOnError()
+	responses := make([]*GeocodeResponse, len(points))
+	var mu sync.Mutex
+	batchErr := make(BatchError)
+	var wg sync.WaitGroup
+	for n := 0; n < concurrency && n < len(points); n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				gres, err := c.lookupPair(ctx, points[i])
+				if err == nil {
+					responses[i] = gres
+					continue
+				}
+				mu.Lock()
+				batchErr[i] = err
+				mu.Unlock()
+				if failFast && !retryableError(err) {
+					cancel()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := parentCtx.Err(); err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeCancelled, Message: err.Error()})
+		return responses, err
+	}
+	// Points that were never looked up because of a cancellation.
+	if len(batchErr) > 0 {
+		for i, gres := range responses {
+			if _, failed := batchErr[i]; gres == nil && !failed {
+				batchErr[i] = context.Canceled
+			}
+		}
+	}
+	if len(batchErr) > 0 {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: batchErr.Error()})
+		return responses, batchErr
+	}
+	span.SetStatus(trace.Status{Code: trace.StatusCodeOK})
+	return responses, nil
+}
+
+func (c *Client) lookupPair(ctx context.Context, point *LatLonPair) (*GeocodeResponse, error) {
+	if point == nil || len(*point) != 2 {
+		return nil, errBatchPoint
+	}
+	return c.LookupLatLon(ctx, float64((*point)[1]), float64((*point)[0]))
+}
+
+func (c *Client) batchContinueOnError() bool {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.continueOnError
+}
@@ -0,0 +1,168 @@
+package mapbox_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orijtech/mapbox"
+)
+
+// delayedReverse responds to reverse geocoding requests after
+// a delay, echoing back the queried point, except for those
+// of failLon which get a 401 Unauthorized.
+type delayedReverse struct {
+	delay   func(lon float32) time.Duration
+	failLon float32
+
+	mu              sync.Mutex
+	inFlight        int
+	maxInFlight     int
+	requestsHandled int
+}
+
+func (dr *delayedReverse) RoundTrip(req *http.Request) (*http.Response, error) {
+	dr.mu.Lock()
+	dr.inFlight++
+	dr.requestsHandled++
+	if dr.inFlight > dr.maxInFlight {
+		dr.maxInFlight = dr.inFlight
+	}
+	dr.mu.Unlock()
+	defer func() {
+		dr.mu.Lock()
+		dr.inFlight--
+		dr.mu.Unlock()
+	}()
+
+	splits := strings.Split(req.URL.Path, "/")
+	query := strings.TrimSuffix(splits[len(splits)-1], ".json")
+	var lon, lat float32
+	if _, err := fmt.Sscanf(query, "%f,%f", &lon, &lat); err != nil {
+		return makeResp(err.Error(), http.StatusBadRequest, http.NoBody), nil
+	}
+
+	select {
+	case <-time.After(dr.delay(lon)):
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	if lon == dr.failLon {
+		body := `{"message": "Not Authorized - Invalid Token"}`
+		return makeResp("401 Unauthorized", http.StatusUnauthorized, ioutil.NopCloser(strings.NewReader(body))), nil
+	}
+	body := fmt.Sprintf(`{"type": "FeatureCollection", "query": [%f, %f], "features": []}`, lon, lat)
+	return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(body))), nil
+}
+
+func batchPoints(n int) []*mapbox.LatLonPair {
+	points := make([]*mapbox.LatLonPair, n)
+	for i := range points {
+		points[i] = &mapbox.LatLonPair{float32(i + 1), 34}
+	}
+	return points
+}
+
+func TestBatchReverse(t *testing.T) {
+	points := batchPoints(12)
+	dr := &delayedReverse{
+		// Later points respond sooner, to check that the
+		// responses are still in the order of the points.
+		delay: func(lon float32) time.Duration {
+			return time.Duration(20-lon) * time.Millisecond
+		},
+	}
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{Transport: dr}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responses, err := client.BatchReverse(context.Background(), points, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, w := len(responses), len(points); g != w {
+		t.Fatalf("got %d responses want %d", g, w)
+	}
+	for i, gres := range responses {
+		if gres == nil || gres.Query == nil || (*gres.Query)[0] != (*points[i])[0] {
+			t.Errorf("#%d: got %+v want the response for %v", i, gres, *points[i])
+		}
+	}
+	if dr.maxInFlight > 3 {
+		t.Errorf("got %d concurrent requests want at most 3", dr.maxInFlight)
+	}
+}
+
+func TestBatchReverseErrors(t *testing.T) {
+	tests := []struct {
+		opts          []mapbox.Option
+		wantFailed    []int
+		wantSucceeded []int
+	}{
+		// By default, the 401 for the 3rd point cancels the rest.
+		0: {wantFailed: []int{2, 3, 4, 5}, wantSucceeded: []int{0, 1}},
+		1: {
+			opts:          []mapbox.Option{mapbox.WithBatchContinueOnError()},
+			wantFailed:    []int{2},
+			wantSucceeded: []int{0, 1, 3, 4, 5},
+		},
+	}
+
+	for i, tt := range tests {
+		points := batchPoints(6)
+		dr := &delayedReverse{
+			delay:   func(float32) time.Duration { return time.Millisecond },
+			failLon: 3,
+		}
+		opts := append([]mapbox.Option{mapbox.WithHTTPClient(&http.Client{Transport: dr})}, tt.opts...)
+		client, err := mapbox.NewClient(opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		responses, err := client.BatchReverse(context.Background(), points, 1)
+		batchErr, ok := err.(mapbox.BatchError)
+		if !ok {
+			t.Errorf("#%d: got %T(%v) want a BatchError", i, err, err)
+			continue
+		}
+		if g, w := len(batchErr), len(tt.wantFailed); g != w {
+			t.Errorf("#%d: got %d failures want %d: %v", i, g, w, batchErr)
+		}
+		for _, index := range tt.wantFailed {
+			if batchErr[index] == nil {
+				t.Errorf("#%d: expected point #%d to fail", i, index)
+			}
+		}
+		if me, ok := batchErr[2].(*mapbox.MapboxError); !ok || me.StatusCode != http.StatusUnauthorized {
+			t.Errorf("#%d: point #2 got %v want a 401", i, batchErr[2])
+		}
+		for _, index := range tt.wantSucceeded {
+			if responses[index] == nil {
+				t.Errorf("#%d: expected a response for point #%d", i, index)
+			}
+		}
+	}
+}
+
+func TestBatchReverseCancelledContext(t *testing.T) {
+	dr := &delayedReverse{
+		delay: func(float32) time.Duration { return time.Minute },
+	}
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{Transport: dr}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.BatchReverse(ctx, batchPoints(6), 2); err != context.DeadlineExceeded {
+		t.Errorf("got %v want %v", err, context.DeadlineExceeded)
+	}
+}
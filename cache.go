@@ -0,0 +1,117 @@
+package mapbox
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Cache stores the bodies of successful responses, keyed by their
+// request URL without the access token but with a hash of it.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the body cached for key, and false if there
+	// is none or it has expired.
+	Get(key string) ([]byte, bool)
+
+	// Set caches body for key for ttl, or
+	// until it is evicted if ttl is 0.
+	Set(key string, body []byte, ttl time.Duration)
+}
+
+// defaultCacheTTL is how long geocoding responses are cached for.
+const defaultCacheTTL = 24 * time.Hour
+
+// cacheKeyFor returns the cache key of the request to u, which
+// has no access token, made with apiKey. Only a hash of apiKey is
+// kept so that the cache doesn't hold the key itself.
+func cacheKeyFor(u, apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return u + "#" + hex.EncodeToString(sum[:8])
+}
+
+func (c *Client) _cache() Cache {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.cache
+}
+
+// LRUCache is an in-memory Cache that evicts
+// the least recently used entry once it is full.
+type LRUCache struct {
+	mu      sync.Mutex
+	size    int
+	ll      *list.List
+	entries map[string]*list.Element
+}
+
+var _ Cache = (*LRUCache)(nil)
+
+type lruEntry struct {
+	key       string
+	body      []byte
+	expiresAt time.Time
+}
+
+// NewLRUCache returns a cache of at most size entries.
+func NewLRUCache(size int) *LRUCache {
+	if size < 1 {
+		size = 1
+	}
+	return &LRUCache{
+		size:    size,
+		ll:      list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (lc *LRUCache) Get(key string) ([]byte, bool) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	elem, ok := lc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if !entry.expiresAt.IsZero() && !time.Now().Before(entry.expiresAt) {
+		lc.ll.Remove(elem)
+		delete(lc.entries, key)
+		return nil, false
+	}
+	lc.ll.MoveToFront(elem)
+	return entry.body, true
+}
+
+func (lc *LRUCache) Set(key string, body []byte, ttl time.Duration) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+	if elem, ok := lc.entries[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.body, entry.expiresAt = body, expiresAt
+		lc.ll.MoveToFront(elem)
+		return
+	}
+	lc.entries[key] = lc.ll.PushFront(&lruEntry{key: key, body: body, expiresAt: expiresAt})
+	for lc.ll.Len() > lc.size {
+		oldest := lc.ll.Back()
+		lc.ll.Remove(oldest)
+		delete(lc.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// Len returns the number of cached entries.
+func (lc *LRUCache) Len() int {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	return lc.ll.Len()
+}
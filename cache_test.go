@@ -0,0 +1,168 @@
+package mapbox_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orijtech/mapbox"
+)
+
+type fakeCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+	ttls    map[string]time.Duration
+}
+
+func (fc *fakeCache) Get(key string) ([]byte, bool) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	body, ok := fc.entries[key]
+	return body, ok
+}
+
+func (fc *fakeCache) Set(key string, body []byte, ttl time.Duration) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	if fc.entries == nil {
+		fc.entries = make(map[string][]byte)
+		fc.ttls = make(map[string]time.Duration)
+	}
+	fc.entries[key] = body
+	fc.ttls[key] = ttl
+}
+
+func TestWithCache(t *testing.T) {
+	requests := 0
+	cache := new(fakeCache)
+	client, err := mapbox.NewClient(
+		mapbox.WithAPIKey("pk.test"),
+		mapbox.WithCache(cache),
+		mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				requests++
+				return respFromFileContents(geocodeResponsePath("LA"))
+			}),
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	first, err := client.LookupPlacePermanent(ctx, "Los Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := client.LookupPlacePermanent(ctx, "Los Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 1 {
+		t.Errorf("got %d requests want 1", requests)
+	}
+	if g, w := jsonMarshal(second), jsonMarshal(first); string(g) != string(w) {
+		t.Errorf("cached response\ngot:  %s\nwant: %s", g, w)
+	}
+
+	// The temporary mode's results may not be stored.
+	for i := 0; i < 2; i++ {
+		if _, err := client.LookupPlace(ctx, "Los Angeles"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests != 3 {
+		t.Errorf("got %d requests want 3", requests)
+	}
+
+	// Another access token must not be served the first one's entry.
+	otherCtx := mapbox.WithContextAPIKey(ctx, "pk.other")
+	if _, err := client.LookupPlacePermanent(otherCtx, "Los Angeles"); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 4 {
+		t.Errorf("got %d requests want 4", requests)
+	}
+	if _, err := client.LookupPlacePermanent(otherCtx, "Los Angeles"); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 4 {
+		t.Errorf("got %d requests want 4", requests)
+	}
+
+	if g, w := len(cache.entries), 2; g != w {
+		t.Errorf("got %d cache entries want %d", g, w)
+	}
+	for key := range cache.entries {
+		if !strings.Contains(key, "mapbox.places-permanent") {
+			t.Errorf("cache key %q is not in the permanent mode", key)
+		}
+		if strings.Contains(key, "pk.test") || strings.Contains(key, "pk.other") {
+			t.Errorf("cache key %q contains the access token", key)
+		}
+	}
+}
+
+func TestWithCacheSkipsErrors(t *testing.T) {
+	requests := 0
+	cache := new(fakeCache)
+	client, err := mapbox.NewClient(
+		mapbox.WithCache(cache),
+		mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				requests++
+				return makeResp("503 Service Unavailable", http.StatusServiceUnavailable, http.NoBody), nil
+			}),
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.LookupPlacePermanent(context.Background(), "Los Angeles"); err == nil {
+			t.Errorf("#%d: want non-nil error", i)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("got %d requests want 2", requests)
+	}
+	if len(cache.entries) != 0 {
+		t.Errorf("got %d cache entries want none", len(cache.entries))
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	lc := mapbox.NewLRUCache(2)
+
+	lc.Set("a", []byte("A"), 0)
+	lc.Set("b", []byte("B"), 0)
+	// Using "a" makes "b" the least recently used.
+	if body, ok := lc.Get("a"); !ok || string(body) != "A" {
+		t.Errorf("a: got (%q, %v) want (%q, true)", body, ok, "A")
+	}
+	lc.Set("c", []byte("C"), 0)
+
+	if _, ok := lc.Get("b"); ok {
+		t.Error("b: expected it to have been evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := lc.Get(key); !ok {
+			t.Errorf("%s: expected it to be cached", key)
+		}
+	}
+	if g, w := lc.Len(), 2; g != w {
+		t.Errorf("len got %d want %d", g, w)
+	}
+
+	lc.Set("d", []byte("D"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := lc.Get("d"); ok {
+		t.Error("d: expected it to have expired")
+	}
+}
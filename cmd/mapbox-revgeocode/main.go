@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
@@ -19,7 +20,7 @@ func main() {
 		log.Fatal(err)
 	}
 
-	resp, err := client.LookupLatLon(lat, lon)
+	resp, err := client.LookupLatLon(context.Background(), lat, lon)
 	if err != nil {
 		log.Fatal(err)
 	}
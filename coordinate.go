@@ -0,0 +1,123 @@
+package mapbox
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Coordinate is a position in degrees, named to avoid
+// mixing up the [lon, lat] order that Mapbox uses.
+type Coordinate struct {
+	Lon float32 `json:"lon"`
+	Lat float32 `json:"lat"`
+}
+
+// String formats c as "lon,lat", the order that Mapbox expects
+// coordinates in, e.g. in a reverse geocode query, with the default
+// precision. Requests use the client's WithCoordinatePrecision.
+func (c Coordinate) String() string {
+	return formatPair(LatLonPair{c.Lon, c.Lat}, defaultCoordinatePrecision)
+}
+
+// CenterCoordinate returns the feature's center, and false
+// if the response didn't have a [lon, lat] center for it.
+func (gf *GeocodeFeature) CenterCoordinate() (Coordinate, bool) {
+	if gf == nil || len(gf.Center) < 2 {
+		return Coordinate{}, false
+	}
+	return Coordinate{Lon: gf.Center[0], Lat: gf.Center[1]}, true
+}
+
+// Bounds returns the southwest and northeast corners of the
+// feature's bounding box, and false if the response didn't
+// have a [minLon, minLat, maxLon, maxLat] box for it.
+func (gf *GeocodeFeature) Bounds() (sw, ne Coordinate, ok bool) {
+	if gf == nil || len(gf.BoundingBox) < 4 {
+		return Coordinate{}, Coordinate{}, false
+	}
+	bbox := gf.BoundingBox
+	sw = Coordinate{Lon: bbox[0], Lat: bbox[1]}
+	ne = Coordinate{Lon: bbox[2], Lat: bbox[3]}
+	return sw, ne, true
+}
+
+// defaultCoordinatePrecision is the number of decimals that
+// coordinates are formatted with, which is about 10 cm.
+const defaultCoordinatePrecision = 6
+
+func (c *Client) coordinatePrecision() int {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.precision > 0 {
+		return c.precision
+	}
+	return defaultCoordinatePrecision
+}
+
+// formatDegrees formats v, in degrees, with at most the client's
+// coordinate precision of decimals and no trailing zeros.
+func (c *Client) formatDegrees(v float64) string {
+	return formatDegrees(v, c.coordinatePrecision())
+}
+
+// formatPair is the package level formatPair
+// with the client's coordinate precision.
+func (c *Client) formatPair(pair LatLonPair) string {
+	return formatPair(pair, c.coordinatePrecision())
+}
+
+// formatDegrees formats v, in degrees, with at most
+// precision decimals and no trailing zeros.
+func formatDegrees(v float64, precision int) string {
+	s := strconv.FormatFloat(v, 'f', precision, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// formatPair formats a [lon, lat] pair as "lon,lat". Each of its
+// float32 values is first converted to its shortest decimal form,
+// so that e.g. 37.78881 isn't sent as 37.788811.
+func formatPair(pair LatLonPair, precision int) string {
+	return formatDegrees(shortest(pair[0]), precision) + "," + formatDegrees(shortest(pair[1]), precision)
+}
+
+func shortest(f float32) float64 {
+	v, _ := strconv.ParseFloat(strconv.FormatFloat(float64(f), 'f', -1, 32), 64)
+	return v
+}
+
+// earthRadius is the mean radius of the Earth in meters.
+const earthRadius = 6371008.8
+
+// Haversine returns the great-circle distance in meters between
+// a and b, which like every LatLonPair including a GeocodeFeature's
+// Center are [lon, lat] pairs, that is longitude first.
+// It returns NaN if either pair is nil or has fewer than 2 values.
+func Haversine(a, b *LatLonPair) float64 {
+	if a == nil || b == nil || len(*a) < 2 || len(*b) < 2 {
+		return math.NaN()
+	}
+	lon1, lat1 := toRadians((*a)[0]), toRadians((*a)[1])
+	lon2, lat2 := toRadians((*b)[0]), toRadians((*b)[1])
+	sinLat := math.Sin((lat2 - lat1) / 2)
+	sinLon := math.Sin((lon2 - lon1) / 2)
+	h := sinLat*sinLat + math.Cos(lat1)*math.Cos(lat2)*sinLon*sinLon
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func toRadians(degrees float32) float64 {
+	return float64(degrees) * math.Pi / 180
+}
+
+// DistanceTo returns the great-circle distance in meters
+// from the [lon, lat] pair llp to b, see Haversine.
+func (llp *LatLonPair) DistanceTo(b *LatLonPair) float64 {
+	return Haversine(llp, b)
+}
@@ -0,0 +1,197 @@
+package mapbox_test
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/orijtech/mapbox"
+)
+
+func TestCenterCoordinate(t *testing.T) {
+	tests := []struct {
+		feature *mapbox.GeocodeFeature
+		want    mapbox.Coordinate
+		wantOk  bool
+	}{
+		0: {
+			feature: &mapbox.GeocodeFeature{Center: []float32{-118.2439, 34.0544}},
+			want:    mapbox.Coordinate{Lon: -118.2439, Lat: 34.0544},
+			wantOk:  true,
+		},
+		1: {feature: &mapbox.GeocodeFeature{Center: []float32{-118.2439}}},
+		2: {feature: &mapbox.GeocodeFeature{}},
+		3: {feature: nil},
+	}
+
+	for i, tt := range tests {
+		got, ok := tt.feature.CenterCoordinate()
+		if ok != tt.wantOk {
+			t.Errorf("#%d: ok got %v want %v", i, ok, tt.wantOk)
+		}
+		if got != tt.want {
+			t.Errorf("#%d: got %+v want %+v", i, got, tt.want)
+		}
+	}
+}
+
+func TestBounds(t *testing.T) {
+	tests := []struct {
+		feature *mapbox.GeocodeFeature
+		sw, ne  mapbox.Coordinate
+		wantOk  bool
+	}{
+		0: {
+			feature: &mapbox.GeocodeFeature{
+				BoundingBox: []float32{-118.6682, 33.7036, -118.1553, 34.3373},
+			},
+			sw:     mapbox.Coordinate{Lon: -118.6682, Lat: 33.7036},
+			ne:     mapbox.Coordinate{Lon: -118.1553, Lat: 34.3373},
+			wantOk: true,
+		},
+		1: {feature: &mapbox.GeocodeFeature{BoundingBox: []float32{-118.6682, 33.7036}}},
+		2: {feature: &mapbox.GeocodeFeature{}},
+		3: {feature: nil},
+	}
+
+	for i, tt := range tests {
+		sw, ne, ok := tt.feature.Bounds()
+		if ok != tt.wantOk {
+			t.Errorf("#%d: ok got %v want %v", i, ok, tt.wantOk)
+		}
+		if sw != tt.sw || ne != tt.ne {
+			t.Errorf("#%d: got (%+v, %+v) want (%+v, %+v)", i, sw, ne, tt.sw, tt.ne)
+		}
+	}
+}
+
+func TestHaversine(t *testing.T) {
+	var (
+		london  = &mapbox.LatLonPair{-0.1278, 51.5074}
+		paris   = &mapbox.LatLonPair{2.3522, 48.8566}
+		newYork = &mapbox.LatLonPair{-74.0060, 40.7128}
+		losAng  = &mapbox.LatLonPair{-118.2437, 34.0522}
+		sydney  = &mapbox.LatLonPair{151.2093, -33.8688}
+	)
+
+	tests := []struct {
+		a, b *mapbox.LatLonPair
+		want float64 // In meters.
+	}{
+		0: {a: london, b: paris, want: 343.5e3},
+		1: {a: newYork, b: losAng, want: 3935.7e3},
+		2: {a: london, b: sydney, want: 16993.9e3},
+		3: {a: paris, b: paris, want: 0},
+	}
+
+	for i, tt := range tests {
+		// Allow for the float32 coordinates and the
+		// Earth not quite being a sphere, within 0.5%.
+		tolerance := tt.want * 0.005
+		got := mapbox.Haversine(tt.a, tt.b)
+		if math.Abs(got-tt.want) > tolerance {
+			t.Errorf("#%d: got %.0fm want %.0fm", i, got, tt.want)
+		}
+		if back := tt.b.DistanceTo(tt.a); math.Abs(back-got) > 1e-6 {
+			t.Errorf("#%d: DistanceTo in reverse got %.0fm want %.0fm", i, back, got)
+		}
+	}
+
+	malformed := []*mapbox.LatLonPair{nil, {}, {-0.1278}}
+	for i, llp := range malformed {
+		if got := mapbox.Haversine(llp, london); !math.IsNaN(got) {
+			t.Errorf("malformed #%d: got %v want NaN", i, got)
+		}
+		if got := mapbox.Haversine(london, llp); !math.IsNaN(got) {
+			t.Errorf("malformed #%d reversed: got %v want NaN", i, got)
+		}
+	}
+}
+
+func TestWithCoordinatePrecision(t *testing.T) {
+	tests := []struct {
+		opts          []mapbox.Option
+		lat, lon      float64
+		wantPath      string
+		wantProximity string
+		wantBody      string
+	}{
+		// The default of 6 decimals.
+		0: {
+			lat: 38.897663, lon: -77.03653,
+			wantPath:      "/geocoding/v5/mapbox.places/-77.03653,38.897663.json",
+			wantProximity: "-77.03653,38.897663",
+			wantBody:      `{"coordinates":[[-77.03653,38.897663],[13.41894,52.50055]]}`,
+		},
+		1: {
+			opts: []mapbox.Option{mapbox.WithCoordinatePrecision(5)},
+			lat:  38.897663, lon: -77.03653,
+			wantPath:      "/geocoding/v5/mapbox.places/-77.03653,38.89766.json",
+			wantProximity: "-77.03653,38.89766",
+			wantBody:      `{"coordinates":[[-77.03653,38.89766],[13.41894,52.50055]]}`,
+		},
+		// Whole numbers don't get trailing zeros.
+		2: {
+			opts: []mapbox.Option{mapbox.WithCoordinatePrecision(5)},
+			lat:  30, lon: -40,
+			wantPath:      "/geocoding/v5/mapbox.places/-40,30.json",
+			wantProximity: "-40,30",
+			wantBody:      `{"coordinates":[[-40,30],[13.41894,52.50055]]}`,
+		},
+	}
+
+	for i, tt := range tests {
+		var gotPath, gotProximity, gotBody string
+		opts := append([]mapbox.Option{mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				if req.Body != nil {
+					blob, _ := ioutil.ReadAll(req.Body)
+					gotBody = string(blob)
+					return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(`{"code": "Ok"}`))), nil
+				}
+				gotPath, gotProximity = req.URL.Path, req.URL.Query().Get("proximity")
+				return respFromFileContents(geocodeResponsePath("LA"))
+			}),
+		})}, tt.opts...)
+		client, err := mapbox.NewClient(opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx := context.Background()
+		if _, err := client.LookupLatLon(ctx, tt.lat, tt.lon); err != nil {
+			t.Errorf("#%d: reverse: %v", i, err)
+			continue
+		}
+		if gotPath != tt.wantPath {
+			t.Errorf("#%d: path got %q want %q", i, gotPath, tt.wantPath)
+		}
+
+		point := &mapbox.LatLonPair{float32(tt.lon), float32(tt.lat)}
+		_, err = client.ForwardGeocode(ctx, &mapbox.ForwardGeocodeRequest{
+			Query:   "coffee",
+			Request: &mapbox.GeocodeRequest{Proximity: point},
+		})
+		if err != nil {
+			t.Errorf("#%d: forward: %v", i, err)
+			continue
+		}
+		if gotProximity != tt.wantProximity {
+			t.Errorf("#%d: proximity got %q want %q", i, gotProximity, tt.wantProximity)
+		}
+
+		_, err = client.Matrix(ctx, &mapbox.MatrixRequest{
+			Coordinates: []*mapbox.LatLonPair{point, {13.41894, 52.50055}},
+		})
+		if err != nil {
+			t.Errorf("#%d: matrix: %v", i, err)
+			continue
+		}
+		if gotBody != tt.wantBody {
+			t.Errorf("#%d: matrix body\ngot:  %s\nwant: %s", i, gotBody, tt.wantBody)
+		}
+	}
+}
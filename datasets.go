@@ -0,0 +1,277 @@
+package mapbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	ScopeDatasetsRead  = "datasets:read"
+	ScopeDatasetsWrite = "datasets:write"
+)
+
+// DatasetFeature is a GeoJSON feature stored in a dataset.
+type DatasetFeature struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Geometry   *Geometry       `json:"geometry"`
+	Properties GeocodeProperty `json:"properties"`
+}
+
+type datasetFeatureCollection struct {
+	Type     string            `json:"type"`
+	Features []*DatasetFeature `json:"features"`
+}
+
+type ListFeaturesOptions struct {
+	// Start if set is the token returned
+	// by ListFeatures for the next page.
+	Start string `json:"start,omitempty"`
+
+	// Limit if set is the maximum
+	// number of features per page.
+	Limit uint `json:"limit,omitempty"`
+}
+
+// ScopeError is returned when the access token
+// lacks the Scope that the request needs.
+type ScopeError struct {
+	Scope string
+
+	// Err is the forbidden response, if the request was made.
+	Err *MapboxError
+}
+
+func (se *ScopeError) Error() string {
+	if se.Err == nil {
+		return fmt.Sprintf("mapbox: the access token needs the %q scope", se.Scope)
+	}
+	return fmt.Sprintf("mapbox: the access token needs the %q scope: %s", se.Scope, se.Err.Message)
+}
+
+func (se *ScopeError) Unwrap() error {
+	if se.Err == nil {
+		return nil
+	}
+	return se.Err
+}
+
+var (
+	errDatasetID        = errors.New("mapbox: expecting a non-empty username and dataset ID")
+	errDatasetFeatureID = errors.New("mapbox: expecting a non-empty feature ID")
+	errNilFeature       = errors.New("mapbox: expecting a non-nil DatasetFeature")
+)
+
+// datasetURL returns the URL of the dataset's features, or of
+// the feature with featureID if it is set, with the query values.
+func (c *Client) datasetURL(username, datasetID, featureID string, values url.Values) string {
+	outURL := fmt.Sprintf("%s/datasets/v1/%s/%s/features", c._baseURL(), url.PathEscape(username), url.PathEscape(datasetID))
+	if featureID != "" {
+		outURL += "/" + url.PathEscape(featureID)
+	}
+	return outURL + "?" + values.Encode()
+}
+
+// checkScope returns a *ScopeError for writes with a public
+// "pk." access token, which can never have write scopes.
+func (c *Client) checkScope(ctx context.Context, scope string) error {
+	if scope == ScopeDatasetsWrite && strings.HasPrefix(c.apiKeyFor(ctx), "pk.") {
+		return &ScopeError{Scope: scope}
+	}
+	return nil
+}
+
+// datasetRequest makes the request, decoding the response into
+// recv unless it is nil, after checking that the token may have scope.
+func (c *Client) datasetRequest(ctx context.Context, span *trace.Span, scope, method, outURL string, body []byte, recv interface{}) error {
+	if err := c.checkScope(ctx, scope); err != nil {
+		span.Annotate(nil, "Missing scope")
+		span.SetStatus(trace.Status{Code: trace.StatusCodePermissionDenied, Message: err.Error()})
+		return err
+	}
+
+	var err error
+	switch {
+	case recv == nil:
+		var res *http.Response
+		res, err = c.doHTTPRequest(ctx, span, method, outURL, nil)
+		if err == nil {
+			drainAndClose(res.Body)
+		}
+	case body != nil:
+		err = c.doRequest(ctx, span, method, outURL, bytes.NewReader(body), recv)
+	default:
+		err = c.doRequest(ctx, span, method, outURL, nil, recv)
+	}
+	return scopeError(scope, err)
+}
+
+// scopeError converts a forbidden response into a *ScopeError for scope.
+func scopeError(scope string, err error) error {
+	if me, ok := err.(*MapboxError); ok && me.StatusCode == http.StatusForbidden {
+		return &ScopeError{Scope: scope, Err: me}
+	}
+	return err
+}
+
+func validateDataset(username, datasetID string) error {
+	if username == "" || datasetID == "" {
+		return errDatasetID
+	}
+	return nil
+}
+
+// ListFeatures returns a page of the dataset's features, along with
+// the Start token for the next page, which is empty for the last page.
+// Request format:
+// GET /datasets/v1/{username}/{dataset_id}/features
+func (c *Client) ListFeatures(ctx context.Context, username, datasetID string, opts *ListFeaturesOptions) ([]*DatasetFeature, string, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).ListFeatures")
+	defer span.End()
+
+	if err := validateDataset(username, datasetID); err != nil {
+		span.Annotate(nil, "Invalid request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
+		return nil, "", err
+	}
+	values := make(url.Values)
+	if opts != nil {
+		if opts.Start != "" {
+			values.Add("start", opts.Start)
+		}
+		if opts.Limit > 0 {
+			values.Add("limit", strconv.FormatUint(uint64(opts.Limit), 10))
+		}
+	}
+	values.Add("access_token", c.apiKeyFor(ctx))
+
+	res, err := c.doHTTPRequest(ctx, span, "GET", c.datasetURL(username, datasetID, "", values), nil)
+	if err != nil {
+		return nil, "", scopeError(ScopeDatasetsRead, err)
+	}
+	defer drainAndClose(res.Body)
+
+	fc := new(datasetFeatureCollection)
+	if err := decodeResponse(span, res, res.Body, fc); err != nil {
+		return nil, "", err
+	}
+	span.SetStatus(trace.Status{Code: trace.StatusCodeOK})
+	next, _ := parseNextLink(res.Header)
+	return fc.Features, next, nil
+}
+
+// GetFeature returns the dataset's feature with featureID,
+// or an error matching ErrNotFound if there is none.
+// Request format:
+// GET /datasets/v1/{username}/{dataset_id}/features/{feature_id}
+func (c *Client) GetFeature(ctx context.Context, username, datasetID, featureID string) (*DatasetFeature, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).GetFeature")
+	defer span.End()
+
+	err := validateDataset(username, datasetID)
+	if err == nil && featureID == "" {
+		err = errDatasetFeatureID
+	}
+	if err != nil {
+		span.Annotate(nil, "Invalid request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
+		return nil, err
+	}
+
+	values := make(url.Values)
+	values.Add("access_token", c.apiKeyFor(ctx))
+	feature := new(DatasetFeature)
+	outURL := c.datasetURL(username, datasetID, featureID, values)
+	if err := c.datasetRequest(ctx, span, ScopeDatasetsRead, "GET", outURL, nil, feature); err != nil {
+		return nil, err
+	}
+	span.SetStatus(trace.Status{Code: trace.StatusCodeOK})
+	return feature, nil
+}
+
+// PutFeature creates or replaces the dataset's feature with
+// feature.ID, returning the feature as stored. It needs an
+// access token with the ScopeDatasetsWrite scope, otherwise it
+// returns a *ScopeError.
+// Request format:
+// PUT /datasets/v1/{username}/{dataset_id}/features/{feature_id}
+func (c *Client) PutFeature(ctx context.Context, username, datasetID string, feature *DatasetFeature) (*DatasetFeature, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).PutFeature")
+	defer span.End()
+
+	err := validateDataset(username, datasetID)
+	if err == nil && feature == nil {
+		err = errNilFeature
+	}
+	if err == nil && feature.ID == "" {
+		err = errDatasetFeatureID
+	}
+	if err != nil {
+		span.Annotate(nil, "Invalid request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
+		return nil, err
+	}
+
+	body := *feature
+	if body.Type == "" {
+		body.Type = "Feature"
+	}
+	if body.Properties == nil {
+		// Mapbox expects an object rather than null.
+		body.Properties = make(GeocodeProperty)
+	}
+	blob, err := json.Marshal(&body)
+	if err != nil {
+		span.Annotate(nil, "Failed to JSON serialize request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return nil, err
+	}
+
+	values := make(url.Values)
+	values.Add("access_token", c.apiKeyFor(ctx))
+	stored := new(DatasetFeature)
+	outURL := c.datasetURL(username, datasetID, feature.ID, values)
+	if err := c.datasetRequest(ctx, span, ScopeDatasetsWrite, "PUT", outURL, blob, stored); err != nil {
+		return nil, err
+	}
+	span.SetStatus(trace.Status{Code: trace.StatusCodeOK})
+	return stored, nil
+}
+
+// DeleteFeature removes the dataset's feature with featureID.
+// It needs an access token with the ScopeDatasetsWrite scope,
+// otherwise it returns a *ScopeError.
+// Request format:
+// DELETE /datasets/v1/{username}/{dataset_id}/features/{feature_id}
+func (c *Client) DeleteFeature(ctx context.Context, username, datasetID, featureID string) error {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).DeleteFeature")
+	defer span.End()
+
+	err := validateDataset(username, datasetID)
+	if err == nil && featureID == "" {
+		err = errDatasetFeatureID
+	}
+	if err != nil {
+		span.Annotate(nil, "Invalid request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
+		return err
+	}
+
+	values := make(url.Values)
+	values.Add("access_token", c.apiKeyFor(ctx))
+	outURL := c.datasetURL(username, datasetID, featureID, values)
+	if err := c.datasetRequest(ctx, span, ScopeDatasetsWrite, "DELETE", outURL, nil, nil); err != nil {
+		return err
+	}
+	span.SetStatus(trace.Status{Code: trace.StatusCodeOK})
+	return nil
+}
@@ -0,0 +1,214 @@
+package mapbox_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/orijtech/mapbox"
+)
+
+// datasetBackend is an in-memory store of a dataset's features.
+type datasetBackend struct {
+	mu       sync.Mutex
+	features map[string][]byte
+	methods  []string
+}
+
+func (db *datasetBackend) roundTrip(req *http.Request) (*http.Response, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.methods = append(db.methods, req.Method)
+	const prefix = "/datasets/v1/orijtech/stores/features/"
+	if !strings.HasPrefix(req.URL.Path, prefix) {
+		return makeResp("404 Not Found", http.StatusNotFound, http.NoBody), nil
+	}
+	id := strings.TrimPrefix(req.URL.Path, prefix)
+	switch req.Method {
+	case "PUT":
+		if g, w := req.Header.Get("Content-Type"), "application/json"; g != w {
+			return makeResp("400 Bad Request", http.StatusBadRequest, http.NoBody), nil
+		}
+		blob, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		db.features[id] = blob
+		return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(string(blob)))), nil
+	case "GET":
+		blob, ok := db.features[id]
+		if !ok {
+			body := ioutil.NopCloser(strings.NewReader(`{"message": "Feature does not exist"}`))
+			return makeResp("404 Not Found", http.StatusNotFound, body), nil
+		}
+		return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(string(blob)))), nil
+	case "DELETE":
+		delete(db.features, id)
+		return makeResp("204 No Content", http.StatusNoContent, http.NoBody), nil
+	default:
+		return makeResp("405 Method Not Allowed", http.StatusMethodNotAllowed, http.NoBody), nil
+	}
+}
+
+func TestDatasetFeatureRoundTrip(t *testing.T) {
+	backend := &datasetBackend{features: make(map[string][]byte)}
+	client, err := mapbox.NewClient(
+		mapbox.WithAPIKey("sk.test-key"),
+		mapbox.WithHTTPClient(&http.Client{Transport: roundTrip(backend.roundTrip)}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	feature := &mapbox.DatasetFeature{
+		ID:         "store-1",
+		Geometry:   &mapbox.Geometry{Type: mapbox.GeometryPoint, Coordinates: []float32{-77.0366, 38.8971}},
+		Properties: mapbox.GeocodeProperty{"name": "Downtown", "open": true},
+	}
+	stored, err := client.PutFeature(ctx, "orijtech", "stores", feature)
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if g, w := stored.Type, "Feature"; g != w {
+		t.Errorf("stored type got %q want %q", g, w)
+	}
+
+	got, err := client.GetFeature(ctx, "orijtech", "stores", "store-1")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if !reflect.DeepEqual(got, stored) {
+		t.Errorf("get\ngot:  %+v\nwant: %+v", got, stored)
+	}
+	if got.ID != feature.ID || !reflect.DeepEqual(got.Geometry, feature.Geometry) || got.Properties["name"] != "Downtown" {
+		t.Errorf("got %+v, not the feature that was put", got)
+	}
+
+	if err := client.DeleteFeature(ctx, "orijtech", "stores", "store-1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := client.GetFeature(ctx, "orijtech", "stores", "store-1"); !errors.Is(err, mapbox.ErrNotFound) {
+		t.Errorf("get after delete: got err %v want one matching %v", err, mapbox.ErrNotFound)
+	}
+
+	if g, w := backend.methods, []string{"PUT", "GET", "DELETE", "GET"}; !reflect.DeepEqual(g, w) {
+		t.Errorf("methods got %q want %q", g, w)
+	}
+}
+
+func TestListFeatures(t *testing.T) {
+	var gotPath, gotStart string
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			gotPath = req.URL.Path
+			gotStart = req.URL.Query().Get("start")
+			fc := map[string]interface{}{
+				"type": "FeatureCollection",
+				"features": []interface{}{
+					map[string]interface{}{
+						"id":         "store-2",
+						"type":       "Feature",
+						"geometry":   map[string]interface{}{"type": "Point", "coordinates": []float32{-77.03, 38.9}},
+						"properties": map[string]interface{}{"name": "Uptown"},
+					},
+				},
+			}
+			res := makeResp("200 OK", http.StatusOK, ioutil.NopCloser(bytes.NewReader(jsonMarshal(fc))))
+			res.Header.Set("Link", `<https://api.mapbox.com/datasets/v1/orijtech/stores/features?start=store-2>; rel="next"`)
+			return res, nil
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	features, next, err := client.ListFeatures(context.Background(), "orijtech", "stores", &mapbox.ListFeaturesOptions{Start: "store-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, w := gotPath, "/datasets/v1/orijtech/stores/features"; g != w {
+		t.Errorf("path got %q want %q", g, w)
+	}
+	if g, w := gotStart, "store-1"; g != w {
+		t.Errorf("start got %q want %q", g, w)
+	}
+	if g, w := next, "store-2"; g != w {
+		t.Errorf("next got %q want %q", g, w)
+	}
+	if len(features) != 1 || features[0].ID != "store-2" || features[0].Properties["name"] != "Uptown" {
+		t.Errorf("got features %s", jsonMarshal(features))
+	}
+}
+
+func TestDatasetScopeErrors(t *testing.T) {
+	forbidden := func(req *http.Request) (*http.Response, error) {
+		body := ioutil.NopCloser(strings.NewReader(`{"message": "This endpoint requires a token with datasets:write scope"}`))
+		return makeResp("403 Forbidden", http.StatusForbidden, body), nil
+	}
+	feature := &mapbox.DatasetFeature{ID: "store-1", Geometry: &mapbox.Geometry{Type: mapbox.GeometryPoint, Coordinates: []float32{0, 0}}}
+
+	tests := []struct {
+		apiKey      string
+		wantRequest bool
+	}{
+		// A public token can never have a write scope.
+		0: {apiKey: "pk.test-key", wantRequest: false},
+		1: {apiKey: "sk.test-key", wantRequest: true},
+	}
+
+	for i, tt := range tests {
+		requests := 0
+		client, err := mapbox.NewClient(
+			mapbox.WithAPIKey(tt.apiKey),
+			mapbox.WithHTTPClient(&http.Client{
+				Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+					requests++
+					return forbidden(req)
+				}),
+			}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx := context.Background()
+		_, putErr := client.PutFeature(ctx, "orijtech", "stores", feature)
+		deleteErr := client.DeleteFeature(ctx, "orijtech", "stores", "store-1")
+		for j, err := range []error{putErr, deleteErr} {
+			se := new(mapbox.ScopeError)
+			if !errors.As(err, &se) {
+				t.Errorf("#%d.%d: got err %v (%T) want a *ScopeError", i, j, err, err)
+				continue
+			}
+			if g, w := se.Scope, mapbox.ScopeDatasetsWrite; g != w {
+				t.Errorf("#%d.%d: scope got %q want %q", i, j, g, w)
+			}
+			if tt.wantRequest && !errors.Is(err, mapbox.ErrForbidden) {
+				t.Errorf("#%d.%d: got err %v want one matching %v", i, j, err, mapbox.ErrForbidden)
+			}
+		}
+		if g := requests > 0; g != tt.wantRequest {
+			t.Errorf("#%d: made %d requests", i, requests)
+		}
+	}
+}
+
+func TestDatasetFeatureJSON(t *testing.T) {
+	blob := `{"id":"a","type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},"properties":{"k":"v"}}`
+	feature := new(mapbox.DatasetFeature)
+	if err := json.Unmarshal([]byte(blob), feature); err != nil {
+		t.Fatal(err)
+	}
+	if g := string(jsonMarshal(feature)); g != blob {
+		t.Errorf("got %s\nwant %s", g, blob)
+	}
+}
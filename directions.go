@@ -0,0 +1,416 @@
+package mapbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.opencensus.io/trace"
+)
+
+type DirectionsRequest struct {
+	// Profile is one of ProfileDriving, ProfileDrivingTraffic,
+	// ProfileWalking or ProfileCycling. If unset, it
+	// defaults to ProfileDriving.
+	Profile Profile `json:"profile,omitempty"`
+
+	// Coordinates are the 2 to 25 [lon, lat]
+	// pairs to route through, in order.
+	Coordinates []*LatLonPair `json:"coordinates"`
+
+	// Alternatives if set requests up to 2
+	// other routes besides the best one.
+	Alternatives bool `json:"alternatives,omitempty"`
+
+	// Steps if set populates each RouteLeg's
+	// Steps with the turn-by-turn maneuvers.
+	Steps bool `json:"steps,omitempty"`
+
+	// VoiceInstructions and BannerInstructions if set populate
+	// each RouteStep's spoken and visual guidance, and require Steps.
+	VoiceInstructions  bool `json:"voice_instructions,omitempty"`
+	BannerInstructions bool `json:"banner_instructions,omitempty"`
+
+	// Exclude if set are the kinds of roads to avoid e.g.
+	// ExcludeToll. Which are allowed depends on the profile,
+	// with the driving ones allowing them all, cycling only
+	// allowing ExcludeFerry and walking allowing none.
+	Exclude []string `json:"exclude,omitempty"`
+
+	// ExcludePoints if set are up to 50 [lon, lat] pairs, such as
+	// road closures, that the route must avoid. They are sent along
+	// with Exclude, and only the driving profiles support them.
+	ExcludePoints []*LatLonPair `json:"-"`
+
+	// Approaches if set has one of ApproachUnrestricted,
+	// ApproachCurb or "" for the default, per coordinate.
+	Approaches []string `json:"approaches,omitempty"`
+
+	// Bearings if set has an {angle, range} per coordinate, in
+	// degrees, for the direction of travel when snapping it. An
+	// empty {0, 0} entry leaves its coordinate unconstrained.
+	Bearings [][2]uint `json:"bearings,omitempty"`
+
+	// Radiuses if set has the maximum distance in meters, per
+	// coordinate, that it can be snapped by, with RadiusUnlimited
+	// for no limit and 0 for the default.
+	Radiuses []float32 `json:"radiuses,omitempty"`
+
+	// Geometries is the format of the routes' geometries, one of
+	// GeometriesPolyline, GeometriesPolyline6 or GeometriesGeoJSON,
+	// and defaults to GeometriesPolyline6.
+	Geometries string `json:"geometries,omitempty"`
+
+	// Overview is the detail of the routes' geometries, one of
+	// OverviewSimplified, OverviewFull or OverviewFalse for none,
+	// and defaults to OverviewSimplified.
+	Overview string `json:"overview,omitempty"`
+
+	// Annotations if set are the per segment values to return in
+	// each RouteLeg's Annotation, any of AnnotationDuration,
+	// AnnotationDistance, AnnotationSpeed and AnnotationCongestion.
+	// AnnotationCongestion requires the "driving-traffic" profile
+	// and OverviewFull.
+	Annotations []string `json:"annotations,omitempty"`
+
+	// Waypoints if set are the indices of the coordinates that
+	// are stops, in increasing order from the first coordinate
+	// to the last, with the others being via points that the
+	// route passes through without splitting it into legs.
+	Waypoints []uint `json:"waypoints,omitempty"`
+
+	// WaypointNames if set names each waypoint, or each coordinate
+	// if Waypoints is unset, for use in the instructions.
+	WaypointNames []string `json:"waypoint_names,omitempty"`
+
+	// ContinueStraight if set, to true or false, allows or forbids
+	// U-turns when departing the intermediate waypoints, otherwise
+	// the profile's default is used. It requires more than 2 waypoints.
+	ContinueStraight *bool `json:"continue_straight,omitempty"`
+
+	// RoundaboutExits if set adds an "exit roundabout" or "exit
+	// rotary" step for leaving each roundabout, separate from
+	// the step entering it.
+	RoundaboutExits bool `json:"roundabout_exits,omitempty"`
+}
+
+const (
+	OverviewSimplified = "simplified"
+	OverviewFull       = "full"
+	OverviewFalse      = "false"
+)
+
+const (
+	GeometriesPolyline  = "polyline"
+	GeometriesPolyline6 = "polyline6"
+	GeometriesGeoJSON   = "geojson"
+)
+
+type DirectionsResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+
+	// Routes are ordered from the best, with any
+	// alternatives requested following it.
+	Routes []*Route `json:"routes,omitempty"`
+
+	// Waypoints are where each of the
+	// coordinates snapped to, in order.
+	Waypoints []*Waypoint `json:"waypoints,omitempty"`
+}
+
+const (
+	ExcludeMotorway      = "motorway"
+	ExcludeToll          = "toll"
+	ExcludeFerry         = "ferry"
+	ExcludeUnpaved       = "unpaved"
+	ExcludeCashOnlyTolls = "cash_only_tolls"
+)
+
+const maxExcludePoints = 50
+
+var errExcludePointsProfile = errors.New("mapbox: only the driving profiles can exclude points")
+
+var drivingExcludes = []string{ExcludeMotorway, ExcludeToll, ExcludeFerry, ExcludeUnpaved, ExcludeCashOnlyTolls}
+
+// allowedExcludes are the excludes that each profile accepts.
+var allowedExcludes = map[Profile][]string{
+	ProfileDriving:        drivingExcludes,
+	ProfileDrivingTraffic: drivingExcludes,
+	ProfileCycling:        {ExcludeFerry},
+	ProfileWalking:        nil,
+}
+
+func validateExclude(profile Profile, exclude []string) error {
+	allowed := allowedExcludes[profile]
+	for _, ex := range exclude {
+		ok := false
+		for _, a := range allowed {
+			if ex == a {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return fmt.Errorf("mapbox: the %q profile cannot exclude %q", profile, ex)
+		}
+	}
+	return nil
+}
+
+const (
+	minDirectionsCoordinates = 2
+	maxDirectionsCoordinates = 25
+)
+
+var (
+	errNilDirectionsRequest  = errors.New("mapbox: expecting a non-nil DirectionsRequest")
+	errDirectionsCoordinates = fmt.Errorf("mapbox: expecting between %d and %d coordinates", minDirectionsCoordinates, maxDirectionsCoordinates)
+	errWaypointEnds          = errors.New("mapbox: waypoints must include the first and last coordinates")
+	errWaypointNamesCount    = errors.New("mapbox: expecting exactly one name per waypoint")
+	errInstructionsSteps     = errors.New("mapbox: voice and banner instructions require Steps")
+	errContinueStraight      = errors.New("mapbox: continue_straight requires more than 2 waypoints")
+)
+
+func (dreq *DirectionsRequest) validate() error {
+	if dreq == nil {
+		return errNilDirectionsRequest
+	}
+	if err := validateProfile(dreq.Profile); err != nil {
+		return err
+	}
+	if n := len(dreq.Coordinates); n < minDirectionsCoordinates || n > maxDirectionsCoordinates {
+		return errDirectionsCoordinates
+	}
+	for i, coord := range dreq.Coordinates {
+		if coord == nil || len(*coord) != 2 {
+			return fmt.Errorf("mapbox: coordinate #%d is not a [lon, lat] pair", i)
+		}
+	}
+	switch dreq.Geometries {
+	case "", GeometriesPolyline, GeometriesPolyline6, GeometriesGeoJSON:
+	default:
+		return fmt.Errorf("mapbox: unknown geometries %q", dreq.Geometries)
+	}
+	switch dreq.Overview {
+	case "", OverviewSimplified, OverviewFull, OverviewFalse:
+	default:
+		return fmt.Errorf("mapbox: unknown overview %q", dreq.Overview)
+	}
+	if err := validateApproaches(dreq.Approaches, len(dreq.Coordinates)); err != nil {
+		return err
+	}
+	if err := validateBearings(dreq.Bearings, len(dreq.Coordinates)); err != nil {
+		return err
+	}
+	if err := validateRadiuses(dreq.Radiuses, len(dreq.Coordinates)); err != nil {
+		return err
+	}
+	if err := dreq.validateWaypoints(); err != nil {
+		return err
+	}
+	if (dreq.VoiceInstructions || dreq.BannerInstructions) && !dreq.Steps {
+		return errInstructionsSteps
+	}
+	profile := dreq.Profile
+	if profile == "" {
+		profile = defaultProfile
+	}
+	if err := dreq.validateAnnotations(profile); err != nil {
+		return err
+	}
+	if err := validateExclude(profile, dreq.Exclude); err != nil {
+		return err
+	}
+	return dreq.validateExcludePoints(profile)
+}
+
+func (dreq *DirectionsRequest) validateExcludePoints(profile Profile) error {
+	if len(dreq.ExcludePoints) == 0 {
+		return nil
+	}
+	if profile != ProfileDriving && profile != ProfileDrivingTraffic {
+		return errExcludePointsProfile
+	}
+	if n := len(dreq.ExcludePoints); n > maxExcludePoints {
+		return fmt.Errorf("mapbox: at most %d points can be excluded, got %d", maxExcludePoints, n)
+	}
+	for i, point := range dreq.ExcludePoints {
+		if point == nil || len(*point) != 2 {
+			return fmt.Errorf("mapbox: exclude point #%d is not a [lon, lat] pair", i)
+		}
+		// A point can't be both avoided and routed through.
+		for j, coord := range dreq.Coordinates {
+			if (*coord)[0] == (*point)[0] && (*coord)[1] == (*point)[1] {
+				return fmt.Errorf("mapbox: exclude point #%d is also coordinate #%d", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// excludeParam joins the excluded road kinds and
+// points, the latter formatted as "point(lon lat)".
+func (c *Client) excludeParam(dreq *DirectionsRequest) string {
+	excludes := append([]string(nil), dreq.Exclude...)
+	for _, point := range dreq.ExcludePoints {
+		lonLat := strings.Replace(c.formatPair(*point), ",", " ", 1)
+		excludes = append(excludes, "point("+lonLat+")")
+	}
+	return strings.Join(excludes, ",")
+}
+
+func (dreq *DirectionsRequest) validateWaypoints() error {
+	n := len(dreq.Coordinates)
+	if len(dreq.Waypoints) > 0 {
+		if dreq.Waypoints[0] != 0 || int(dreq.Waypoints[len(dreq.Waypoints)-1]) != n-1 {
+			return errWaypointEnds
+		}
+		for i, index := range dreq.Waypoints {
+			if int(index) >= n {
+				return fmt.Errorf("mapbox: waypoint index %d is out of range of %d coordinates", index, n)
+			}
+			if i > 0 && index <= dreq.Waypoints[i-1] {
+				return fmt.Errorf("mapbox: waypoint index %d is not after %d", index, dreq.Waypoints[i-1])
+			}
+		}
+		n = len(dreq.Waypoints)
+	}
+	if dreq.ContinueStraight != nil && n <= 2 {
+		return errContinueStraight
+	}
+	if len(dreq.WaypointNames) == 0 {
+		return nil
+	}
+	if len(dreq.WaypointNames) != n {
+		return errWaypointNamesCount
+	}
+	for i, name := range dreq.WaypointNames {
+		if strings.Contains(name, ";") {
+			return fmt.Errorf("mapbox: waypoint name #%d contains the %q separator", i, ";")
+		}
+	}
+	return nil
+}
+
+var errCongestion = errors.New(`mapbox: the congestion annotation requires the "driving-traffic" profile and the full overview`)
+
+func (dreq *DirectionsRequest) validateAnnotations(profile Profile) error {
+	for _, annotation := range dreq.Annotations {
+		switch annotation {
+		case AnnotationDuration, AnnotationDistance, AnnotationSpeed:
+		case AnnotationCongestion:
+			if profile != ProfileDrivingTraffic || dreq.Overview != OverviewFull {
+				return errCongestion
+			}
+		default:
+			return fmt.Errorf("mapbox: unknown annotation %q", annotation)
+		}
+	}
+	return nil
+}
+
+// Directions returns the routes through the coordinates.
+// If no route is found, it returns a *CodeError, or a
+// *NoSegmentError if a coordinate couldn't be snapped.
+// Request format:
+// GET /directions/v5/mapbox/{profile}/{coordinates}
+func (c *Client) Directions(ctx context.Context, dreq *DirectionsRequest) (*DirectionsResponse, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).Directions")
+	defer span.End()
+
+	if err := dreq.validate(); err != nil {
+		span.Annotate(nil, "Invalid request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
+		return nil, err
+	}
+
+	profile := dreq.Profile
+	if profile == "" {
+		profile = defaultProfile
+	}
+
+	geometries := dreq.Geometries
+	if geometries == "" {
+		geometries = GeometriesPolyline6
+	}
+
+	values := make(url.Values)
+	values.Add("geometries", geometries)
+	overview := dreq.Overview
+	if overview == "" {
+		overview = OverviewSimplified
+	}
+	values.Add("overview", overview)
+	if dreq.Alternatives {
+		values.Add("alternatives", "true")
+	}
+	if dreq.Steps {
+		values.Add("steps", "true")
+	}
+	if dreq.VoiceInstructions {
+		values.Add("voice_instructions", "true")
+	}
+	if dreq.BannerInstructions {
+		values.Add("banner_instructions", "true")
+	}
+	if len(dreq.Exclude) > 0 || len(dreq.ExcludePoints) > 0 {
+		values.Add("exclude", c.excludeParam(dreq))
+	}
+	if len(dreq.Annotations) > 0 {
+		values.Add("annotations", strings.Join(dreq.Annotations, ","))
+	}
+	if len(dreq.Approaches) > 0 {
+		values.Add("approaches", strings.Join(dreq.Approaches, ";"))
+	}
+	if len(dreq.Bearings) > 0 {
+		values.Add("bearings", joinBearings(dreq.Bearings))
+	}
+	if len(dreq.Radiuses) > 0 {
+		values.Add("radiuses", joinRadiuses(dreq.Radiuses))
+	}
+	if len(dreq.Waypoints) > 0 {
+		values.Add("waypoints", joinIndices(dreq.Waypoints))
+	}
+	if len(dreq.WaypointNames) > 0 {
+		values.Add("waypoint_names", strings.Join(dreq.WaypointNames, ";"))
+	}
+	if dreq.ContinueStraight != nil {
+		values.Add("continue_straight", strconv.FormatBool(*dreq.ContinueStraight))
+	}
+	if dreq.RoundaboutExits {
+		values.Add("roundabout_exits", "true")
+	}
+	values.Add("access_token", c.apiKeyFor(ctx))
+
+	outURL := fmt.Sprintf("%s/directions/v5/mapbox/%s/%s?%s",
+		c._baseURL(), profile, c.coordinatesPath(dreq.Coordinates), values.Encode())
+
+	dres := new(DirectionsResponse)
+	if err := c.doRequest(ctx, span, "GET", outURL, nil, dres); err != nil {
+		return nil, err
+	}
+	if dres.Code != CodeOk {
+		err := codeError(dres.Code, dres.Message, dreq.Radiuses)
+		span.SetStatus(trace.Status{Code: trace.StatusCodeNotFound, Message: err.Error()})
+		return nil, err
+	}
+	var indices []uint
+	if len(dres.Waypoints) == len(dreq.Waypoints) {
+		// Only the stops, rather than the via points, snapped.
+		indices = dreq.Waypoints
+	}
+	setDistancesToInput(dres.Waypoints, dreq.Coordinates, indices)
+	if geometries == GeometriesPolyline6 {
+		for _, route := range dres.Routes {
+			if route != nil {
+				route.precision = 6
+			}
+		}
+	}
+	span.SetStatus(trace.Status{Code: trace.StatusCodeOK})
+	return dres, nil
+}
@@ -0,0 +1,910 @@
+package mapbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/orijtech/mapbox"
+)
+
+func TestDirectionsSteps(t *testing.T) {
+	var gotURL string
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			gotURL = req.URL.String()
+			return respFromFileContents("./testdata/directions-SF.json")
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dres, err := client.Directions(context.Background(), &mapbox.DirectionsRequest{
+		Coordinates: []*mapbox.LatLonPair{
+			{-122.40252, 37.78881},
+			{-122.39929, 37.79152},
+		},
+		Steps: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantSubstrs := []string{
+		"/directions/v5/mapbox/driving/-122.40252,37.78881;-122.39929,37.79152?",
+		"steps=true",
+	}
+	for _, want := range wantSubstrs {
+		if !strings.Contains(gotURL, want) {
+			t.Errorf("URL %q does not contain %q", gotURL, want)
+		}
+	}
+
+	if g, w := len(dres.Routes), 1; g != w {
+		t.Fatalf("got %d routes want %d", g, w)
+	}
+	legs := dres.Routes[0].Legs
+	if g, w := len(legs), 1; g != w {
+		t.Fatalf("got %d legs want %d", g, w)
+	}
+	steps := legs[0].Steps
+	if g, w := len(steps), 3; g != w {
+		t.Fatalf("got %d steps want %d", g, w)
+	}
+
+	first := steps[0]
+	if g, w := first.Maneuver.Type, "depart"; g != w {
+		t.Errorf("first maneuver type: got %q want %q", g, w)
+	}
+	if g, w := first.Maneuver.Location, (&mapbox.LatLonPair{-122.40252, 37.78881}); !reflect.DeepEqual(g, w) {
+		t.Errorf("first maneuver location: got %v want %v", g, w)
+	}
+	if g, w := first.Name, "Market Street"; g != w {
+		t.Errorf("first step name: got %q want %q", g, w)
+	}
+	turn := steps[1].Maneuver
+	if turn.Type != "turn" || turn.Modifier != "left" || turn.Instruction != "Turn left onto 2nd Street" {
+		t.Errorf("second maneuver: got %+v", turn)
+	}
+	if g, w := steps[2].Maneuver.Type, "arrive"; g != w {
+		t.Errorf("last maneuver type: got %q want %q", g, w)
+	}
+
+	if g, w := len(dres.Waypoints), 2; g != w {
+		t.Fatalf("got %d waypoints want %d", g, w)
+	}
+	last := dres.Waypoints[1]
+	if g, w := last.Name, "2nd Street"; g != w {
+		t.Errorf("last waypoint name: got %q want %q", g, w)
+	}
+	if g, w := last.Location, (&mapbox.LatLonPair{-122.39929, 37.79152}); !reflect.DeepEqual(g, w) {
+		t.Errorf("last waypoint location: got %v want %v", g, w)
+	}
+	if d := last.DistanceToInput; d > 1 {
+		t.Errorf("last waypoint snapped %.2fm from its input", d)
+	}
+}
+
+func TestDirectionsWithoutSteps(t *testing.T) {
+	var gotQuery string
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			gotQuery = req.URL.RawQuery
+			return respFromFileContents("./testdata/directions-SF.json")
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Directions(context.Background(), &mapbox.DirectionsRequest{
+		Coordinates: []*mapbox.LatLonPair{{-122.40252, 37.78881}, {-122.39929, 37.79152}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(gotQuery, "steps") {
+		t.Errorf("query %q unexpectedly asks for steps", gotQuery)
+	}
+}
+
+func TestDirectionsErrors(t *testing.T) {
+	tests := []struct {
+		req      *mapbox.DirectionsRequest
+		body     string
+		wantCode string
+	}{
+		0: {req: nil},
+		1: {req: &mapbox.DirectionsRequest{Coordinates: []*mapbox.LatLonPair{{-122.40252, 37.78881}}}},
+		2: {req: &mapbox.DirectionsRequest{Coordinates: []*mapbox.LatLonPair{{-122.40252, 37.78881}, {-122.39929}}}},
+		3: {
+			req:      &mapbox.DirectionsRequest{Coordinates: []*mapbox.LatLonPair{{-122.40252, 37.78881}, {-157.8583, 21.3069}}},
+			body:     `{"code": "NoRoute", "message": "No route found"}`,
+			wantCode: "NoRoute",
+		},
+	}
+
+	for i, tt := range tests {
+		requests := 0
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				requests++
+				return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(tt.body))), nil
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = client.Directions(context.Background(), tt.req)
+		if err == nil {
+			t.Errorf("#%d: want non-nil error", i)
+			continue
+		}
+		if tt.wantCode == "" {
+			if requests != 0 {
+				t.Errorf("#%d: made %d requests, want none", i, requests)
+			}
+			continue
+		}
+		var ce *mapbox.CodeError
+		if !errors.As(err, &ce) || ce.Code != tt.wantCode {
+			t.Errorf("#%d: got %v want a %s *CodeError", i, err, tt.wantCode)
+		}
+	}
+}
+
+func TestDirectionsExclude(t *testing.T) {
+	tests := []struct {
+		profile       mapbox.Profile
+		exclude       []string
+		excludePoints []*mapbox.LatLonPair
+		wantExclude   string
+		wantErr       bool
+	}{
+		0: {exclude: []string{mapbox.ExcludeToll, mapbox.ExcludeFerry}, wantExclude: "toll,ferry"},
+		1: {profile: "driving-traffic", exclude: []string{mapbox.ExcludeMotorway}, wantExclude: "motorway"},
+		2: {profile: "cycling", exclude: []string{mapbox.ExcludeFerry}, wantExclude: "ferry"},
+		3: {profile: "cycling", exclude: []string{mapbox.ExcludeMotorway}, wantErr: true},
+		4: {profile: "walking", exclude: []string{mapbox.ExcludeFerry}, wantErr: true},
+		5: {exclude: []string{"highway"}, wantErr: true},
+		6: {profile: "walking"},
+		7: {
+			exclude:       []string{mapbox.ExcludeToll},
+			excludePoints: []*mapbox.LatLonPair{{-122.4011, 37.7902}},
+			wantExclude:   "toll,point(-122.4011 37.7902)",
+		},
+		8: {
+			profile:       "driving-traffic",
+			excludePoints: []*mapbox.LatLonPair{{-122.4011, 37.7902}, {-122.4, 37.79}},
+			wantExclude:   "point(-122.4011 37.7902),point(-122.4 37.79)",
+		},
+		9:  {profile: "cycling", excludePoints: []*mapbox.LatLonPair{{-122.4011, 37.7902}}, wantErr: true},
+		10: {excludePoints: []*mapbox.LatLonPair{{-122.4011}}, wantErr: true},
+		// The origin can't be avoided.
+		11: {excludePoints: []*mapbox.LatLonPair{{-122.40252, 37.78881}}, wantErr: true},
+		12: {excludePoints: make([]*mapbox.LatLonPair, 51), wantErr: true},
+	}
+
+	for i, tt := range tests {
+		var gotQuery url.Values
+		requests := 0
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				requests++
+				gotQuery = req.URL.Query()
+				return respFromFileContents("./testdata/directions-SF.json")
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = client.Directions(context.Background(), &mapbox.DirectionsRequest{
+			Profile:       tt.profile,
+			Coordinates:   []*mapbox.LatLonPair{{-122.40252, 37.78881}, {-122.39929, 37.79152}},
+			Exclude:       tt.exclude,
+			ExcludePoints: tt.excludePoints,
+		})
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error", i)
+			}
+			if requests != 0 {
+				t.Errorf("#%d: made %d requests, want none", i, requests)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if g, w := gotQuery.Get("exclude"), tt.wantExclude; g != w {
+			t.Errorf("#%d: exclude got %q want %q", i, g, w)
+		}
+	}
+}
+
+func TestApproachesAndBearings(t *testing.T) {
+	coords := []*mapbox.LatLonPair{
+		{-122.40252, 37.78881},
+		{-122.40071, 37.79033},
+		{-122.39929, 37.79152},
+	}
+	approaches := []string{"", mapbox.ApproachCurb, ""}
+	bearings := [][2]uint{{}, {90, 45}, {}}
+
+	// The query is unescaped as the Matrix one has literal
+	// semicolons, which url.ParseQuery would reject.
+	var gotQuery string
+	requests := 0
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			requests++
+			gotQuery, _ = url.QueryUnescape(req.URL.RawQuery)
+			if strings.Contains(req.URL.Path, "/directions/") {
+				return respFromFileContents("./testdata/directions-SF.json")
+			}
+			return respFromFileContents("./testdata/matrix-3x3.json")
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	requesters := []func(approaches []string, bearings [][2]uint) error{
+		func(approaches []string, bearings [][2]uint) error {
+			_, err := client.Directions(ctx, &mapbox.DirectionsRequest{
+				Coordinates: coords, Approaches: approaches, Bearings: bearings,
+			})
+			return err
+		},
+		func(approaches []string, bearings [][2]uint) error {
+			_, err := client.Matrix(ctx, &mapbox.MatrixRequest{
+				Coordinates: coords, Approaches: approaches, Bearings: bearings,
+			})
+			return err
+		},
+	}
+
+	for i, request := range requesters {
+		if err := request(approaches, bearings); err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		for _, want := range []string{"approaches=;curb;&", "bearings=;90,45;&"} {
+			if !strings.Contains(gotQuery+"&", want) {
+				t.Errorf("#%d: query %q does not contain %q", i, gotQuery, want)
+			}
+		}
+
+		// There must be one entry per coordinate and valid bearings.
+		requests = 0
+		if err := request(approaches[:2], nil); err == nil {
+			t.Errorf("#%d: too few approaches: want non-nil error", i)
+		}
+		if err := request(nil, bearings[:2]); err == nil {
+			t.Errorf("#%d: too few bearings: want non-nil error", i)
+		}
+		if err := request(nil, [][2]uint{{}, {400, 45}, {}}); err == nil {
+			t.Errorf("#%d: out of range bearing: want non-nil error", i)
+		}
+		if requests != 0 {
+			t.Errorf("#%d: made %d invalid requests, want none", i, requests)
+		}
+	}
+}
+
+func TestDirectionsGeometries(t *testing.T) {
+	points := []*mapbox.LatLonPair{
+		{-122.40252, 37.78881},
+		{-122.40113, 37.78999},
+		{-122.40071, 37.79033},
+		{-122.39929, 37.79152},
+	}
+
+	// routeBody responds with the points in the requested format.
+	routeBody := func(geometries string) (string, error) {
+		var geometry []byte
+		var err error
+		switch geometries {
+		case mapbox.GeometriesGeoJSON:
+			geometry, err = json.Marshal(&mapbox.Geometry{Type: mapbox.GeometryLineString, Line: points})
+		case mapbox.GeometriesPolyline:
+			var line string
+			line, err = mapbox.EncodePolyline(points, 5)
+			geometry, _ = json.Marshal(line)
+		default:
+			var line string
+			line, err = mapbox.EncodePolyline(points, 6)
+			geometry, _ = json.Marshal(line)
+		}
+		return fmt.Sprintf(`{"code": "Ok", "routes": [{"distance": 412.6, "geometry": %s}]}`, geometry), err
+	}
+
+	tests := []struct {
+		geometries     string
+		wantGeometries string
+		wantPolyline   bool
+	}{
+		0: {geometries: "", wantGeometries: "polyline6", wantPolyline: true},
+		1: {geometries: mapbox.GeometriesPolyline, wantGeometries: "polyline", wantPolyline: true},
+		2: {geometries: mapbox.GeometriesPolyline6, wantGeometries: "polyline6", wantPolyline: true},
+		3: {geometries: mapbox.GeometriesGeoJSON, wantGeometries: "geojson"},
+	}
+
+	for i, tt := range tests {
+		var gotGeometries string
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				gotGeometries = req.URL.Query().Get("geometries")
+				body, err := routeBody(gotGeometries)
+				if err != nil {
+					return nil, err
+				}
+				return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(body))), nil
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dres, err := client.Directions(context.Background(), &mapbox.DirectionsRequest{
+			Coordinates: []*mapbox.LatLonPair{points[0], points[len(points)-1]},
+			Geometries:  tt.geometries,
+		})
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if gotGeometries != tt.wantGeometries {
+			t.Errorf("#%d: geometries got %q want %q", i, gotGeometries, tt.wantGeometries)
+		}
+
+		route := dres.Routes[0]
+		if gotPolyline := route.Geometry != ""; gotPolyline != tt.wantPolyline {
+			t.Errorf("#%d: got polyline %q, want one: %v", i, route.Geometry, tt.wantPolyline)
+		}
+		if gotGeoJSON := route.GeometryGeoJSON != nil; gotGeoJSON == tt.wantPolyline {
+			t.Errorf("#%d: got GeoJSON %v, want one: %v", i, route.GeometryGeoJSON, !tt.wantPolyline)
+		}
+		decoded, err := route.DecodedGeometry()
+		if err != nil {
+			t.Errorf("#%d: decoding: %v", i, err)
+			continue
+		}
+		if msg := pointsWithin(decoded, points, 6); msg != "" {
+			t.Errorf("#%d: %s", i, msg)
+		}
+	}
+
+	_, err := (&mapbox.Client{}).Directions(context.Background(), &mapbox.DirectionsRequest{
+		Coordinates: points[:2],
+		Geometries:  "wkt",
+	})
+	if err == nil {
+		t.Error("unknown geometries: want non-nil error")
+	}
+}
+
+func TestDirectionsOverview(t *testing.T) {
+	tests := []struct {
+		overview     string
+		wantOverview string
+	}{
+		0: {overview: "", wantOverview: "simplified"},
+		1: {overview: mapbox.OverviewSimplified, wantOverview: "simplified"},
+		2: {overview: mapbox.OverviewFull, wantOverview: "full"},
+		3: {overview: mapbox.OverviewFalse, wantOverview: "false"},
+	}
+
+	for i, tt := range tests {
+		var gotOverview string
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				gotOverview = req.URL.Query().Get("overview")
+				if gotOverview == "false" {
+					// Mapbox leaves out the geometry altogether.
+					body := `{"code": "Ok", "routes": [{"distance": 412.6, "duration": 96.3}]}`
+					return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(body))), nil
+				}
+				return respFromFileContents("./testdata/directions-SF.json")
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dres, err := client.Directions(context.Background(), &mapbox.DirectionsRequest{
+			Coordinates: []*mapbox.LatLonPair{{-122.40252, 37.78881}, {-122.39929, 37.79152}},
+			Overview:    tt.overview,
+		})
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if gotOverview != tt.wantOverview {
+			t.Errorf("#%d: overview got %q want %q", i, gotOverview, tt.wantOverview)
+		}
+
+		route := dres.Routes[0]
+		decoded, err := route.DecodedGeometry()
+		if err != nil {
+			t.Errorf("#%d: decoding: %v", i, err)
+			continue
+		}
+		if wantNone := tt.overview == mapbox.OverviewFalse; wantNone != (route.Geometry == "") || wantNone != (len(decoded) == 0) {
+			t.Errorf("#%d: got geometry %q decoded to %d points", i, route.Geometry, len(decoded))
+		}
+	}
+}
+
+func TestDirectionsAnnotations(t *testing.T) {
+	var gotAnnotations string
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			gotAnnotations = req.URL.Query().Get("annotations")
+			return respFromFileContents("./testdata/directions-traffic.json")
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dres, err := client.Directions(context.Background(), &mapbox.DirectionsRequest{
+		Profile:     "driving-traffic",
+		Coordinates: []*mapbox.LatLonPair{{-122.40252, 37.78881}, {-122.39929, 37.79152}},
+		Overview:    mapbox.OverviewFull,
+		Annotations: []string{mapbox.AnnotationCongestion, mapbox.AnnotationSpeed, mapbox.AnnotationDuration},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, w := gotAnnotations, "congestion,speed,duration"; g != w {
+		t.Errorf("annotations got %q want %q", g, w)
+	}
+
+	route := dres.Routes[0]
+	points, err := route.DecodedGeometry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	segments := len(points) - 1
+	annotation := route.Legs[0].Annotation
+	if annotation == nil {
+		t.Fatal("expected the leg to have an annotation")
+	}
+	if g, w := len(annotation.Congestion), segments; g != w {
+		t.Errorf("got %d congestion values for %d segments", g, w)
+	}
+	if g, w := len(annotation.Speed), segments; g != w {
+		t.Errorf("got %d speeds for %d segments", g, w)
+	}
+	if g, w := annotation.Congestion, []string{"low", "heavy", "moderate"}; !reflect.DeepEqual(g, w) {
+		t.Errorf("congestion got %q want %q", g, w)
+	}
+}
+
+func TestDirectionsAnnotationsValidation(t *testing.T) {
+	coords := []*mapbox.LatLonPair{{-122.40252, 37.78881}, {-122.39929, 37.79152}}
+	tests := []struct {
+		req     *mapbox.DirectionsRequest
+		wantErr bool
+	}{
+		0: {
+			req:     &mapbox.DirectionsRequest{Coordinates: coords, Overview: mapbox.OverviewFull, Annotations: []string{"congestion"}},
+			wantErr: true,
+		},
+		1: {
+			req:     &mapbox.DirectionsRequest{Profile: "driving-traffic", Coordinates: coords, Annotations: []string{"congestion"}},
+			wantErr: true,
+		},
+		2: {
+			req:     &mapbox.DirectionsRequest{Coordinates: coords, Annotations: []string{"traffic"}},
+			wantErr: true,
+		},
+		3: {
+			req: &mapbox.DirectionsRequest{Coordinates: coords, Annotations: []string{"speed", "distance"}},
+		},
+		4: {
+			req: &mapbox.DirectionsRequest{Profile: "driving-traffic", Coordinates: coords, Overview: mapbox.OverviewFull, Annotations: []string{"congestion"}},
+		},
+	}
+
+	for i, tt := range tests {
+		requests := 0
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				requests++
+				return respFromFileContents("./testdata/directions-traffic.json")
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = client.Directions(context.Background(), tt.req)
+		if gotErr := err != nil; gotErr != tt.wantErr {
+			t.Errorf("#%d: got err %v, want error: %v", i, err, tt.wantErr)
+		}
+		if tt.wantErr && requests != 0 {
+			t.Errorf("#%d: made %d requests for an invalid request", i, requests)
+		}
+	}
+}
+
+func TestDirectionsWaypoints(t *testing.T) {
+	// The middle two coordinates are via points.
+	coords := []*mapbox.LatLonPair{
+		{-122.40252, 37.78881},
+		{-122.40071, 37.79033},
+		{-122.40010, 37.79090},
+		{-122.39929, 37.79152},
+	}
+	tests := []struct {
+		waypoints     []uint
+		names         []string
+		wantWaypoints string
+		wantNames     string
+		wantErr       bool
+	}{
+		0: {
+			waypoints:     []uint{0, 3},
+			names:         []string{"Home", "Work"},
+			wantWaypoints: "0;3",
+			wantNames:     "Home;Work",
+		},
+		1: {waypoints: []uint{0, 2, 3}, wantWaypoints: "0;2;3"},
+		// Without Waypoints, every coordinate is named.
+		2: {names: []string{"Home", "", "", "Work"}, wantNames: "Home;;;Work"},
+		3: {waypoints: []uint{1, 3}, wantErr: true},
+		4: {waypoints: []uint{0, 2}, wantErr: true},
+		5: {waypoints: []uint{0, 4}, wantErr: true},
+		6: {waypoints: []uint{0, 2, 1, 3}, wantErr: true},
+		7: {waypoints: []uint{0, 3}, names: []string{"Home"}, wantErr: true},
+		8: {waypoints: []uint{0, 3}, names: []string{"Home", "Work;Office"}, wantErr: true},
+		9: {names: []string{"Home", "Work"}, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		var gotQuery url.Values
+		requests := 0
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				requests++
+				gotQuery = req.URL.Query()
+				return respFromFileContents("./testdata/directions-SF.json")
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = client.Directions(context.Background(), &mapbox.DirectionsRequest{
+			Coordinates:   coords,
+			Waypoints:     tt.waypoints,
+			WaypointNames: tt.names,
+		})
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error", i)
+			}
+			if requests != 0 {
+				t.Errorf("#%d: made %d requests, want none", i, requests)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if g, w := gotQuery.Get("waypoints"), tt.wantWaypoints; g != w {
+			t.Errorf("#%d: waypoints got %q want %q", i, g, w)
+		}
+		if g, w := gotQuery.Get("waypoint_names"), tt.wantNames; g != w {
+			t.Errorf("#%d: waypoint_names got %q want %q", i, g, w)
+		}
+	}
+}
+
+func TestDirectionsInstructions(t *testing.T) {
+	var gotQuery url.Values
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			gotQuery = req.URL.Query()
+			return respFromFileContents("./testdata/directions-voice.json")
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dres, err := client.Directions(context.Background(), &mapbox.DirectionsRequest{
+		Coordinates:        []*mapbox.LatLonPair{{-122.40252, 37.78881}, {-122.39929, 37.79152}},
+		Steps:              true,
+		VoiceInstructions:  true,
+		BannerInstructions: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"voice_instructions", "banner_instructions"} {
+		if g, w := gotQuery.Get(key), "true"; g != w {
+			t.Errorf("%s got %q want %q", key, g, w)
+		}
+	}
+
+	step := dres.Routes[0].Legs[0].Steps[0]
+	if g, w := len(step.VoiceInstructions), 2; g != w {
+		t.Fatalf("got %d voice instructions want %d", g, w)
+	}
+	voice := step.VoiceInstructions[0]
+	if g, w := voice.Announcement, "Head northeast on Market Street, then turn left onto 2nd Street"; g != w {
+		t.Errorf("announcement got %q want %q", g, w)
+	}
+	if g, w := voice.DistanceAlongGeometry, float32(178.2); g != w {
+		t.Errorf("distanceAlongGeometry got %v want %v", g, w)
+	}
+	if !strings.HasPrefix(voice.SSMLAnnouncement, "<speak>") {
+		t.Errorf("ssmlAnnouncement got %q", voice.SSMLAnnouncement)
+	}
+
+	if g, w := len(step.BannerInstructions), 1; g != w {
+		t.Fatalf("got %d banner instructions want %d", g, w)
+	}
+	banner := step.BannerInstructions[0]
+	wantPrimary := &mapbox.Banner{
+		Text:     "2nd Street",
+		Type:     "turn",
+		Modifier: "left",
+		Components: []*mapbox.BannerComponent{
+			{Text: "2nd Street", Type: "text", Abbreviation: "2nd St"},
+		},
+	}
+	if !reflect.DeepEqual(banner.Primary, wantPrimary) {
+		t.Errorf("primary got %+v want %+v", banner.Primary, wantPrimary)
+	}
+	if banner.Sub == nil || len(banner.Sub.Components) != 2 || !banner.Sub.Components[0].Active {
+		t.Errorf("sub got %+v, want the active left lane first", banner.Sub)
+	}
+
+	// The later steps have no instructions.
+	if step := dres.Routes[0].Legs[0].Steps[1]; step.VoiceInstructions != nil || step.BannerInstructions != nil {
+		t.Errorf("step #1 got instructions %+v", step)
+	}
+}
+
+func TestDirectionsInstructionsRequireSteps(t *testing.T) {
+	tests := []struct {
+		voice, banner bool
+	}{
+		0: {voice: true},
+		1: {banner: true},
+		2: {voice: true, banner: true},
+	}
+
+	for i, tt := range tests {
+		requests := 0
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				requests++
+				return respFromFileContents("./testdata/directions-voice.json")
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = client.Directions(context.Background(), &mapbox.DirectionsRequest{
+			Coordinates:        []*mapbox.LatLonPair{{-122.40252, 37.78881}, {-122.39929, 37.79152}},
+			VoiceInstructions:  tt.voice,
+			BannerInstructions: tt.banner,
+		})
+		if err == nil {
+			t.Errorf("#%d: want non-nil error", i)
+		}
+		if requests != 0 {
+			t.Errorf("#%d: made %d requests, want none", i, requests)
+		}
+	}
+}
+
+func TestDirectionsContinueStraight(t *testing.T) {
+	yes, no := true, false
+	coords := []*mapbox.LatLonPair{
+		{-122.40252, 37.78881},
+		{-122.40071, 37.79033},
+		{-122.39929, 37.79152},
+	}
+	tests := []struct {
+		coords    []*mapbox.LatLonPair
+		waypoints []uint
+		straight  *bool
+		want      string
+		wantErr   bool
+	}{
+		0: {coords: coords, straight: &yes, want: "true"},
+		1: {coords: coords, straight: &no, want: "false"},
+		// Unset keeps the profile's default.
+		2: {coords: coords, want: ""},
+		3: {coords: coords[:2], want: ""},
+		// Without an intermediate waypoint there are no U-turns to control.
+		4: {coords: coords[:2], straight: &yes, wantErr: true},
+		5: {coords: coords, waypoints: []uint{0, 2}, straight: &no, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		var gotQuery url.Values
+		requests := 0
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				requests++
+				gotQuery = req.URL.Query()
+				return respFromFileContents("./testdata/directions-SF.json")
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = client.Directions(context.Background(), &mapbox.DirectionsRequest{
+			Coordinates:      tt.coords,
+			Waypoints:        tt.waypoints,
+			ContinueStraight: tt.straight,
+		})
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error", i)
+			}
+			if requests != 0 {
+				t.Errorf("#%d: made %d requests, want none", i, requests)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if g, w := gotQuery.Get("continue_straight"), tt.want; g != w {
+			t.Errorf("#%d: continue_straight got %q want %q", i, g, w)
+		}
+		if _, ok := gotQuery["roundabout_exits"]; ok {
+			t.Errorf("#%d: unexpectedly sent roundabout_exits", i)
+		}
+	}
+}
+
+func TestDirectionsRoundaboutExits(t *testing.T) {
+	var gotQuery url.Values
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			gotQuery = req.URL.Query()
+			return respFromFileContents("./testdata/directions-roundabout.json")
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dres, err := client.Directions(context.Background(), &mapbox.DirectionsRequest{
+		Coordinates: []*mapbox.LatLonPair{
+			{-77.04412, 38.91129},
+			{-77.04209, 38.90912},
+		},
+		Steps:           true,
+		RoundaboutExits: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, w := gotQuery.Get("roundabout_exits"), "true"; g != w {
+		t.Errorf("roundabout_exits got %q want %q", g, w)
+	}
+
+	steps := dres.Routes[0].Legs[0].Steps
+	if g, w := len(steps), 4; g != w {
+		t.Fatalf("got %d steps want %d", g, w)
+	}
+	want := &mapbox.StepManeuver{
+		Type:        "exit roundabout",
+		Modifier:    "slight right",
+		Instruction: "Exit the roundabout onto Massachusetts Avenue Northwest",
+		Location:    &mapbox.LatLonPair{-77.04285, 38.90947},
+		Exit:        3,
+	}
+	if got := steps[2].Maneuver; !reflect.DeepEqual(got, want) {
+		t.Errorf("exit maneuver\ngot:  %+v\nwant: %+v", got, want)
+	}
+	if g, w := steps[1].Maneuver.Exit, 3; g != w {
+		t.Errorf("entering maneuver's exit got %d want %d", g, w)
+	}
+	if g := steps[0].Maneuver.Exit; g != 0 {
+		t.Errorf("depart maneuver's exit got %d want 0", g)
+	}
+}
+
+func TestDirectionsRadiuses(t *testing.T) {
+	coords := []*mapbox.LatLonPair{
+		{-122.40252, 37.78881},
+		{-122.40071, 37.79033},
+		{-122.39929, 37.79152},
+	}
+	noSegment := `{"code": "NoSegment", "message": "Could not find a matching segment for input coordinates"}`
+	tests := []struct {
+		radiuses      []float32
+		body          string
+		want          string
+		wantErr       bool
+		wantNoSegment bool
+	}{
+		0: {radiuses: []float32{50, mapbox.RadiusUnlimited, 2.5}, want: "50;unlimited;2.5"},
+		// A 0 radius keeps the default.
+		1: {radiuses: []float32{0, 100, 0}, want: ";100;"},
+		2: {radiuses: nil, want: ""},
+		3: {radiuses: []float32{50, 100}, wantErr: true},
+		4: {radiuses: []float32{50, -5, 100}, wantErr: true},
+		5: {radiuses: []float32{50, 1, 100}, body: noSegment, want: "50;1;100", wantNoSegment: true},
+	}
+
+	for i, tt := range tests {
+		var gotQuery url.Values
+		requests := 0
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				requests++
+				gotQuery = req.URL.Query()
+				if tt.body != "" {
+					return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(tt.body))), nil
+				}
+				return respFromFileContents("./testdata/directions-SF.json")
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = client.Directions(context.Background(), &mapbox.DirectionsRequest{
+			Coordinates: coords,
+			Radiuses:    tt.radiuses,
+		})
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error", i)
+			}
+			if requests != 0 {
+				t.Errorf("#%d: made %d requests, want none", i, requests)
+			}
+			continue
+		}
+		if g, w := gotQuery.Get("radiuses"), tt.want; g != w {
+			t.Errorf("#%d: radiuses got %q want %q", i, g, w)
+		}
+		if !tt.wantNoSegment {
+			if err != nil {
+				t.Errorf("#%d: %v", i, err)
+			}
+			continue
+		}
+		var nse *mapbox.NoSegmentError
+		if !errors.As(err, &nse) {
+			t.Errorf("#%d: got %v want a *NoSegmentError", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(nse.Radiuses, tt.radiuses) {
+			t.Errorf("#%d: error radiuses got %v want %v", i, nse.Radiuses, tt.radiuses)
+		}
+		var ce *mapbox.CodeError
+		if !errors.As(err, &ce) || ce.Code != mapbox.CodeNoSegment {
+			t.Errorf("#%d: got %v want it to wrap a NoSegment *CodeError", i, err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package mapbox
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	terrainTilesetID    = "mapbox.mapbox-terrain-v2"
+	terrainContourLayer = "contour"
+
+	maxElevationConcurrency = 8
+)
+
+// Elevation returns the ground elevation in meters of each of the
+// [lon, lat] points, in the same order, by querying the contours of
+// the terrain tileset. Points without any contour, such as those in
+// the sea, have an elevation of NaN which can be checked by math.IsNaN.
+func (c *Client) Elevation(ctx context.Context, points []*LatLonPair) ([]float64, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).Elevation")
+	defer span.End()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	indices := make(chan int)
+	go func() {
+		defer close(indices)
+		for i := range points {
+			select {
+			case indices <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	elevations := make([]float64, len(points))
+	var errOnce sync.Once
+	var firstErr error
+	var wg sync.WaitGroup
+	for n := 0; n < maxElevationConcurrency && n < len(points); n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				gres, err := c.Tilequery(ctx, &TilequeryRequest{
+					TilesetID: terrainTilesetID,
+					Point:     points[i],
+					Layers:    []string{terrainContourLayer},
+					Limit:     maxTilequeryLimit,
+				})
+				if err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					return
+				}
+				elevations[i] = highestElevation(gres)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	if firstErr != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: firstErr.Error()})
+		return nil, firstErr
+	}
+	return elevations, nil
+}
+
+// highestElevation returns the highest "ele" of the
+// contours containing the point, or NaN if there are none.
+func highestElevation(gres *GeocodeResponse) float64 {
+	highest := math.NaN()
+	for _, feat := range gres.Features {
+		if feat == nil || feat.Properties == nil {
+			continue
+		}
+		ele, ok := (*feat.Properties)["ele"].(float64)
+		if ok && (math.IsNaN(highest) || ele > highest) {
+			highest = ele
+		}
+	}
+	return highest
+}
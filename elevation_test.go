@@ -0,0 +1,108 @@
+package mapbox_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orijtech/mapbox"
+)
+
+func TestElevation(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+			defer func() {
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+			}()
+			time.Sleep(5 * time.Millisecond)
+
+			if !strings.HasPrefix(req.URL.Path, "/v4/mapbox.mapbox-terrain-v2/tilequery/") {
+				return makeResp("Not Found", http.StatusNotFound, http.NoBody), nil
+			}
+			if g, w := req.URL.Query().Get("layers"), "contour"; g != w {
+				return makeResp("Bad Request", http.StatusBadRequest, http.NoBody), nil
+			}
+
+			// Points with a latitude of 0 are in the sea,
+			// the rest are as high as their longitude.
+			var lon, lat float64
+			fmt.Sscanf(strings.TrimSuffix(req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:], ".json"), "%f,%f", &lon, &lat)
+			body := `{"type":"FeatureCollection","features":[]}`
+			if lat != 0 {
+				body = fmt.Sprintf(`{"type":"FeatureCollection","features":[
+					{"type":"Feature","id":1,"properties":{"ele":%d,"index":5}},
+					{"type":"Feature","id":2,"properties":{"ele":%d,"index":10}}
+				]}`, int(lon)-10, int(lon))
+			}
+			return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(body))), nil
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var points []*mapbox.LatLonPair
+	for i := 0; i < 20; i++ {
+		lat := float32(45)
+		if i%5 == 0 {
+			lat = 0
+		}
+		points = append(points, &mapbox.LatLonPair{float32(100 + i), lat})
+	}
+
+	elevations, err := client.Elevation(context.Background(), points)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g, w := len(elevations), len(points); g != w {
+		t.Fatalf("got %d elevations want %d", g, w)
+	}
+	for i, ele := range elevations {
+		if i%5 == 0 {
+			if !math.IsNaN(ele) {
+				t.Errorf("#%d: got %v want NaN", i, ele)
+			}
+			continue
+		}
+		if g, w := ele, float64(100+i); g != w {
+			t.Errorf("#%d: got %v want %v", i, g, w)
+		}
+	}
+	if maxInFlight > 8 {
+		t.Errorf("got %d concurrent requests, want at most 8", maxInFlight)
+	}
+}
+
+func TestElevationError(t *testing.T) {
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			return makeResp("401 Unauthorized", http.StatusUnauthorized, http.NoBody), nil
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	points := []*mapbox.LatLonPair{{-105.0, 39.7}, {-105.1, 39.8}}
+	if elevations, err := client.Elevation(context.Background(), points); err == nil {
+		t.Errorf("want non-nil error, got elevations %v", elevations)
+	}
+}
@@ -48,3 +48,25 @@ func Example_client_LookupLatLon() {
 		fmt.Printf("\n\n")
 	}
 }
+
+func Example_client_RequestDuration() {
+	client, err := mapbox.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dres, err := client.RequestDuration(context.Background(), &mapbox.DurationRequest{
+		Coordinates: []*mapbox.LatLonPair{
+			{13.41894, 52.50055},
+			{14.10293, 52.50055},
+			{13.50116, 53.10293},
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for i, row := range dres.Durations {
+		fmt.Printf("From #%d: %v\n", i, *row)
+	}
+}
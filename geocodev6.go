@@ -0,0 +1,180 @@
+package mapbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.opencensus.io/trace"
+)
+
+// GeocodeV6Request is a forward geocode of Query, or
+// a reverse geocode of Point if it is set instead.
+type GeocodeV6Request struct {
+	Query string `json:"q,omitempty"`
+
+	// Point is the [lon, lat] pair to reverse geocode.
+	Point *LatLonPair `json:"-"`
+
+	// Country is a set of one or more countries
+	// specified with ISO 3166 alpha 2 country codes.
+	Country []string `json:"country,omitempty"`
+
+	// Language is an IETF language tag e.g. "fr".
+	Language string `json:"language,omitempty"`
+
+	Limit uint `json:"limit,omitempty"`
+
+	// Types if set limits the results to these feature
+	// types e.g. "address", "street" or "place".
+	Types []string `json:"types,omitempty"`
+
+	// Permanent if set requests results that
+	// may be stored, as GeocodePermanentPlaces does.
+	Permanent bool `json:"permanent,omitempty"`
+}
+
+type GeocodeV6Response struct {
+	Type        string              `json:"type"`
+	Features    []*GeocodeV6Feature `json:"features"`
+	Attribution string              `json:"attribution,omitempty"`
+}
+
+type GeocodeV6Feature struct {
+	ID         string               `json:"id"`
+	Type       string               `json:"type"`
+	Geometry   *Geometry            `json:"geometry"`
+	Properties *GeocodeV6Properties `json:"properties"`
+}
+
+type GeocodeV6Properties struct {
+	MapboxID string `json:"mapbox_id"`
+
+	// FeatureType is e.g. "address", "street" or "place".
+	FeatureType string `json:"feature_type"`
+
+	Name           string `json:"name"`
+	NamePreferred  string `json:"name_preferred,omitempty"`
+	PlaceFormatted string `json:"place_formatted,omitempty"`
+	FullAddress    string `json:"full_address,omitempty"`
+
+	// BoundingBox is in [minLon, minLat, maxLon, maxLat] order.
+	BoundingBox []float32 `json:"bbox,omitempty"`
+
+	Context *GeocodeV6Context `json:"context,omitempty"`
+}
+
+// GeocodeV6Context is the hierarchy of features that a feature
+// is in, with nil for the levels that don't apply to it.
+type GeocodeV6Context struct {
+	Country      *GeocodeV6Country `json:"country,omitempty"`
+	Region       *GeocodeV6Region  `json:"region,omitempty"`
+	Postcode     *GeocodeV6Entry   `json:"postcode,omitempty"`
+	District     *GeocodeV6Entry   `json:"district,omitempty"`
+	Place        *GeocodeV6Entry   `json:"place,omitempty"`
+	Locality     *GeocodeV6Entry   `json:"locality,omitempty"`
+	Neighborhood *GeocodeV6Entry   `json:"neighborhood,omitempty"`
+	Street       *GeocodeV6Entry   `json:"street,omitempty"`
+	Address      *GeocodeV6Address `json:"address,omitempty"`
+}
+
+type GeocodeV6Entry struct {
+	MapboxID string `json:"mapbox_id"`
+	Name     string `json:"name"`
+}
+
+type GeocodeV6Country struct {
+	GeocodeV6Entry
+
+	// CountryCode is the ISO 3166 alpha 2 code e.g. "US".
+	CountryCode string `json:"country_code"`
+}
+
+type GeocodeV6Region struct {
+	GeocodeV6Entry
+
+	// RegionCode is e.g. "DC" for the District of Columbia.
+	RegionCode string `json:"region_code"`
+}
+
+type GeocodeV6Address struct {
+	GeocodeV6Entry
+
+	AddressNumber string `json:"address_number"`
+	StreetName    string `json:"street_name"`
+}
+
+var (
+	errNilGeocodeV6Request = errors.New("mapbox: expecting a non-nil GeocodeV6Request")
+	errGeocodeV6Query      = errors.New("mapbox: expecting either a Query to forward geocode or a Point to reverse geocode")
+	errGeocodeV6Point      = errors.New("mapbox: expecting Point as a [lon, lat] pair")
+)
+
+func (greq *GeocodeV6Request) validate() error {
+	if greq == nil {
+		return errNilGeocodeV6Request
+	}
+	if (greq.Query == "") == (greq.Point == nil) {
+		return errGeocodeV6Query
+	}
+	if greq.Point != nil && len(*greq.Point) != 2 {
+		return errGeocodeV6Point
+	}
+	return validateCountries(&GeocodeRequest{Country: greq.Country})
+}
+
+// GeocodeV6 geocodes with version 6 of the Geocoding API, whose
+// features have their address broken down in their Context.
+// Request format:
+// GET /search/geocode/v6/forward?q={query}
+// GET /search/geocode/v6/reverse?longitude={lon}&latitude={lat}
+func (c *Client) GeocodeV6(ctx context.Context, greq *GeocodeV6Request) (*GeocodeV6Response, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).GeocodeV6")
+	defer span.End()
+
+	if err := greq.validate(); err != nil {
+		span.Annotate(nil, "Invalid request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
+		return nil, err
+	}
+
+	endpoint := "forward"
+	values := make(url.Values)
+	if greq.Point != nil {
+		endpoint = "reverse"
+		values.Add("longitude", c.formatDegrees(shortest((*greq.Point)[0])))
+		values.Add("latitude", c.formatDegrees(shortest((*greq.Point)[1])))
+	} else {
+		values.Add("q", greq.Query)
+	}
+	if len(greq.Country) > 0 {
+		values.Add("country", strings.Join(greq.Country, ","))
+	}
+	if greq.Language != "" {
+		values.Add("language", greq.Language)
+	}
+	if greq.Limit > 0 {
+		values.Add("limit", strconv.FormatUint(uint64(greq.Limit), 10))
+	}
+	if len(greq.Types) > 0 {
+		values.Add("types", strings.Join(greq.Types, ","))
+	}
+	if greq.Permanent {
+		values.Add("permanent", "true")
+	}
+	values.Add("access_token", c.apiKeyFor(ctx))
+	outURL := fmt.Sprintf("%s/search/geocode/v6/%s?%s", c._baseURL(), endpoint, values.Encode())
+
+	gres := new(GeocodeV6Response)
+	if err := c.doRequest(ctx, span, "GET", outURL, nil, gres); err != nil {
+		return nil, err
+	}
+	if len(gres.Features) == 0 && c.errOnEmpty() {
+		return nil, ErrNoResults
+	}
+	span.SetStatus(trace.Status{Code: trace.StatusCodeOK})
+	return gres, nil
+}
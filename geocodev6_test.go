@@ -0,0 +1,129 @@
+package mapbox_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/orijtech/mapbox"
+)
+
+func TestGeocodeV6(t *testing.T) {
+	tests := []struct {
+		req       *mapbox.GeocodeV6Request
+		wantPath  string
+		wantQuery url.Values
+	}{
+		0: {
+			req:      &mapbox.GeocodeV6Request{Query: "1600 Pennsylvania Ave NW", Country: []string{"us"}, Limit: 1},
+			wantPath: "/search/geocode/v6/forward",
+			wantQuery: url.Values{
+				"q":       {"1600 Pennsylvania Ave NW"},
+				"country": {"us"},
+				"limit":   {"1"},
+			},
+		},
+		1: {
+			req:      &mapbox.GeocodeV6Request{Point: &mapbox.LatLonPair{-77.0366, 38.8977}, Types: []string{"address", "street"}},
+			wantPath: "/search/geocode/v6/reverse",
+			wantQuery: url.Values{
+				"longitude": {"-77.0366"},
+				"latitude":  {"38.8977"},
+				"types":     {"address,street"},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		var gotPath string
+		var gotQuery url.Values
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				gotPath = req.URL.Path
+				gotQuery = req.URL.Query()
+				return respFromFileContents("./testdata/places-v6-DC.json")
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		gres, err := client.GeocodeV6(context.Background(), tt.req)
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if gotPath != tt.wantPath {
+			t.Errorf("#%d: path got %q want %q", i, gotPath, tt.wantPath)
+		}
+		gotQuery.Del("access_token")
+		for key := range tt.wantQuery {
+			if g, w := gotQuery.Get(key), tt.wantQuery.Get(key); g != w {
+				t.Errorf("#%d: %s got %q want %q", i, key, g, w)
+			}
+		}
+		if g, w := len(gotQuery), len(tt.wantQuery); g != w {
+			t.Errorf("#%d: got %d parameters want %d: %v", i, g, w, gotQuery)
+		}
+
+		if len(gres.Features) != 1 {
+			t.Fatalf("#%d: got %d features want 1", i, len(gres.Features))
+		}
+		props := gres.Features[0].Properties
+		if g, w := props.FeatureType, "address"; g != w {
+			t.Errorf("#%d: feature type got %q want %q", i, g, w)
+		}
+		ctxt := props.Context
+		if ctxt == nil || ctxt.Address == nil || ctxt.Street == nil || ctxt.Country == nil || ctxt.Region == nil || ctxt.Postcode == nil || ctxt.Place == nil {
+			t.Fatalf("#%d: incomplete context %+v", i, ctxt)
+		}
+		checks := []struct{ name, got, want string }{
+			{"address number", ctxt.Address.AddressNumber, "1600"},
+			{"street name", ctxt.Address.StreetName, "Pennsylvania Avenue Northwest"},
+			{"street", ctxt.Street.Name, "Pennsylvania Avenue Northwest"},
+			{"postcode", ctxt.Postcode.Name, "20500"},
+			{"place", ctxt.Place.Name, "Washington"},
+			{"region code", ctxt.Region.RegionCode, "DC"},
+			{"country", ctxt.Country.Name, "United States"},
+			{"country code", ctxt.Country.CountryCode, "US"},
+		}
+		for _, check := range checks {
+			if check.got != check.want {
+				t.Errorf("#%d: %s got %q want %q", i, check.name, check.got, check.want)
+			}
+		}
+		if ctxt.District != nil || ctxt.Locality != nil {
+			t.Errorf("#%d: expected no district nor locality", i)
+		}
+	}
+}
+
+func TestGeocodeV6Validation(t *testing.T) {
+	tests := []*mapbox.GeocodeV6Request{
+		0: nil,
+		1: {},
+		2: {Query: "Washington", Point: &mapbox.LatLonPair{-77.0366, 38.8977}},
+		3: {Point: &mapbox.LatLonPair{-77.0366}},
+		4: {Query: "Washington", Country: []string{"USA"}},
+	}
+
+	for i, req := range tests {
+		requests := 0
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				requests++
+				return respFromFileContents("./testdata/places-v6-DC.json")
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.GeocodeV6(context.Background(), req); err == nil {
+			t.Errorf("#%d: want non-nil error", i)
+		}
+		if requests != 0 {
+			t.Errorf("#%d: made %d requests for an invalid request", i, requests)
+		}
+	}
+}
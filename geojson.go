@@ -0,0 +1,67 @@
+package mapbox
+
+import (
+	"encoding/json"
+)
+
+type geoJSONFeatureCollection struct {
+	Type     string            `json:"type"`
+	Features []*geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Id         string                 `json:"id,omitempty"`
+	BBox       []float32              `json:"bbox,omitempty"`
+	Geometry   *Geometry              `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// ToGeoJSON serializes the response as a GeoJSON FeatureCollection
+// that tools such as geojson.io or Leaflet can consume. Each feature's
+// properties are complemented with its "place_name", "text" and
+// "relevance", and its coordinates are [lon, lat] ordered.
+func (r *GeocodeResponse) ToGeoJSON() ([]byte, error) {
+	fc := &geoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]*geoJSONFeature, 0, len(r.Features)),
+	}
+	for _, feat := range r.Features {
+		if feat == nil {
+			continue
+		}
+
+		props := make(map[string]interface{})
+		if feat.Properties != nil {
+			for key, value := range *feat.Properties {
+				props[key] = value
+			}
+		}
+		if feat.PlaceName != "" {
+			props["place_name"] = feat.PlaceName
+		}
+		if feat.Text != "" {
+			props["text"] = feat.Text
+		}
+		if feat.Relevance != 0 {
+			props["relevance"] = feat.Relevance
+		}
+
+		geometry := feat.Geometry
+		if geometry == nil && len(feat.Center) == 2 {
+			geometry = &Geometry{Type: GeometryPoint, Coordinates: feat.Center}
+		}
+
+		gf := &geoJSONFeature{
+			Type:       "Feature",
+			Id:         feat.Id,
+			Geometry:   geometry,
+			Properties: props,
+		}
+		if len(feat.BoundingBox) == 4 {
+			gf.BBox = feat.BoundingBox
+		}
+		fc.Features = append(fc.Features, gf)
+	}
+	return json.Marshal(fc)
+}
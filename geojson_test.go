@@ -0,0 +1,72 @@
+package mapbox_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/orijtech/mapbox"
+)
+
+func TestGeocodeResponseToGeoJSON(t *testing.T) {
+	gr := geocodeResponseFromFile("LA")
+	// A feature without a geometry falls back to its center.
+	gr.Features = append(gr.Features, &mapbox.GeocodeFeature{
+		Id:     "place.0",
+		Text:   "Centerville",
+		Center: []float32{-77.43, 38.84},
+	})
+
+	blob, err := gr.ToGeoJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fc struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Type     string `json:"type"`
+			Geometry *struct {
+				Type        string    `json:"type"`
+				Coordinates []float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(blob, &fc); err != nil {
+		t.Fatalf("not valid JSON: %v\n%s", err, blob)
+	}
+
+	if g, w := fc.Type, "FeatureCollection"; g != w {
+		t.Errorf("type: got %q want %q", g, w)
+	}
+	if g, w := len(fc.Features), len(gr.Features); g != w {
+		t.Fatalf("got %d features want %d", g, w)
+	}
+	for i, feat := range fc.Features {
+		if g, w := feat.Type, "Feature"; g != w {
+			t.Errorf("#%d: type: got %q want %q", i, g, w)
+		}
+		if feat.Geometry == nil || feat.Geometry.Type != "Point" || len(feat.Geometry.Coordinates) != 2 {
+			t.Errorf("#%d: expected a Point geometry, got %+v", i, feat.Geometry)
+		}
+		if feat.Properties == nil {
+			t.Errorf("#%d: properties must be an object", i)
+		}
+	}
+
+	// Coordinates must be [lon, lat] ordered.
+	first := fc.Features[0]
+	if lon, lat := first.Geometry.Coordinates[0], first.Geometry.Coordinates[1]; lon > -118 || lon < -119 || lat < 34 || lat > 35 {
+		t.Errorf("Los Angeles: got [%v, %v] want [lon, lat] = [-118.24, 34.05]", lon, lat)
+	}
+	if g, w := first.Properties["place_name"], "Los Angeles, California, United States"; g != w {
+		t.Errorf("place_name: got %v want %q", g, w)
+	}
+	if g, w := first.Properties["wikidata"], "Q65"; g != w {
+		t.Errorf("wikidata: got %v want %q", g, w)
+	}
+	last := fc.Features[len(fc.Features)-1]
+	if lon, lat := last.Geometry.Coordinates[0], last.Geometry.Coordinates[1]; lon > -77 || lat < 38 {
+		t.Errorf("Centerville: got [%v, %v] want [lon, lat] = [-77.43, 38.84]", lon, lat)
+	}
+}
@@ -0,0 +1,148 @@
+package mapbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.opencensus.io/trace"
+)
+
+type IsochroneRequest struct {
+	// Center is the [lon, lat] pair
+	// from which the contours are computed.
+	Center *LatLonPair `json:"center"`
+
+	// Profile is one of ProfileDriving, ProfileWalking or
+	// ProfileCycling. If unset, it defaults to ProfileDriving.
+	Profile Profile `json:"profile,omitempty"`
+
+	// ContourMinutes is a list of up to 4 travel
+	// times in minutes, in increasing order.
+	ContourMinutes []uint `json:"contours_minutes"`
+
+	// ContourColors if set, are hex colors without the
+	// leading "#" with one color per contour.
+	ContourColors []string `json:"contours_colors,omitempty"`
+
+	// Polygons if set returns the contours as
+	// GeoJSON polygons instead of lines.
+	Polygons bool `json:"polygons,omitempty"`
+
+	// Denoise if set, in [0, 1], drops the contours' disconnected
+	// islands smaller than that fraction of the largest one,
+	// otherwise Mapbox uses 1 which keeps only the largest.
+	Denoise *float64 `json:"denoise,omitempty"`
+
+	// Generalize if set is the tolerance in meters
+	// with which the contours are simplified.
+	Generalize *float64 `json:"generalize,omitempty"`
+}
+
+type IsochroneResponse struct {
+	Type     string            `json:"type,omitempty"`
+	Features []*GeocodeFeature `json:"features,omitempty"`
+}
+
+const (
+	maxIsochroneContours       = 4
+	maxIsochroneContourMinutes = 60
+)
+
+var (
+	errNilIsochroneRequest = errors.New("mapbox: expecting a non-nil IsochroneRequest")
+	errInvalidCenter       = errors.New("mapbox: expecting Center as a [lon, lat] pair")
+	errNoContours          = errors.New("mapbox: expecting at least one contour")
+	errTooManyContours     = fmt.Errorf("mapbox: at most %d contours can be requested", maxIsochroneContours)
+	errContourColorsCount  = errors.New("mapbox: expecting exactly one color per contour")
+	errIsochroneTraffic    = errors.New(`mapbox: the "driving-traffic" profile is not supported by isochrones`)
+	errGeneralize          = errors.New("mapbox: expecting a non-negative generalize tolerance")
+)
+
+func (ireq *IsochroneRequest) validate() error {
+	if ireq == nil {
+		return errNilIsochroneRequest
+	}
+	if ireq.Profile == ProfileDrivingTraffic {
+		return errIsochroneTraffic
+	}
+	if err := validateProfile(ireq.Profile); err != nil {
+		return err
+	}
+	if ireq.Center == nil || len(*ireq.Center) != 2 {
+		return errInvalidCenter
+	}
+	switch n := len(ireq.ContourMinutes); {
+	case n == 0:
+		return errNoContours
+	case n > maxIsochroneContours:
+		return errTooManyContours
+	}
+	for _, minutes := range ireq.ContourMinutes {
+		if minutes == 0 || minutes > maxIsochroneContourMinutes {
+			return fmt.Errorf("mapbox: contour minutes must be in the range [1, %d], got %d", maxIsochroneContourMinutes, minutes)
+		}
+	}
+	if len(ireq.ContourColors) > 0 && len(ireq.ContourColors) != len(ireq.ContourMinutes) {
+		return errContourColorsCount
+	}
+	if d := ireq.Denoise; d != nil && (*d < 0 || *d > 1) {
+		return fmt.Errorf("mapbox: denoise must be in the range [0, 1], got %g", *d)
+	}
+	if g := ireq.Generalize; g != nil && *g < 0 {
+		return errGeneralize
+	}
+	return nil
+}
+
+// Isochrone returns the areas reachable from a center
+// within each of the requested travel times.
+// Request format:
+// GET /isochrone/v1/mapbox/{profile}/{lon},{lat}
+func (c *Client) Isochrone(ctx context.Context, ireq *IsochroneRequest) (*IsochroneResponse, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).Isochrone")
+	defer span.End()
+
+	if err := ireq.validate(); err != nil {
+		span.Annotate(nil, "Invalid request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
+		return nil, err
+	}
+
+	profile := ireq.Profile
+	if profile == "" {
+		profile = defaultProfile
+	}
+
+	minutes := make([]string, len(ireq.ContourMinutes))
+	for i, m := range ireq.ContourMinutes {
+		minutes[i] = fmt.Sprintf("%d", m)
+	}
+	values := make(url.Values)
+	values.Add("contours_minutes", strings.Join(minutes, ","))
+	if len(ireq.ContourColors) > 0 {
+		values.Add("contours_colors", strings.Join(ireq.ContourColors, ","))
+	}
+	if ireq.Polygons {
+		values.Add("polygons", "true")
+	}
+	if ireq.Denoise != nil {
+		values.Add("denoise", strconv.FormatFloat(*ireq.Denoise, 'f', -1, 64))
+	}
+	if ireq.Generalize != nil {
+		values.Add("generalize", strconv.FormatFloat(*ireq.Generalize, 'f', -1, 64))
+	}
+	values.Add("access_token", c.apiKeyFor(ctx))
+
+	outURL := fmt.Sprintf("%s/isochrone/v1/mapbox/%s/%s?%s",
+		c._baseURL(), profile, c.formatPair(*ireq.Center), values.Encode())
+
+	ires := new(IsochroneResponse)
+	if err := c.doRequest(ctx, span, "GET", outURL, nil, ires); err != nil {
+		return nil, err
+	}
+	return ires, nil
+}
@@ -0,0 +1,149 @@
+package mapbox_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/orijtech/mapbox"
+)
+
+func TestIsochrone(t *testing.T) {
+	var gotURL string
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			gotURL = req.URL.String()
+			return respFromFileContents("./testdata/isochrone-SF.json")
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ires, err := client.Isochrone(context.Background(), &mapbox.IsochroneRequest{
+		Center:         &mapbox.LatLonPair{-122.4194, 37.7749},
+		Profile:        "walking",
+		ContourMinutes: []uint{5, 15},
+		ContourColors:  []string{"04e813", "6706ce"},
+		Polygons:       true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantSubstrs := []string{
+		"/isochrone/v1/mapbox/walking/-122.4194,37.7749?",
+		"contours_minutes=5%2C15",
+		"contours_colors=04e813%2C6706ce",
+		"polygons=true",
+	}
+	for _, want := range wantSubstrs {
+		if !strings.Contains(gotURL, want) {
+			t.Errorf("URL %q does not contain %q", gotURL, want)
+		}
+	}
+
+	if g, w := len(ires.Features), 2; g != w {
+		t.Fatalf("got %d features want %d", g, w)
+	}
+	geom := ires.Features[1].Geometry
+	if g, w := geom.Type, mapbox.GeometryPolygon; g != w {
+		t.Errorf("geometry type: got %q want %q", g, w)
+	}
+	if g, w := len(geom.Polygon), 1; g != w {
+		t.Fatalf("got %d rings want %d", g, w)
+	}
+	ring := geom.Polygon[0]
+	if g, w := len(ring), 4; g != w {
+		t.Fatalf("got %d ring points want %d", g, w)
+	}
+	if first, last := *ring[0], *ring[len(ring)-1]; first[0] != last[0] || first[1] != last[1] {
+		t.Errorf("ring is not closed: first %v last %v", first, last)
+	}
+}
+
+func TestIsochroneValidation(t *testing.T) {
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			t.Errorf("unexpected request to %q", req.URL)
+			return makeResp("Bad Request", http.StatusBadRequest, http.NoBody), nil
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	center := &mapbox.LatLonPair{-122.4194, 37.7749}
+	tests := []*mapbox.IsochroneRequest{
+		0: nil,
+		1: {ContourMinutes: []uint{5}},
+		2: {Center: center},
+		3: {Center: center, ContourMinutes: []uint{5, 10, 15, 20, 25}},
+		4: {Center: center, ContourMinutes: []uint{90}},
+		5: {Center: center, ContourMinutes: []uint{5, 10}, ContourColors: []string{"04e813"}},
+		6: {Center: center, ContourMinutes: []uint{5}, Denoise: float64Ptr(2)},
+		7: {Center: center, ContourMinutes: []uint{5}, Denoise: float64Ptr(-0.1)},
+		8: {Center: center, ContourMinutes: []uint{5}, Generalize: float64Ptr(-10)},
+	}
+
+	for i, ireq := range tests {
+		if _, err := client.Isochrone(context.Background(), ireq); err == nil {
+			t.Errorf("#%d: want non-nil error", i)
+		}
+	}
+}
+
+func float64Ptr(f float64) *float64 { return &f }
+
+func TestIsochroneDenoiseGeneralize(t *testing.T) {
+	tests := []struct {
+		denoise, generalize *float64
+		wantDenoise         string
+		wantGeneralize      string
+	}{
+		0: {},
+		1: {denoise: float64Ptr(0.5), wantDenoise: "0.5"},
+		2: {generalize: float64Ptr(500), wantGeneralize: "500"},
+		// 0 is sent, unlike an unset value.
+		3: {denoise: float64Ptr(0), generalize: float64Ptr(0), wantDenoise: "0", wantGeneralize: "0"},
+		4: {denoise: float64Ptr(1), generalize: float64Ptr(12.5), wantDenoise: "1", wantGeneralize: "12.5"},
+	}
+
+	for i, tt := range tests {
+		var gotQuery url.Values
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				gotQuery = req.URL.Query()
+				return respFromFileContents("./testdata/isochrone-SF.json")
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = client.Isochrone(context.Background(), &mapbox.IsochroneRequest{
+			Center:         &mapbox.LatLonPair{-122.4194, 37.7749},
+			ContourMinutes: []uint{10},
+			Denoise:        tt.denoise,
+			Generalize:     tt.generalize,
+		})
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		for key, want := range map[string]string{"denoise": tt.wantDenoise, "generalize": tt.wantGeneralize} {
+			got, ok := gotQuery[key]
+			if want == "" {
+				if ok {
+					t.Errorf("#%d: unexpectedly sent %s=%q", i, key, got)
+				}
+				continue
+			}
+			if g := gotQuery.Get(key); g != want {
+				t.Errorf("#%d: %s got %q want %q", i, key, g, want)
+			}
+		}
+	}
+}
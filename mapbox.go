@@ -1,24 +1,58 @@
-package mapbox
+package mapbox // import "github.com/orijtech/mapbox"
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/trace"
 )
 
 type Client struct {
 	sync.RWMutex
 	version    string
 	apiKey     string
+	baseURL    string
 	httpClient *http.Client
+	maxRetries int
+
+	errorOnEmpty  bool
+	userAgent     string
+	requireAPIKey bool
+	sampler       trace.Sampler
+
+	continueOnError bool
+	headers         http.Header
+	cache           Cache
+	limiter         *rateLimiter
+	timeout         time.Duration
+	logf            func(format string, args ...interface{})
+	wrappers        []func(http.RoundTripper) http.RoundTripper
+
+	metrics       Metrics
+	tokenInHeader bool
+	precision     int
+
+	// pool is the transport of the default http.Client
+	// that NewClient built, tuned by WithConnectionPool.
+	pool                *http.Transport
+	maxIdleConnsPerHost int
+	idleConnTimeout     time.Duration
+
+	// wrapped is the transport with the wrappers
+	// applied, built once by NewClient.
+	wrapped http.RoundTripper
 }
 
 func (c *Client) SetAPIKey(key string) {
@@ -30,6 +64,17 @@ func (c *Client) SetAPIKey(key string) {
 
 var defaultEnvAPIKey = os.Getenv("MAPBOX_API_KEY")
 
+// String describes the client without its API key,
+// so that printing it doesn't leak the key.
+func (c *Client) String() string {
+	return fmt.Sprintf("mapbox.Client{baseURL: %q, apiKey: %q}", c._baseURL(), "REDACTED")
+}
+
+// GoString is String, so that %#v doesn't leak the API key either.
+func (c *Client) GoString() string {
+	return c.String()
+}
+
 func (c *Client) APIKey() string {
 	c.RLock()
 	defer c.RUnlock()
@@ -40,10 +85,118 @@ func (c *Client) APIKey() string {
 	return defaultEnvAPIKey
 }
 
+// KeySource is where a client's API key comes from.
+type KeySource string
+
+const (
+	// SourceExplicit is a key set with WithAPIKey or SetAPIKey.
+	SourceExplicit KeySource = "explicit"
+
+	// SourceEnv is the key in the MAPBOX_API_KEY environment
+	// variable, used when none was set explicitly.
+	SourceEnv KeySource = "env"
+
+	// SourceNone is when the client has no key at all.
+	SourceNone KeySource = "none"
+)
+
+// APIKeySource reports where APIKey comes from, so that how the
+// client authenticates can be logged without logging the key.
+// A key set explicitly takes precedence over the environment.
+// It doesn't account for keys passed with WithContextAPIKey.
+func (c *Client) APIKeySource() KeySource {
+	c.RLock()
+	defer c.RUnlock()
+
+	switch {
+	case c.apiKey != "":
+		return SourceExplicit
+	case defaultEnvAPIKey != "":
+		return SourceEnv
+	default:
+		return SourceNone
+	}
+}
+
+type contextAPIKey struct{}
+
+// WithContextAPIKey returns a copy of ctx carrying an API key
+// that overrides the client's own for any request made with it.
+// This allows a single Client to make requests for many users.
+func WithContextAPIKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, contextAPIKey{}, key)
+}
+
+// apiKeyFor returns the API key in ctx if
+// any, otherwise the client's API key.
+func (c *Client) apiKeyFor(ctx context.Context) string {
+	if key, _ := ctx.Value(contextAPIKey{}).(string); key != "" {
+		return key
+	}
+	return c.APIKey()
+}
+
+type contextHeaders struct{}
+
+// WithContextHeaders returns a copy of ctx carrying headers
+// to send with any request made with it. They take precedence
+// over the same headers set by WithHTTPHeaders.
+func WithContextHeaders(ctx context.Context, headers http.Header) context.Context {
+	return context.WithValue(ctx, contextHeaders{}, headers)
+}
+
+// setHeaders sets the client's headers, then those in ctx
+// and finally the User-Agent, on the outgoing request hreq.
+// Any body is JSON unless those headers say otherwise.
+func (c *Client) setHeaders(ctx context.Context, hreq *http.Request) {
+	c.RLock()
+	headers := c.headers
+	c.RUnlock()
+
+	if hreq.Body != nil && hreq.Body != http.NoBody {
+		hreq.Header.Set("Content-Type", "application/json")
+	}
+
+	ctxHeaders, _ := ctx.Value(contextHeaders{}).(http.Header)
+	for _, hdr := range []http.Header{headers, ctxHeaders} {
+		for key, values := range hdr {
+			hreq.Header.Del(key)
+			for _, value := range values {
+				hreq.Header.Add(key, value)
+			}
+		}
+	}
+	hreq.Header.Set("User-Agent", c.UserAgent())
+}
+
 type LatLonPair []float32
 type LatLonMatrix [][]float32
 
-var NoPathDuration = float32(-1)
+// noPath marks the durations and distances of a matrix between
+// coordinates that have no route between them. Since durations
+// and distances are never negative, it can't be a real value.
+const noPath float32 = -1
+
+// NoPathDuration is the value of the durations, and distances,
+// without a path. It is a constant so that no client can change
+// it for the others, and IsNoPath and HasPath should be preferred
+// to comparing against it.
+const NoPathDuration = noPath
+
+// IsNoPath reports whether the matrix value v marks a
+// duration or distance between coordinates without a path.
+func IsNoPath(v float32) bool {
+	return v == noPath
+}
+
+// HasPath reports whether the matrix row llp has a path to
+// its i-th column, which is false if i is out of range.
+func (llp *LatLonPair) HasPath(i int) bool {
+	if llp == nil || i < 0 || i >= len(*llp) {
+		return false
+	}
+	return !IsNoPath((*llp)[i])
+}
 
 func (llp *LatLonPair) UnmarshalJSON(b []byte) error {
 	var irecv []interface{}
@@ -54,7 +207,7 @@ func (llp *LatLonPair) UnmarshalJSON(b []byte) error {
 	var recv []float32
 	for _, v := range irecv {
 		if v == nil { // They sent back `null` so no path
-			recv = append(recv, NoPathDuration)
+			recv = append(recv, noPath)
 		} else {
 			switch t := v.(type) {
 			case float32:
@@ -74,7 +227,7 @@ func (llp *LatLonPair) UnmarshalJSON(b []byte) error {
 			case uint64:
 				recv = append(recv, float32(t))
 			default:
-				recv = append(recv, NoPathDuration)
+				recv = append(recv, noPath)
 			}
 		}
 	}
@@ -85,12 +238,127 @@ func (llp *LatLonPair) UnmarshalJSON(b []byte) error {
 
 type DurationResponse struct {
 	Durations []*LatLonPair `json:"durations,omitempty"`
+
+	// Distances is only populated if AnnotationDistance
+	// was requested, and its values are in meters.
+	Distances []*LatLonPair `json:"distances,omitempty"`
+
+	// Sources and Destinations are where the
+	// respective coordinates snapped to.
+	Sources      []*Waypoint `json:"sources,omitempty"`
+	Destinations []*Waypoint `json:"destinations,omitempty"`
+}
+
+// UnreachablePairs returns the {row, column} indices of the
+// durations without a path, that is Durations[row][column]
+// is NoPathDuration, in row order. With Sources and
+// Destinations, they index those rather than the coordinates.
+func (dres *DurationResponse) UnreachablePairs() [][2]int {
+	var pairs [][2]int
+	for i, row := range dres.Durations {
+		if row == nil {
+			continue
+		}
+		for j := range *row {
+			if !row.HasPath(j) {
+				pairs = append(pairs, [2]int{i, j})
+			}
+		}
+	}
+	return pairs
 }
 
-var errUnimplemented = errors.New("unimplemented")
+var (
+	errUnimplemented = errors.New("unimplemented")
+	errNoAPIKey      = errors.New("mapbox: no API key; set MAPBOX_API_KEY or use WithAPIKey")
+)
+
+const (
+	AnnotationDuration = "duration"
+	AnnotationDistance = "distance"
+
+	// AnnotationSpeed and AnnotationCongestion
+	// are only available from Directions.
+	AnnotationSpeed      = "speed"
+	AnnotationCongestion = "congestion"
+)
 
 type DurationRequest struct {
+	// Profile is one of ProfileDriving, ProfileDrivingTraffic,
+	// ProfileWalking or ProfileCycling. If unset, it
+	// defaults to ProfileDriving.
+	Profile Profile `json:"-"`
+
 	Coordinates []*LatLonPair `json:"coordinates"`
+
+	// Annotations if set is the list of matrices to return
+	// e.g. []string{AnnotationDuration, AnnotationDistance}
+	Annotations []string `json:"annotations,omitempty"`
+
+	// Sources and Destinations if set are the indices of the
+	// Coordinates to route from and to respectively, otherwise
+	// all the coordinates are used. The response's matrices then
+	// have a row per source and a column per destination, that is
+	// Durations[i][j] is from Coordinates[Sources[i]] to
+	// Coordinates[Destinations[j]].
+	Sources      []uint `json:"-"`
+	Destinations []uint `json:"-"`
+
+	// Radiuses if set has the maximum distance in meters, per
+	// coordinate, that it can be snapped by, with RadiusUnlimited
+	// for no limit and 0 for the default.
+	Radiuses []float32 `json:"-"`
+
+	// DepartAt if set is when to leave, so that the durations
+	// account for the expected traffic. It is only allowed with
+	// the "driving-traffic" profile.
+	DepartAt time.Time `json:"-"`
+}
+
+// NewDurationRequest returns a request for the durations between
+// points, each a [lon, lat] row. Rows that aren't pairs are
+// rejected by RequestDuration.
+func NewDurationRequest(points LatLonMatrix) *DurationRequest {
+	coords := make([]*LatLonPair, len(points))
+	for i, point := range points {
+		pair := LatLonPair(point)
+		coords[i] = &pair
+	}
+	return &DurationRequest{Coordinates: coords}
+}
+
+// CoordinateMatrix returns the request's coordinates as a
+// LatLonMatrix, the inverse of NewDurationRequest.
+func (dreq *DurationRequest) CoordinateMatrix() LatLonMatrix {
+	points := make(LatLonMatrix, len(dreq.Coordinates))
+	for i, coord := range dreq.Coordinates {
+		if coord != nil {
+			points[i] = []float32(*coord)
+		}
+	}
+	return points
+}
+
+var errNilDurationRequest = errors.New("mapbox: expecting a non-nil DurationRequest")
+
+func (dreq *DurationRequest) validate() error {
+	if dreq == nil {
+		return errNilDurationRequest
+	}
+	return dreq.matrixRequest().validate()
+}
+
+// matrixRequest converts dreq to the equivalent MatrixRequest.
+func (dreq *DurationRequest) matrixRequest() *MatrixRequest {
+	return &MatrixRequest{
+		Profile:      dreq.Profile,
+		Coordinates:  dreq.Coordinates,
+		Annotations:  dreq.Annotations,
+		Sources:      dreq.Sources,
+		Destinations: dreq.Destinations,
+		Radiuses:     dreq.Radiuses,
+		DepartAt:     dreq.DepartAt,
+	}
 }
 
 const defaultAPIVersion = "v1"
@@ -106,62 +374,541 @@ func (c *Client) APIVersion() string {
 	}
 }
 
+// UserAgent returns the User-Agent header sent with every
+// request, which defaults to "orijtech-mapbox/<APIVersion>".
+func (c *Client) UserAgent() string {
+	c.RLock()
+	ua := c.userAgent
+	c.RUnlock()
+
+	if ua != "" {
+		return ua
+	}
+	return "orijtech-mapbox/" + c.APIVersion()
+}
+
 var baseURL = "https://api.mapbox.com"
 
-func (c *Client) durationsURL() string {
-	return fmt.Sprintf("%s/distances/%s/mapbox/driving?access_token=%s",
-		baseURL, c.APIVersion(), c.APIKey())
+func (c *Client) _baseURL() string {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.baseURL != "" {
+		return c.baseURL
+	}
+	return baseURL
 }
 
 func (c *Client) _httpClient() *http.Client {
 	c.RLock()
 	defer c.RUnlock()
 
-	if c.httpClient != nil {
-		return c.httpClient
+	hc := c.httpClient
+	if hc == nil {
+		hc = &http.Client{Transport: &ochttp.Transport{}}
+	}
+	if c.wrapped != nil {
+		whc := *hc
+		whc.Transport = c.wrapped
+		hc = &whc
+	}
+	if c.limiter != nil {
+		lhc := *hc
+		lhc.Transport = &rateLimitTransport{limiter: c.limiter, base: hc.Transport}
+		hc = &lhc
+	}
+	if c.maxRetries > 0 {
+		rhc := *hc
+		rhc.Transport = &retryTransport{maxRetries: c.maxRetries, base: hc.Transport}
+		hc = &rhc
+	}
+	return hc
+}
+
+// startSpan starts a span named name, using the client's
+// sampler if WithTraceSampler was set, else the process one.
+func (c *Client) startSpan(ctx context.Context, name string) (context.Context, *trace.Span) {
+	c.RLock()
+	sampler := c.sampler
+	c.RUnlock()
+
+	if sampler == nil {
+		return trace.StartSpan(ctx, name)
+	}
+	return trace.StartSpan(ctx, name, trace.WithSampler(sampler))
+}
+
+const (
+	// The default transport keeps enough idle connections for
+	// batches of concurrent requests to reuse them instead of
+	// dialing Mapbox anew for every request.
+	defaultMaxIdleConnsPerHost = 32
+	defaultIdleConnTimeout     = 90 * time.Second
+)
+
+// newPool returns a transport with http.DefaultTransport's
+// timeouts but with its own connections, tuned for batches.
+func (c *Client) newPool() *http.Transport {
+	pool := http.DefaultTransport.(*http.Transport).Clone()
+	pool.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	if c.maxIdleConnsPerHost > 0 {
+		pool.MaxIdleConnsPerHost = c.maxIdleConnsPerHost
+	}
+	if pool.MaxIdleConns < pool.MaxIdleConnsPerHost {
+		pool.MaxIdleConns = pool.MaxIdleConnsPerHost
+	}
+	pool.IdleConnTimeout = defaultIdleConnTimeout
+	if c.idleConnTimeout > 0 {
+		pool.IdleConnTimeout = c.idleConnTimeout
+	}
+	return pool
+}
+
+// HTTPClient returns the http.Client that requests are made with,
+// either the one set by WithHTTPClient or else the one built by
+// NewClient, before any rate limiting, retries or wrappers.
+func (c *Client) HTTPClient() *http.Client {
+	c.RLock()
+	defer c.RUnlock()
+	return c.httpClient
+}
+
+type idleConnectionsCloser interface {
+	CloseIdleConnections()
+}
+
+// Close closes the idle connections of the default transport or
+// else of the one set by WithHTTPClient, if it supports that, such
+// as an *http.Transport. A closed client should not be used again.
+func (c *Client) Close() error {
+	c.RLock()
+	hc, pool := c.httpClient, c.pool
+	c.RUnlock()
+
+	if pool != nil {
+		pool.CloseIdleConnections()
+		return nil
+	}
+	if hc == nil {
+		return nil
 	}
-	return &http.Client{Transport: &ochttp.Transport{}}
+	if icc, ok := hc.Transport.(idleConnectionsCloser); ok {
+		icc.CloseIdleConnections()
+	}
+	return nil
 }
 
 func statusOK(c int) bool { return c >= 200 && c <= 299 }
 
+// MapboxError is returned for non-2XX responses.
+type MapboxError struct {
+	StatusCode int    `json:"-"`
+	Message    string `json:"message"`
+}
+
+func (me *MapboxError) Error() string {
+	return fmt.Sprintf("mapbox: %d %s", me.StatusCode, me.Message)
+}
+
+var (
+	// ErrUnauthorized matches a *MapboxError, using errors.Is,
+	// for a missing or invalid access token or one that can't
+	// access the resource e.g. another account's private style.
+	ErrUnauthorized = errors.New("mapbox: unauthorized")
+
+	// ErrForbidden matches a *MapboxError, using errors.Is,
+	// for an access token that lacks the request's scope.
+	ErrForbidden = errors.New("mapbox: forbidden")
+
+	// ErrNotFound matches a *MapboxError, using
+	// errors.Is, for a resource that doesn't exist.
+	ErrNotFound = errors.New("mapbox: not found")
+)
+
+// Is reports whether target is the sentinel
+// error for the response's status code.
+func (me *MapboxError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return me.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return me.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return me.StatusCode == http.StatusNotFound
+	default:
+		return false
+	}
+}
+
+// errorFromResponse extracts the message from the
+// JSON body of a bad response, falling back to its status.
+func errorFromResponse(res *http.Response) error {
+	me := new(MapboxError)
+	if res.Body != nil {
+		slurp, _ := ioutil.ReadAll(res.Body)
+		_ = json.Unmarshal(slurp, me)
+	}
+	me.StatusCode = res.StatusCode
+	if me.Message == "" {
+		me.Message = strings.TrimSpace(strings.TrimPrefix(res.Status, strconv.Itoa(res.StatusCode)))
+	}
+	if res.StatusCode == http.StatusTooManyRequests {
+		wait, _ := retryAfter(res.Header)
+		return &RateLimitError{RetryAfter: wait, Err: me}
+	}
+	return me
+}
+
+// RateLimitError is returned for a 429 response, which is
+// also a *MapboxError using errors.As, once the client had
+// made too many requests and any retries were exhausted.
+type RateLimitError struct {
+	// RetryAfter is how long Mapbox asked to wait before
+	// retrying, and 0 if the response didn't say.
+	RetryAfter time.Duration
+
+	Err *MapboxError
+}
+
+func (rle *RateLimitError) Error() string {
+	if rle.RetryAfter <= 0 {
+		return rle.Err.Error()
+	}
+	return fmt.Sprintf("%s, retry after %s", rle.Err.Error(), rle.RetryAfter)
+}
+
+func (rle *RateLimitError) Unwrap() error { return rle.Err }
+
+// Temporary reports true since the request
+// can be retried once RetryAfter elapses.
+func (rle *RateLimitError) Temporary() bool { return true }
+
+// RequestDuration returns the travel times, and any other
+// requested annotations, between the coordinates. It is the
+// subset of Matrix that existed before Matrix was added.
 func (c *Client) RequestDuration(ctx context.Context, dreq *DurationRequest) (*DurationResponse, error) {
-	blob, err := json.Marshal(dreq)
-	if err != nil {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).RequestDuration")
+	defer span.End()
+
+	if err := dreq.validate(); err != nil {
+		span.Annotate(nil, "Invalid request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
 		return nil, err
 	}
-	req, _ := http.NewRequest("POST", c.durationsURL(), bytes.NewReader(blob))
-	httpClient := c._httpClient()
-	req = req.WithContext(ctx)
-	res, err := httpClient.Do(req)
+	mres, err := c.matrix(ctx, span, dreq.matrixRequest())
 	if err != nil {
 		return nil, err
 	}
-	if res.Body != nil {
-		defer res.Body.Close()
+	span.SetStatus(trace.Status{Code: trace.StatusCodeOK})
+	dres := &DurationResponse{
+		Durations:    mres.Durations,
+		Distances:    mres.Distances,
+		Sources:      mres.Sources,
+		Destinations: mres.Destinations,
 	}
+	return dres, nil
+}
 
-	if !statusOK(res.StatusCode) {
-		return nil, fmt.Errorf("%s", res.Status)
+// doRequest sends a request to outURL and JSON
+// decodes the successful response's body into recv.
+func (c *Client) doRequest(ctx context.Context, span *trace.Span, method, outURL string, body io.Reader, recv interface{}) error {
+	res, err := c.doHTTPRequest(ctx, span, method, outURL, body)
+	if err != nil {
+		return err
+	}
+	defer drainAndClose(res.Body)
+
+	return decodeResponse(span, res, res.Body, recv)
+}
+
+// maxDrain caps how much of a body drainAndClose reads, past
+// which closing the connection is cheaper than reusing it.
+const maxDrain = 256 << 10
+
+// drainAndClose reads what is left of body, such as the trailing
+// newline after the JSON that a decoder stops short of, before
+// closing it, so that its keep-alive connection can be reused.
+func drainAndClose(body io.ReadCloser) {
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(body, maxDrain))
+	_ = body.Close()
+}
+
+// decodeResponse streams the JSON in r, the body of res,
+// into recv rather than reading it all into memory first.
+func decodeResponse(span *trace.Span, res *http.Response, r io.Reader, recv interface{}) error {
+	if err := json.NewDecoder(r).Decode(recv); err != nil {
+		err = fmt.Errorf("mapbox: failed to decode the %q response: %v", res.Status, err)
+		span.Annotate(nil, "Failed to decode JSON response")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return err
+	}
+	return nil
+}
+
+func unmarshalResponse(span *trace.Span, slurp []byte, recv interface{}) error {
+	if err := json.Unmarshal(slurp, recv); err != nil {
+		span.Annotate(nil, "Failed to unmarshal JSON response")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return err
 	}
-	slurp, err := ioutil.ReadAll(res.Body)
+	return nil
+}
+
+// doHTTPRequest sends a request to outURL and returns the
+// response only if it was successful, leaving the caller
+// to close its body. Bad responses are returned as errors.
+func (c *Client) doHTTPRequest(ctx context.Context, span *trace.Span, method, outURL string, body io.Reader) (res *http.Response, err error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer func() {
+		if err != nil {
+			cancel()
+		} else {
+			// The deadline also covers reading the body.
+			res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+		}
+	}()
+
+	c.RLock()
+	logf, metrics, tokenInHeader := c.logf, c.metrics, c.tokenInHeader
+	c.RUnlock()
+	if metrics == nil {
+		metrics = noopMetrics{}
+	}
+	endpoint := endpointOf(strings.TrimPrefix(outURL, c._baseURL()))
+	var token string
+	if tokenInHeader && tokenHeaderEndpoints[endpoint] {
+		outURL, token = cutAccessToken(outURL)
+	}
+
+	hreq, err := http.NewRequest(method, outURL, body)
 	if err != nil {
+		err = redactError(err)
+		span.Annotate(nil, "Failed to create http request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
 		return nil, err
 	}
+	hreq = hreq.WithContext(ctx)
+	c.setHeaders(ctx, hreq)
+	if token != "" {
+		hreq.Header.Set("Authorization", "Bearer "+token)
+	}
 
-	dres := new(DurationResponse)
-	if err := json.Unmarshal(slurp, dres); err != nil {
+	redactedURL := redactAccessToken(outURL)
+	if logf != nil {
+		logf("mapbox: %s %s", method, redactedURL)
+	}
+
+	start := time.Now()
+	httpClient := c._httpClient()
+	res, err = httpClient.Do(hreq)
+	statusCode := 0
+	if err == nil {
+		statusCode = res.StatusCode
+	}
+	metrics.ObserveRequest(endpoint, statusCode, time.Since(start))
+	if logf != nil {
+		if err != nil {
+			logf("mapbox: %s %s failed after %s: %s", method, redactedURL, time.Since(start), redactAccessToken(err.Error()))
+		} else {
+			logf("mapbox: %s %s %d in %s", method, redactedURL, res.StatusCode, time.Since(start))
+		}
+	}
+	if err != nil {
+		err = redactError(err)
+		span.Annotate(nil, "Failed to make http request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
 		return nil, err
 	}
+	if res.Body == nil {
+		res.Body = http.NoBody
+	}
+	span.AddAttributes(trace.Int64Attribute("http.status_code", int64(res.StatusCode)))
 
-	return dres, nil
+	if !statusOK(res.StatusCode) {
+		defer drainAndClose(res.Body)
+		span.Annotate(nil, "Bad response")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: res.Status})
+		return nil, errorFromResponse(res)
+	}
+	return res, nil
+}
+
+// Metrics records the requests that a client makes,
+// e.g. to export them to Prometheus.
+type Metrics interface {
+	// ObserveRequest is called after each request's response
+	// headers arrive, or the request fails, which is with a
+	// statusCode of 0, taking d. The endpoint is the API such
+	// as "geocoding", "matrix" or "directions".
+	ObserveRequest(endpoint string, statusCode int, d time.Duration)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveRequest(string, int, time.Duration) {}
+
+// endpointOf returns the API that path, relative
+// to the base URL, belongs to for Metrics.
+func endpointOf(path string) string {
+	path = strings.TrimPrefix(path, "/")
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	segments := strings.Split(path, "/")
+	switch api := segments[0]; api {
+	case "distances":
+		return "matrix"
+	case "search":
+		return "geocoding"
+	case "styles":
+		if len(segments) > 4 && segments[4] == "static" {
+			return "static"
+		}
+		return api
+	case "v4":
+		if len(segments) > 2 && segments[2] == "tilequery" {
+			return "tilequery"
+		}
+		return "tilesets"
+	default:
+		return api
+	}
+}
+
+func (c *Client) _tokenInHeader() bool {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.tokenInHeader
+}
+
+// tokenHeaderEndpoints are the APIs that accept the access
+// token in the Authorization header, for WithTokenInHeader.
+var tokenHeaderEndpoints = map[string]bool{
+	"geocoding":       true,
+	"directions":      true,
+	"matrix":          true,
+	"matching":        true,
+	"isochrone":       true,
+	"optimized-trips": true,
+}
+
+// cutAccessToken removes the access_token query parameter
+// from rawURL, returning the URL without it and its value.
+// The rest of the query is left as is, since some of the
+// APIs use semicolons that url.ParseQuery would reject.
+func cutAccessToken(rawURL string) (string, string) {
+	i := strings.Index(rawURL, "?")
+	if i < 0 {
+		return rawURL, ""
+	}
+	var token string
+	var kept []string
+	for _, param := range strings.Split(rawURL[i+1:], "&") {
+		if value := strings.TrimPrefix(param, "access_token="); value != param {
+			token, _ = url.QueryUnescape(value)
+			continue
+		}
+		kept = append(kept, param)
+	}
+	if len(kept) == 0 {
+		return rawURL[:i], token
+	}
+	return rawURL[:i+1] + strings.Join(kept, "&"), token
+}
+
+// redactAccessToken replaces the value of every access_token
+// query parameter in s, a URL or a message quoting one, with
+// "REDACTED" so that it can be logged without leaking the token.
+func redactAccessToken(s string) string {
+	const param = "access_token="
+	var b strings.Builder
+	for {
+		i := strings.Index(s, param)
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		b.WriteString(s[:i+len(param)])
+		b.WriteString("REDACTED")
+		s = s[i+len(param):]
+		// The token ends at the next parameter, or at the
+		// end of the URL e.g. at a quote or a space.
+		end := strings.IndexAny(s, "&#\"' \n")
+		if end < 0 {
+			end = len(s)
+		}
+		s = s[end:]
+	}
+}
+
+// redactError returns err without any access token, such as
+// that in the URL of the *url.Error returned for a bad URL or
+// a failed request, keeping it a *url.Error for callers.
+func redactError(err error) error {
+	if ue, ok := err.(*url.Error); ok {
+		redacted := *ue
+		redacted.URL = redactAccessToken(ue.URL)
+		if msg := redacted.Err.Error(); strings.Contains(msg, "access_token=") {
+			redacted.Err = errors.New(redactAccessToken(msg))
+		}
+		return &redacted
+	}
+	if msg := err.Error(); strings.Contains(msg, "access_token=") {
+		return errors.New(redactAccessToken(msg))
+	}
+	return err
+}
+
+// withTimeout applies the WithTimeout deadline to ctx
+// unless it already has a deadline of its own.
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	c.RLock()
+	timeout := c.timeout
+	c.RUnlock()
+
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (coc *cancelOnClose) Close() error {
+	err := coc.ReadCloser.Close()
+	coc.cancel()
+	return err
 }
 
 func NewClient(opts ...Option) (*Client, error) {
-	c := new(Client)
+	c := &Client{limiter: newRateLimiter(systemClock{})}
 	for _, opt := range opts {
 		opt.apply(c)
 	}
+	if c.httpClient == nil {
+		c.pool = c.newPool()
+		c.httpClient = &http.Client{Transport: &ochttp.Transport{Base: c.pool}}
+	}
+	if len(c.wrappers) > 0 {
+		base := c.httpClient.Transport
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		c.wrapped = base
+		for _, wrap := range c.wrappers {
+			c.wrapped = wrap(c.wrapped)
+		}
+	}
+	if c.requireAPIKey && c.APIKey() == "" {
+		return nil, errNoAPIKey
+	}
 
 	return c, nil
 }
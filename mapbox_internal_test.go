@@ -0,0 +1,51 @@
+package mapbox
+
+import "testing"
+
+func TestAPIKeySource(t *testing.T) {
+	defer func(key string) { defaultEnvAPIKey = key }(defaultEnvAPIKey)
+
+	tests := []struct {
+		envKey     string
+		opts       []Option
+		setKey     string
+		want       KeySource
+		wantAPIKey string
+	}{
+		0: {want: SourceNone},
+		1: {envKey: "pk.env", want: SourceEnv, wantAPIKey: "pk.env"},
+		2: {opts: []Option{WithAPIKey("pk.option")}, want: SourceExplicit, wantAPIKey: "pk.option"},
+		3: {setKey: "pk.set", want: SourceExplicit, wantAPIKey: "pk.set"},
+		// An explicit key wins over the environment.
+		4: {envKey: "pk.env", opts: []Option{WithAPIKey("pk.option")}, want: SourceExplicit, wantAPIKey: "pk.option"},
+		5: {envKey: "pk.env", setKey: "pk.set", want: SourceExplicit, wantAPIKey: "pk.set"},
+	}
+
+	for i, tt := range tests {
+		defaultEnvAPIKey = tt.envKey
+		client, err := NewClient(tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.setKey != "" {
+			client.SetAPIKey(tt.setKey)
+		}
+		if g, w := client.APIKeySource(), tt.want; g != w {
+			t.Errorf("#%d: source got %q want %q", i, g, w)
+		}
+		if g, w := client.APIKey(), tt.wantAPIKey; g != w {
+			t.Errorf("#%d: key got %q want %q", i, g, w)
+		}
+	}
+
+	// Clearing the explicit key falls back to the environment.
+	defaultEnvAPIKey = "pk.env"
+	client, err := NewClient(WithAPIKey("pk.option"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetAPIKey("")
+	if g, w := client.APIKeySource(), SourceEnv; g != w {
+		t.Errorf("after clearing: source got %q want %q", g, w)
+	}
+}
@@ -4,17 +4,25 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/orijtech/mapbox"
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/trace"
 )
 
 func TestLatLonPairJSONUnmarshal(t *testing.T) {
@@ -89,12 +97,23 @@ var _ http.RoundTripper = (*tBackend)(nil)
 type roundTrip func(*http.Request) (*http.Response, error)
 type produceRT func(*tBackend) roundTrip
 
+var _ http.RoundTripper = (roundTrip)(nil)
+
+func (rt roundTrip) RoundTrip(req *http.Request) (*http.Response, error) {
+	return rt(req)
+}
+
 var routeMatchesToRoundTripper = map[string]produceRT{
 	"/distances": func(b *tBackend) roundTrip { return b.durationRoundTrip },
 	"/geocoding": func(b *tBackend) roundTrip { return b.geocodeRoundTrip },
 }
 
 func (backend *tBackend) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Just like *http.Transport, bail out early on a done context.
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
 	path := req.URL.Path
 
 	var rtFn func(*http.Request) (*http.Response, error)
@@ -113,7 +132,8 @@ func (backend *tBackend) RoundTrip(req *http.Request) (*http.Response, error) {
 }
 
 var knownPlacesToCodes = map[string]string{
-	"Los Angeles": "LA",
+	"Los Angeles":   "LA",
+	"San Francisco": "SF",
 }
 
 func (backend *tBackend) geocodeRoundTrip(req *http.Request) (*http.Response, error) {
@@ -129,6 +149,9 @@ func (backend *tBackend) geocodeRoundTrip(req *http.Request) (*http.Response, er
 
 	placeInfo := splits[len(splits)-1]
 	place := strings.TrimSuffix(placeInfo, ".json")
+	if places := strings.Split(place, ";"); len(places) > 1 {
+		return batchGeocodeResp(places)
+	}
 	shortID := knownPlacesToCodes[place]
 	if shortID == "" {
 		msg := fmt.Sprintf("%q not found", shortID)
@@ -139,6 +162,23 @@ func (backend *tBackend) geocodeRoundTrip(req *http.Request) (*http.Response, er
 	return respFromFileContents(fullPath)
 }
 
+func batchGeocodeResp(places []string) (*http.Response, error) {
+	var batch []*mapbox.GeocodeResponse
+	for _, place := range places {
+		shortID := knownPlacesToCodes[place]
+		if shortID == "" {
+			msg := fmt.Sprintf("%q not found", place)
+			return makeResp(msg, http.StatusNotFound, http.NoBody), nil
+		}
+		batch = append(batch, geocodeResponseFromFile(shortID))
+	}
+	blob, err := json.Marshal(batch)
+	if err != nil {
+		return nil, err
+	}
+	return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(bytes.NewReader(blob))), nil
+}
+
 func respFromFileContents(path string) (*http.Response, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -274,25 +314,89 @@ func TestRoundtripDurationResponse(t *testing.T) {
 
 }
 
-func geocodeResponsePath(shortID string) string {
-	return fmt.Sprintf("./testdata/places-%s.json", shortID)
-}
+func TestUnreachablePairs(t *testing.T) {
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			return respFromFileContents("./testdata/durations-3x3.json")
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
 
-func geocodeResponseFromFile(shortID string) *mapbox.GeocodeResponse {
-	fullPath := geocodeResponsePath(shortID)
-	blob, err := ioutil.ReadFile(fullPath)
+	dres, err := client.RequestDuration(context.Background(), &mapbox.DurationRequest{
+		Coordinates: []*mapbox.LatLonPair{
+			{13.41894, 52.50055},
+			{14.10293, 52.50055},
+			{13.50116, 53.10293},
+		},
+	})
 	if err != nil {
-		return nil
+		t.Fatal(err)
+	}
+	if g, w := dres.UnreachablePairs(), [][2]int{{0, 2}}; !reflect.DeepEqual(g, w) {
+		t.Errorf("got %v want %v", g, w)
 	}
 
-	gr := new(mapbox.GeocodeResponse)
-	if err := json.Unmarshal(blob, gr); err != nil {
-		return nil
+	// A coordinate that couldn't be snapped has a whole row
+	// and column without paths, apart from to itself.
+	dres = &mapbox.DurationResponse{
+		Durations: []*mapbox.LatLonPair{
+			{0, mapbox.NoPathDuration, 120},
+			{mapbox.NoPathDuration, 0, mapbox.NoPathDuration},
+			{118, mapbox.NoPathDuration, 0},
+		},
+	}
+	want := [][2]int{{0, 1}, {1, 0}, {1, 2}, {2, 1}}
+	if g := dres.UnreachablePairs(); !reflect.DeepEqual(g, want) {
+		t.Errorf("got %v want %v", g, want)
+	}
+
+	if g := (&mapbox.DurationResponse{}).UnreachablePairs(); len(g) != 0 {
+		t.Errorf("empty response: got %v want none", g)
 	}
-	return gr
 }
 
-func TestLookupPlace(t *testing.T) {
+func TestNoPathHelpers(t *testing.T) {
+	row := new(mapbox.LatLonPair)
+	if err := json.Unmarshal([]byte(`[0, 2910.5, null]`), row); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		i    int
+		want bool
+	}{
+		0: {i: 0, want: true}, // A zero duration to itself is a path.
+		1: {i: 1, want: true},
+		2: {i: 2, want: false},
+		3: {i: 3, want: false},
+		4: {i: -1, want: false},
+	}
+	for _, tt := range tests {
+		if got := row.HasPath(tt.i); got != tt.want {
+			t.Errorf("HasPath(%d): got %v want %v", tt.i, got, tt.want)
+		}
+	}
+
+	if !mapbox.IsNoPath((*row)[2]) || !mapbox.IsNoPath(mapbox.NoPathDuration) {
+		t.Errorf("IsNoPath: expected the null entry to be no path")
+	}
+	// Durations and distances are never negative, so -1 is only
+	// ever the sentinel, while any other value is a real one.
+	for _, v := range []float32{0, 0.5, 2910.5, -0.5} {
+		if mapbox.IsNoPath(v) {
+			t.Errorf("IsNoPath(%v): got true want false", v)
+		}
+	}
+
+	var nilRow *mapbox.LatLonPair
+	if nilRow.HasPath(0) {
+		t.Errorf("HasPath on a nil row: got true want false")
+	}
+}
+
+func TestRequestDurationCancelledContext(t *testing.T) {
 	backend := &tBackend{
 		mapping: durationsMap,
 	}
@@ -300,54 +404,2865 @@ func TestLookupPlace(t *testing.T) {
 	client, err := mapbox.NewClient(
 		mapbox.WithHTTPClient(&http.Client{Transport: backend}),
 	)
-
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req := &mapbox.DurationRequest{
+		Coordinates: []*mapbox.LatLonPair{
+			{13.41894, 52.50055},
+			{14.10293, 52.50055},
+		},
+	}
+	dres, err := client.RequestDuration(ctx, req)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got err: %v; want context.Canceled", err)
+	}
+	if dres != nil {
+		t.Errorf("got non-nil response: %#v", dres)
+	}
+}
+
+func TestRequestDurationDistances(t *testing.T) {
 	tests := []struct {
-		query   string
-		wantErr bool
-		want    *mapbox.GeocodeResponse
+		annotations []string
+		wantQuery   string
+		body        string
+		want        *mapbox.DurationResponse
 	}{
 		0: {
-			query: "Los Angeles",
-			want:  geocodeResponseFromFile("LA"),
+			body: `{"durations": [[0, 2910], [2903, 0]]}`,
+			want: &mapbox.DurationResponse{
+				Durations: []*mapbox.LatLonPair{{0, 2910}, {2903, 0}},
+			},
+		},
+		1: {
+			annotations: []string{mapbox.AnnotationDuration, mapbox.AnnotationDistance},
+			wantQuery:   "annotations=duration,distance",
+			body:        `{"durations": [[0, 2910], [2903, 0]], "distances": [[0, 51234.5], [50998, 0]]}`,
+			want: &mapbox.DurationResponse{
+				Durations: []*mapbox.LatLonPair{{0, 2910}, {2903, 0}},
+				Distances: []*mapbox.LatLonPair{{0, 51234.5}, {50998, 0}},
+			},
 		},
 	}
 
 	for i, tt := range tests {
-		gr, err := client.LookupPlace(context.Background(), tt.query)
+		var gotURL string
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				gotURL = req.URL.String()
+				return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(tt.body))), nil
+			}),
+		}))
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+
+		req := &mapbox.DurationRequest{
+			Coordinates: []*mapbox.LatLonPair{
+				{13.41894, 52.50055},
+				{14.10293, 52.50055},
+			},
+			Annotations: tt.annotations,
+		}
+		dres, err := client.RequestDuration(context.Background(), req)
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+
+		if tt.wantQuery != "" && !strings.Contains(gotURL, tt.wantQuery) {
+			t.Errorf("#%d: URL %q does not contain %q", i, gotURL, tt.wantQuery)
+		}
+		if tt.wantQuery == "" && strings.Contains(gotURL, "annotations") {
+			t.Errorf("#%d: URL %q unexpectedly has annotations", i, gotURL)
+		}
+		if !reflect.DeepEqual(dres, tt.want) {
+			gotBytes, _ := json.MarshalIndent(dres, "", "  ")
+			wantBytes, _ := json.MarshalIndent(tt.want, "", "  ")
+			t.Errorf("#%d:\ngot: %s\nwant: %s", i, gotBytes, wantBytes)
+		}
+	}
+}
+
+func TestRequestDurationRateLimited(t *testing.T) {
+	tests := []struct {
+		retryAfter     string
+		wantRetryAfter time.Duration
+	}{
+		0: {retryAfter: "3", wantRetryAfter: 3 * time.Second},
+		1: {wantRetryAfter: 0},
+		2: {retryAfter: "soon", wantRetryAfter: 0},
+	}
+
+	for i, tt := range tests {
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				res := makeResp("429 Too Many Requests", http.StatusTooManyRequests,
+					ioutil.NopCloser(strings.NewReader(`{"message": "Too Many Requests"}`)))
+				if tt.retryAfter != "" {
+					res.Header.Set("Retry-After", tt.retryAfter)
+				}
+				return res, nil
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = client.RequestDuration(context.Background(), &mapbox.DurationRequest{
+			Coordinates: []*mapbox.LatLonPair{{13.41894, 52.50055}, {14.10293, 52.50055}},
+		})
+		var rle *mapbox.RateLimitError
+		if !errors.As(err, &rle) {
+			t.Errorf("#%d: got err %v (%T); want a *RateLimitError", i, err, err)
+			continue
+		}
+		if g, w := rle.RetryAfter, tt.wantRetryAfter; g != w {
+			t.Errorf("#%d: RetryAfter got %s want %s", i, g, w)
+		}
+		if !rle.Temporary() {
+			t.Errorf("#%d: want a temporary error", i)
+		}
+		var me *mapbox.MapboxError
+		if !errors.As(err, &me) || me.StatusCode != http.StatusTooManyRequests {
+			t.Errorf("#%d: got err %v; want a 429 *MapboxError", i, err)
+		}
+	}
+}
+
+func TestRequestDurationProfile(t *testing.T) {
+	tests := []struct {
+		profile  mapbox.Profile
+		wantPath string
+	}{
+		0: {profile: "", wantPath: "/distances/v1/mapbox/driving"},
+		1: {profile: "walking", wantPath: "/distances/v1/mapbox/walking"},
+		2: {profile: "cycling", wantPath: "/distances/v1/mapbox/cycling"},
+	}
+
+	for i, tt := range tests {
+		var gotPath string
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				gotPath = req.URL.Path
+				body := `{"durations": [[0, 2910], [2903, 0]]}`
+				return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(body))), nil
+			}),
+		}))
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+
+		req := &mapbox.DurationRequest{
+			Profile: tt.profile,
+			Coordinates: []*mapbox.LatLonPair{
+				{13.41894, 52.50055},
+				{14.10293, 52.50055},
+			},
+		}
+		if _, err := client.RequestDuration(context.Background(), req); err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if gotPath != tt.wantPath {
+			t.Errorf("#%d: path got %q want %q", i, gotPath, tt.wantPath)
+		}
+	}
+}
+
+func TestRequestDurationCoordinateLimit(t *testing.T) {
+	coords := func(n int) []*mapbox.LatLonPair {
+		pairs := make([]*mapbox.LatLonPair, n)
+		for i := range pairs {
+			pairs[i] = &mapbox.LatLonPair{13.41894 + float32(i)/100, 52.50055}
+		}
+		return pairs
+	}
+
+	tests := []struct {
+		profile mapbox.Profile
+		n       int
+		wantErr bool
+	}{
+		0: {profile: "", n: 25},
+		1: {profile: "", n: 26, wantErr: true},
+		2: {profile: "walking", n: 100, wantErr: true},
+		3: {profile: "driving-traffic", n: 10},
+		4: {profile: "driving-traffic", n: 11, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		requests := 0
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				requests++
+				return respFromFileContents("./testdata/durations-3x3.json")
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req := &mapbox.DurationRequest{Profile: tt.profile, Coordinates: coords(tt.n)}
+		_, err = client.RequestDuration(context.Background(), req)
 		if tt.wantErr {
 			if err == nil {
-				t.Errorf("#%d: want non-nil err", i)
+				t.Errorf("#%d: want non-nil error", i)
+			}
+			if requests != 0 {
+				t.Errorf("#%d: made %d requests, want none", i, requests)
 			}
 			continue
 		}
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+		}
+	}
+}
+
+func TestRequestDurationSourcesDestinations(t *testing.T) {
+	var gotQuery string
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			gotQuery = req.URL.RawQuery
+			body := `{"durations": [[2910, 4120, 3305]]}`
+			return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(body))), nil
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := &mapbox.DurationRequest{
+		Coordinates: []*mapbox.LatLonPair{
+			{13.41894, 52.50055},
+			{14.10293, 52.50055},
+			{13.50116, 53.10293},
+			{13.42855, 52.51231},
+		},
+		Sources:      []uint{0},
+		Destinations: []uint{1, 2, 3},
+	}
+	dres, err := client.RequestDuration(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"sources=0", "destinations=1;2;3"} {
+		if !strings.Contains(gotQuery, want) {
+			t.Errorf("query %q does not contain %q", gotQuery, want)
+		}
+	}
+	want := []*mapbox.LatLonPair{{2910, 4120, 3305}}
+	if !reflect.DeepEqual(dres.Durations, want) {
+		t.Errorf("durations:\ngot:  %v\nwant: %v", dres.Durations, want)
+	}
+
+	// Out of range indices must be rejected.
+	req.Destinations = []uint{1, 4}
+	if _, err := client.RequestDuration(context.Background(), req); err == nil {
+		t.Error("out of range destination: want non-nil error")
+	}
+}
+
+func TestRequestDurationDepartAt(t *testing.T) {
+	departAt := time.Date(2018, time.March, 5, 8, 30, 0, 0, time.FixedZone("PST", -8*60*60))
+
+	tests := []struct {
+		profile   mapbox.Profile
+		departAt  time.Time
+		wantQuery string
+		wantErr   bool
+	}{
+		0: {profile: "driving-traffic", departAt: departAt, wantQuery: "depart_at=2018-03-05T16:30:00Z"},
+		1: {profile: "driving-traffic"},
+		2: {profile: "driving", departAt: departAt, wantErr: true},
+		3: {profile: "", departAt: departAt, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		var gotQuery string
+		requests := 0
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				requests++
+				gotQuery = req.URL.RawQuery
+				body := `{"durations": [[0, 2910], [2903, 0]]}`
+				return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(body))), nil
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
 
+		req := &mapbox.DurationRequest{
+			Profile:  tt.profile,
+			DepartAt: tt.departAt,
+			Coordinates: []*mapbox.LatLonPair{
+				{13.41894, 52.50055},
+				{14.10293, 52.50055},
+			},
+		}
+		_, err = client.RequestDuration(context.Background(), req)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error", i)
+			}
+			if requests != 0 {
+				t.Errorf("#%d: made %d requests, want none", i, requests)
+			}
+			continue
+		}
 		if err != nil {
-			t.Errorf("#%d err: %v", i, err)
+			t.Errorf("#%d: %v", i, err)
 			continue
 		}
+		if tt.wantQuery == "" {
+			if strings.Contains(gotQuery, "depart_at") {
+				t.Errorf("#%d: query %q unexpectedly has depart_at", i, gotQuery)
+			}
+		} else if !strings.Contains(gotQuery, tt.wantQuery) {
+			t.Errorf("#%d: query %q does not contain %q", i, gotQuery, tt.wantQuery)
+		}
+	}
+}
 
-		gotBlob := jsonMarshal(gr)
-		wantBlob := jsonMarshal(tt.want)
-		if !bytes.Equal(gotBlob, wantBlob) {
-			t.Errorf("#%d\ngot:  %s\nwant: %s", i, gotBlob, wantBlob)
+func TestNewDurationRequest(t *testing.T) {
+	points := mapbox.LatLonMatrix{
+		{13.41894, 52.50055},
+		{14.10293, 52.50055},
+		{13.50116, 53.10293},
+	}
+	dreq := mapbox.NewDurationRequest(points)
+
+	want := []*mapbox.LatLonPair{
+		{13.41894, 52.50055},
+		{14.10293, 52.50055},
+		{13.50116, 53.10293},
+	}
+	if !reflect.DeepEqual(dreq.Coordinates, want) {
+		t.Errorf("coordinates\ngot:  %v\nwant: %v", dreq.Coordinates, want)
+	}
+	if g := dreq.CoordinateMatrix(); !reflect.DeepEqual(g, points) {
+		t.Errorf("matrix\ngot:  %v\nwant: %v", g, points)
+	}
+
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{Transport: &tBackend{mapping: durationsMap}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dres, err := client.RequestDuration(context.Background(), dreq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, w := len(dres.Durations), 3; g != w {
+		t.Errorf("got %d rows want %d", g, w)
+	}
+
+	// Rows that aren't [lon, lat] pairs must be rejected.
+	for i, bad := range []mapbox.LatLonMatrix{
+		{{13.41894, 52.50055}, {14.10293}},
+		{{13.41894, 52.50055}, {14.10293, 52.50055, 10}},
+	} {
+		if _, err := client.RequestDuration(context.Background(), mapbox.NewDurationRequest(bad)); err == nil {
+			t.Errorf("#%d: want non-nil error", i)
 		}
 	}
 }
 
-func jsonMarshal(v interface{}) []byte {
-	blob, _ := json.Marshal(v)
-	return blob
+func TestTruncatedResponse(t *testing.T) {
+	lookupPlace := func(client *mapbox.Client) error {
+		_, err := client.LookupPlace(context.Background(), "Los Angeles")
+		return err
+	}
+	truncatedPlace := `{"type": "FeatureCollection", "features": [{"id": "place.7397503093427640", "text": "Los`
+
+	tests := []struct {
+		body string
+		opts []mapbox.Option
+		do   func(*mapbox.Client) error
+	}{
+		0: {
+			body: `{"durations": [[0, 2910], [29`,
+			do: func(client *mapbox.Client) error {
+				_, err := client.RequestDuration(context.Background(), &mapbox.DurationRequest{
+					Coordinates: []*mapbox.LatLonPair{{13.41894, 52.50055}, {14.10293, 52.50055}},
+				})
+				return err
+			},
+		},
+		1: {body: truncatedPlace, do: lookupPlace},
+		// Only the permanent mode is cached.
+		2: {
+			body: truncatedPlace,
+			opts: []mapbox.Option{mapbox.WithCache(mapbox.NewLRUCache(1))},
+			do: func(client *mapbox.Client) error {
+				_, err := client.LookupPlacePermanent(context.Background(), "Los Angeles")
+				return err
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		client, err := mapbox.NewClient(append(tt.opts, mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(tt.body))), nil
+			}),
+		}))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = tt.do(client)
+		if err == nil {
+			t.Errorf("#%d: want non-nil error", i)
+			continue
+		}
+		if !strings.Contains(err.Error(), "200 OK") {
+			t.Errorf("#%d: error %q does not mention the status", i, err)
+		}
+	}
 }
 
-func makeResp(status string, code int, body io.ReadCloser) *http.Response {
-	return &http.Response{
-		Status:     status,
-		StatusCode: code,
-		Header:     make(http.Header),
-		Body:       body,
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*trace.SpanData
+}
+
+func (sr *spanRecorder) ExportSpan(sd *trace.SpanData) {
+	sr.mu.Lock()
+	sr.spans = append(sr.spans, sd)
+	sr.mu.Unlock()
+}
+
+func (sr *spanRecorder) byName(name string) (matches []*trace.SpanData) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	for _, sd := range sr.spans {
+		if sd.Name == name {
+			matches = append(matches, sd)
+		}
+	}
+	return matches
+}
+
+func TestRequestDurationSpan(t *testing.T) {
+	sr := new(spanRecorder)
+	trace.RegisterExporter(sr)
+	defer trace.UnregisterExporter(sr)
+
+	tests := []struct {
+		code           int
+		wantStatusCode int32
+	}{
+		0: {code: http.StatusOK, wantStatusCode: trace.StatusCodeOK},
+		1: {code: http.StatusInternalServerError, wantStatusCode: trace.StatusCodeInternal},
+	}
+
+	for i, tt := range tests {
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				body := `{"durations": [[0, 2910], [2903, 0]]}`
+				return makeResp(http.StatusText(tt.code), tt.code, ioutil.NopCloser(strings.NewReader(body))), nil
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, span := trace.StartSpan(context.Background(), "test", trace.WithSampler(trace.AlwaysSample()))
+		req := &mapbox.DurationRequest{
+			Coordinates: []*mapbox.LatLonPair{
+				{13.41894, 52.50055},
+				{14.10293, 52.50055},
+			},
+		}
+		_, _ = client.RequestDuration(ctx, req)
+		span.End()
+
+		spans := sr.byName("mapbox.(*Client).RequestDuration")
+		if len(spans) != i+1 {
+			t.Fatalf("#%d: got %d spans want %d", i, len(spans), i+1)
+		}
+		sd := spans[i]
+		if g, w := sd.Status.Code, tt.wantStatusCode; g != w {
+			t.Errorf("#%d: status code got %d want %d", i, g, w)
+		}
+		if g, w := sd.Attributes["http.status_code"], int64(tt.code); g != w {
+			t.Errorf("#%d: http.status_code got %v want %v", i, g, w)
+		}
+		if len(sd.Annotations) == 0 {
+			t.Errorf("#%d: expected annotations", i)
+		} else if g, w := sd.Annotations[0].Attributes["coordinates"], int64(2); g != w {
+			t.Errorf("#%d: coordinates got %v want %v", i, g, w)
+		}
+	}
+}
+
+func TestWithTraceSampler(t *testing.T) {
+	sr := new(spanRecorder)
+	trace.RegisterExporter(sr)
+	defer trace.UnregisterExporter(sr)
+
+	tests := []struct {
+		sampler   trace.Sampler
+		wantSpans int
+	}{
+		0: {sampler: trace.NeverSample(), wantSpans: 0},
+		// LookupPlace records a single span for the request.
+		1: {sampler: trace.AlwaysSample(), wantSpans: 1},
+	}
+
+	for i, tt := range tests {
+		sr.mu.Lock()
+		sr.spans = nil
+		sr.mu.Unlock()
+
+		client, err := mapbox.NewClient(
+			mapbox.WithHTTPClient(&http.Client{Transport: &tBackend{mapping: durationsMap}}),
+			mapbox.WithTraceSampler(tt.sampler),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The parent span is sampled, so only the
+		// client's sampler can turn off its spans.
+		ctx, span := trace.StartSpan(context.Background(), "test", trace.WithSampler(trace.AlwaysSample()))
+		if _, err := client.LookupPlace(ctx, "Los Angeles"); err != nil {
+			t.Errorf("#%d: %v", i, err)
+		}
+		span.End()
+
+		got := len(sr.byName("mapbox.(*Client).LookupPlace")) + len(sr.byName("mapbox.(*Client).ForwardGeocode"))
+		if got != tt.wantSpans {
+			t.Errorf("#%d: got %d sampled spans want %d", i, got, tt.wantSpans)
+		}
+	}
+}
+
+func geocodeResponsePath(shortID string) string {
+	return fmt.Sprintf("./testdata/places-%s.json", shortID)
+}
+
+func geocodeResponseFromFile(shortID string) *mapbox.GeocodeResponse {
+	fullPath := geocodeResponsePath(shortID)
+	blob, err := ioutil.ReadFile(fullPath)
+	if err != nil {
+		return nil
+	}
+
+	gr := new(mapbox.GeocodeResponse)
+	if err := json.Unmarshal(blob, gr); err != nil {
+		return nil
+	}
+	return gr
+}
+
+func TestLookupPlace(t *testing.T) {
+	backend := &tBackend{
+		mapping: durationsMap,
+	}
+
+	client, err := mapbox.NewClient(
+		mapbox.WithHTTPClient(&http.Client{Transport: backend}),
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query   string
+		wantErr bool
+		want    *mapbox.GeocodeResponse
+	}{
+		0: {
+			query: "Los Angeles",
+			want:  geocodeResponseFromFile("LA"),
+		},
+	}
+
+	for i, tt := range tests {
+		gr, err := client.LookupPlace(context.Background(), tt.query)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil err", i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("#%d err: %v", i, err)
+			continue
+		}
+
+		gotBlob := jsonMarshal(gr)
+		wantBlob := jsonMarshal(tt.want)
+		if !bytes.Equal(gotBlob, wantBlob) {
+			t.Errorf("#%d\ngot:  %s\nwant: %s", i, gotBlob, wantBlob)
+		}
+	}
+}
+
+func TestForwardAndReverseGeocodeValidation(t *testing.T) {
+	backend := &tBackend{
+		mapping: durationsMap,
+	}
+
+	client, err := mapbox.NewClient(
+		mapbox.WithHTTPClient(&http.Client{Transport: backend}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	// Valid forward geocode.
+	gr, err := client.ForwardGeocode(ctx, &mapbox.ForwardGeocodeRequest{
+		Query:   "Los Angeles",
+		Request: &mapbox.GeocodeRequest{Proximity: &mapbox.LatLonPair{-118.2, 34.0}},
+	})
+	if err != nil {
+		t.Fatalf("forward geocode err: %v", err)
+	}
+	if gotBlob, wantBlob := jsonMarshal(gr), jsonMarshal(geocodeResponseFromFile("LA")); !bytes.Equal(gotBlob, wantBlob) {
+		t.Errorf("forward geocode\ngot:  %s\nwant: %s", gotBlob, wantBlob)
+	}
+
+	// A forward geocode cannot have both a bbox and proximity.
+	_, err = client.ForwardGeocode(ctx, &mapbox.ForwardGeocodeRequest{
+		Query: "Los Angeles",
+		Request: &mapbox.GeocodeRequest{
+			Proximity:   &mapbox.LatLonPair{-118.2, 34.0},
+			BoundingBox: []float32{-118.5, 33.9, -118.1, 34.2},
+		},
+	})
+	if err == nil {
+		t.Errorf("forward geocode with bbox and proximity: want non-nil error")
+	}
+
+	// A reverse geocode cannot autocomplete.
+	_, err = client.ReverseGeocoding(ctx, &mapbox.ReverseGeocodeRequest{
+		Query:   "-118.2439,34.0544",
+		Request: &mapbox.GeocodeRequest{AutoComplete: true},
+	})
+	if err == nil {
+		t.Errorf("reverse geocode with autocomplete: want non-nil error")
+	}
+}
+
+func TestBatchGeocode(t *testing.T) {
+	backend := &tBackend{
+		mapping: durationsMap,
+	}
+
+	client, err := mapbox.NewClient(
+		mapbox.WithHTTPClient(&http.Client{Transport: backend}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tooMany := make([]string, 51)
+	for i := range tooMany {
+		tooMany[i] = "Los Angeles"
+	}
+
+	tests := []struct {
+		queries []string
+		wantErr bool
+		want    []*mapbox.GeocodeResponse
+	}{
+		0: {
+			queries: []string{"San Francisco", "Los Angeles"},
+			want:    []*mapbox.GeocodeResponse{geocodeResponseFromFile("SF"), geocodeResponseFromFile("LA")},
+		},
+		1: {
+			queries: []string{"Los Angeles"},
+			want:    []*mapbox.GeocodeResponse{geocodeResponseFromFile("LA")},
+		},
+		2: {
+			queries: tooMany,
+			wantErr: true,
+		},
+		3: {
+			wantErr: true,
+		},
+	}
+
+	for i, tt := range tests {
+		gresL, err := client.BatchGeocode(context.Background(), tt.queries, nil)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil err", i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("#%d err: %v", i, err)
+			continue
+		}
+
+		gotBlob := jsonMarshal(gresL)
+		wantBlob := jsonMarshal(tt.want)
+		if !bytes.Equal(gotBlob, wantBlob) {
+			t.Errorf("#%d\ngot:  %s\nwant: %s", i, gotBlob, wantBlob)
+		}
+	}
+}
+
+func TestBatchReverseGeocode(t *testing.T) {
+	var gotPath string
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			gotPath = req.URL.Path
+			return respFromFileContents("./testdata/places-batch-reverse.json")
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	points := []*mapbox.LatLonPair{
+		{-77.0366, 38.8971},
+		{-122.4194, 37.7749},
+		{-40.0, 30.0}, // In the Atlantic ocean.
+	}
+	gresL, err := client.BatchReverseGeocode(context.Background(), points, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPath := "/geocoding/v5/mapbox.places-permanent/-77.0366,38.8971;-122.4194,37.7749;-40,30.json"
+	if gotPath != wantPath {
+		t.Errorf("path\ngot:  %q\nwant: %q", gotPath, wantPath)
+	}
+
+	wantTexts := []string{"Pennsylvania Avenue Northwest", "Market Street", ""}
+	if g, w := len(gresL), len(wantTexts); g != w {
+		t.Fatalf("got %d responses want %d", g, w)
+	}
+	for i, gres := range gresL {
+		var text string
+		if len(gres.Features) > 0 {
+			text = gres.Features[0].Text
+		}
+		if text != wantTexts[i] {
+			t.Errorf("#%d: text got %q want %q", i, text, wantTexts[i])
+		}
+	}
+}
+
+func TestBatchReverseGeocodeErrors(t *testing.T) {
+	tooMany := make([]*mapbox.LatLonPair, 51)
+	for i := range tooMany {
+		tooMany[i] = &mapbox.LatLonPair{-77.0366, 38.8971}
+	}
+
+	tests := []struct {
+		points  []*mapbox.LatLonPair
+		greq    *mapbox.GeocodeRequest
+		wantErr string
+	}{
+		0: {points: nil},
+		1: {points: tooMany},
+		2: {points: []*mapbox.LatLonPair{{-77.0366, 38.8971}, {-77.0366}}},
+		3: {points: []*mapbox.LatLonPair{{-77.0366, 38.8971}, nil}},
+		4: {points: []*mapbox.LatLonPair{{-77.0366, 38.8971}}, greq: &mapbox.GeocodeRequest{AutoComplete: true}},
+		5: {
+			// The invalid request is reported rather than the invalid point.
+			points:  []*mapbox.LatLonPair{{-77.0366, 38.8971}, nil},
+			greq:    &mapbox.GeocodeRequest{AutoComplete: true},
+			wantErr: "autocomplete",
+		},
+	}
+
+	for i, tt := range tests {
+		requests := 0
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				requests++
+				return respFromFileContents("./testdata/places-batch-reverse.json")
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.BatchReverseGeocode(context.Background(), tt.points, tt.greq)
+		if err == nil {
+			t.Errorf("#%d: want non-nil error", i)
+		} else if !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("#%d: got %q want it to contain %q", i, err, tt.wantErr)
+		}
+		if requests != 0 {
+			t.Errorf("#%d: made %d requests for an invalid batch", i, requests)
+		}
+	}
+}
+
+func TestWithAPIKey(t *testing.T) {
+	var gotTokens []string
+	hc := &http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			gotTokens = append(gotTokens, req.URL.Query().Get("access_token"))
+			return respFromFileContents(geocodeResponsePath("LA"))
+		}),
+	}
+
+	keys := []string{"pk.first", "pk.second"}
+	for _, key := range keys {
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(hc), mapbox.WithAPIKey(key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g, w := client.APIKey(), key; g != w {
+			t.Errorf("APIKey: got %q want %q", g, w)
+		}
+		if _, err := client.LookupPlace(context.Background(), "Los Angeles"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !reflect.DeepEqual(gotTokens, keys) {
+		t.Errorf("access_token:\ngot:  %q\nwant: %q", gotTokens, keys)
+	}
+}
+
+func TestWithBaseURL(t *testing.T) {
+	for _, shortID := range []string{"LA", "SF"} {
+		shortID := shortID
+		t.Run(shortID, func(t *testing.T) {
+			t.Parallel()
+
+			cst := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				if !strings.HasPrefix(req.URL.Path, "/geocoding/v5/") {
+					http.Error(rw, "unexpected path "+req.URL.Path, http.StatusNotFound)
+					return
+				}
+				http.ServeFile(rw, req, geocodeResponsePath(shortID))
+			}))
+			defer cst.Close()
+
+			client, err := mapbox.NewClient(mapbox.WithBaseURL(cst.URL + "/"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gr, err := client.LookupPlace(context.Background(), "anywhere")
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotBlob := jsonMarshal(gr)
+			wantBlob := jsonMarshal(geocodeResponseFromFile(shortID))
+			if !bytes.Equal(gotBlob, wantBlob) {
+				t.Errorf("\ngot:  %s\nwant: %s", gotBlob, wantBlob)
+			}
+		})
+	}
+}
+
+func TestProximityEncoding(t *testing.T) {
+	var gotQuery string
+	client, err := mapbox.NewClient(
+		mapbox.WithAPIKey("pk.test"),
+		mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				gotQuery = req.URL.RawQuery
+				return respFromFileContents(geocodeResponsePath("LA"))
+			}),
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.ForwardGeocode(context.Background(), &mapbox.ForwardGeocodeRequest{
+		Query:   "Los Angeles",
+		Request: &mapbox.GeocodeRequest{Proximity: &mapbox.LatLonPair{-77.0, 38.8}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g, w := gotQuery, "access_token=pk.test&proximity=-77%2C38.8"; g != w {
+		t.Errorf("query:\ngot:  %q\nwant: %q", g, w)
+	}
+}
+
+func TestGeocodePermanentMode(t *testing.T) {
+	var gotPath string
+	requests := 0
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			requests++
+			gotPath = req.URL.Path
+			return respFromFileContents(geocodeResponsePath("LA"))
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	tests := []struct {
+		lookup   func() (*mapbox.GeocodeResponse, error)
+		wantPath string
+	}{
+		0: {
+			lookup:   func() (*mapbox.GeocodeResponse, error) { return client.LookupPlace(ctx, "Los Angeles") },
+			wantPath: "/geocoding/v5/mapbox.places/Los%20Angeles.json",
+		},
+		1: {
+			lookup:   func() (*mapbox.GeocodeResponse, error) { return client.LookupPlacePermanent(ctx, "Los Angeles") },
+			wantPath: "/geocoding/v5/mapbox.places-permanent/Los%20Angeles.json",
+		},
+		2: {
+			lookup:   func() (*mapbox.GeocodeResponse, error) { return client.LookupLatLon(ctx, 34.0544, -118.2439) },
+			wantPath: "/geocoding/v5/mapbox.places/-118.2439,34.0544.json",
+		},
+		3: {
+			lookup:   func() (*mapbox.GeocodeResponse, error) { return client.LookupLatLonPermanent(ctx, 34.0544, -118.2439) },
+			wantPath: "/geocoding/v5/mapbox.places-permanent/-118.2439,34.0544.json",
+		},
+	}
+
+	for i, tt := range tests {
+		if _, err := tt.lookup(); err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if escaped := (&url.URL{Path: gotPath}).EscapedPath(); escaped != tt.wantPath {
+			t.Errorf("#%d: path got %q want %q", i, escaped, tt.wantPath)
+		}
+	}
+
+	// Autocomplete results cannot be stored and unknown modes are rejected.
+	requests = 0
+	invalid := []*mapbox.ForwardGeocodeRequest{
+		{
+			Query:   "Los Ang",
+			Mode:    mapbox.GeocodePermanentPlaces,
+			Request: &mapbox.GeocodeRequest{AutoComplete: true},
+		},
+		{Query: "Los Angeles", Mode: "mapbox.places-forever"},
+	}
+	for i, freq := range invalid {
+		if _, err := client.ForwardGeocode(ctx, freq); err == nil {
+			t.Errorf("invalid #%d: want non-nil error", i)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("made %d requests for invalid modes, want none", requests)
+	}
+}
+
+func TestGeocodePropertyAccessors(t *testing.T) {
+	gr := geocodeResponseFromFile("POI")
+	if gr == nil || len(gr.Features) != 2 {
+		t.Fatalf("failed to load the POI fixture: %+v", gr)
+	}
+	poi, place := gr.Features[0].Properties, gr.Features[1].Properties
+
+	tests := []struct {
+		name   string
+		get    func() (string, bool)
+		want   string
+		wantOk bool
+	}{
+		0: {name: "category", get: poi.Category, want: "coffee, tea, coffee shop, cafe", wantOk: true},
+		1: {name: "address", get: poi.Address, want: "66 Mint St", wantOk: true},
+		2: {name: "phone", get: poi.Phone, want: "(510) 653-3394", wantOk: true},
+		3: {name: "maki", get: poi.Maki, want: "cafe", wantOk: true},
+		4: {name: "poi wikidata", get: poi.Wikidata},
+		5: {name: "place wikidata", get: place.Wikidata, want: "Q62", wantOk: true},
+		6: {name: "place category", get: place.Category},
+		7: {name: "nil properties", get: (*mapbox.GeocodeProperty)(nil).Address},
+	}
+
+	for i, tt := range tests {
+		got, ok := tt.get()
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("#%d %s: got (%q, %v) want (%q, %v)", i, tt.name, got, ok, tt.want, tt.wantOk)
+		}
+	}
+
+	// Uncommon properties are still accessible by key.
+	if landmark, _ := (*poi)["landmark"].(bool); !landmark {
+		t.Errorf("landmark: got %v want true", (*poi)["landmark"])
+	}
+}
+
+func TestGeocodeFeatureContext(t *testing.T) {
+	gr := geocodeResponseFromFile("POI")
+	if gr == nil || len(gr.Features) != 2 {
+		t.Fatalf("failed to load the POI fixture: %+v", gr)
+	}
+	poi, place := gr.Features[0], gr.Features[1]
+	california := &mapbox.GeocodeContext{
+		Id:        "region.11319063928738010",
+		Text:      "California",
+		ShortCode: "US-CA",
+		Wikidata:  "Q99",
+	}
+
+	tests := []struct {
+		name       string
+		feature    *mapbox.GeocodeFeature
+		wantRegion *mapbox.GeocodeContext
+		wantCode   string
+		wantQID    string
+	}{
+		0: {name: "poi", feature: poi, wantRegion: california, wantCode: "us"},
+		// The fixture's place has no context.
+		1: {name: "place", feature: place, wantQID: "Q62"},
+		2: {
+			name: "region",
+			feature: &mapbox.GeocodeFeature{
+				Id:         "region.11319063928738010",
+				Text:       "California",
+				Properties: &mapbox.GeocodeProperty{"short_code": "US-CA", "wikidata": "Q99"},
+				Context: []*mapbox.GeocodeContext{
+					{Id: "country.9053006287256050", Text: "United States", ShortCode: "us", Wikidata: "Q30"},
+				},
+			},
+			wantRegion: california,
+			wantCode:   "us",
+			wantQID:    "Q99",
+		},
+		3: {
+			name: "country",
+			feature: &mapbox.GeocodeFeature{
+				Id:         "country.9053006287256050",
+				Text:       "United States",
+				Properties: &mapbox.GeocodeProperty{"short_code": "us", "wikidata": "Q30"},
+			},
+			wantCode: "us",
+			wantQID:  "Q30",
+		},
+		4: {name: "nil", feature: nil},
+	}
+
+	for i, tt := range tests {
+		region, ok := tt.feature.Region()
+		if ok != (tt.wantRegion != nil) || !reflect.DeepEqual(region, tt.wantRegion) {
+			t.Errorf("#%d %s: region got (%+v, %v) want %+v", i, tt.name, region, ok, tt.wantRegion)
+		}
+		if code, ok := tt.feature.CountryCode(); code != tt.wantCode || ok != (tt.wantCode != "") {
+			t.Errorf("#%d %s: country code got (%q, %v) want %q", i, tt.name, code, ok, tt.wantCode)
+		}
+		if qid, ok := tt.feature.Wikidata(); qid != tt.wantQID || ok != (tt.wantQID != "") {
+			t.Errorf("#%d %s: wikidata got (%q, %v) want %q", i, tt.name, qid, ok, tt.wantQID)
+		}
+	}
+
+	// The other levels are found by their kind.
+	if gc, ok := poi.ContextOf("place"); !ok || gc.Text != "San Francisco" || gc.Wikidata != "Q62" {
+		t.Errorf("place: got (%+v, %v)", gc, ok)
+	}
+	if gc, ok := poi.ContextOf("district"); ok {
+		t.Errorf("district: got %+v want none", gc)
+	}
+}
+
+func TestReverseGeocodeRoutablePoints(t *testing.T) {
+	var gotQuery url.Values
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			gotQuery = req.URL.Query()
+			return respFromFileContents(geocodeResponsePath("routable"))
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gres, err := client.ReverseGeocoding(context.Background(), &mapbox.ReverseGeocodeRequest{
+		Query:   "-77.0366,38.8977",
+		Request: &mapbox.GeocodeRequest{Routing: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, w := gotQuery.Get("routing"), "true"; g != w {
+		t.Errorf("routing got %q want %q", g, w)
+	}
+
+	tests := []struct {
+		want *mapbox.LatLonPair
+	}{
+		// The rooftop address, with the points nested.
+		0: {want: &mapbox.LatLonPair{-77.03655, 38.89816}},
+		1: {want: &mapbox.LatLonPair{-77.03484, 38.89743}},
+		// A place has no routable points.
+		2: {},
+	}
+	if g, w := len(gres.Features), len(tests); g != w {
+		t.Fatalf("got %d features want %d", g, w)
+	}
+	for i, tt := range tests {
+		got, ok := gres.Features[i].RoutablePoint()
+		if ok != (tt.want != nil) || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("#%d: got (%v, %v) want %v", i, got, ok, tt.want)
+		}
+	}
+}
+
+func TestForwardGeocodeBoundingBox(t *testing.T) {
+	tests := []struct {
+		bbox     []float32
+		wantBBox []string
+		wantErr  bool
+	}{
+		0: {
+			bbox:     []float32{-118.5, 33.9, -118.1, 34.2},
+			wantBBox: []string{"-118.5,33.9,-118.1,34.2"},
+		},
+		1: {bbox: []float32{-118.5, 33.9, -118.1}, wantErr: true},
+		2: {bbox: []float32{-118.5, 33.9, -118.1, 34.2, 0}, wantErr: true},
+		3: {bbox: []float32{-118.1, 33.9, -118.5, 34.2}, wantErr: true},
+		4: {bbox: []float32{-118.5, 34.2, -118.1, 33.9}, wantErr: true},
+		// A degenerate box around a single point is still valid.
+		5: {
+			bbox:     []float32{-118.5, 33.9, -118.5, 33.9},
+			wantBBox: []string{"-118.5,33.9,-118.5,33.9"},
+		},
+	}
+
+	for i, tt := range tests {
+		var gotQuery url.Values
+		requests := 0
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				requests++
+				gotQuery = req.URL.Query()
+				return respFromFileContents(geocodeResponsePath("LA"))
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = client.ForwardGeocode(context.Background(), &mapbox.ForwardGeocodeRequest{
+			Query:   "Los Angeles",
+			Request: &mapbox.GeocodeRequest{BoundingBox: tt.bbox},
+		})
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error", i)
+			}
+			if requests != 0 {
+				t.Errorf("#%d: made %d requests, want none", i, requests)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if g, w := gotQuery["bbox"], tt.wantBBox; !reflect.DeepEqual(g, w) {
+			t.Errorf("#%d: bbox got %q want %q", i, g, w)
+		}
+	}
+}
+
+func TestGeocodeRequestQuery(t *testing.T) {
+	fuzzyMatchOff, fuzzyMatchOn := false, true
+	tests := []struct {
+		req  *mapbox.GeocodeRequest
+		want url.Values
+	}{
+		0: {
+			req:  &mapbox.GeocodeRequest{Limit: 3},
+			want: url.Values{"limit": {"3"}},
+		},
+		1: {
+			req:  &mapbox.GeocodeRequest{Limit: 3, AutoComplete: true},
+			want: url.Values{"limit": {"3"}, "autocomplete": {"true"}},
+		},
+		2: {
+			req:  &mapbox.GeocodeRequest{Types: []mapbox.GeocodeType{mapbox.GTypeAddress, mapbox.GTypePOI}},
+			want: url.Values{"types": {"address,poi"}},
+		},
+		3: {
+			req:  &mapbox.GeocodeRequest{FuzzyMatch: &fuzzyMatchOff},
+			want: url.Values{"fuzzyMatch": {"false"}},
+		},
+		4: {
+			req:  &mapbox.GeocodeRequest{FuzzyMatch: &fuzzyMatchOn, Routing: true},
+			want: url.Values{"fuzzyMatch": {"true"}, "routing": {"true"}},
+		},
+		5: {
+			req:  &mapbox.GeocodeRequest{},
+			want: url.Values{},
+		},
+		6: {
+			req:  &mapbox.GeocodeRequest{Worldview: mapbox.WorldviewCN},
+			want: url.Values{"worldview": {"cn"}},
+		},
+	}
+
+	for i, tt := range tests {
+		var gotQuery url.Values
+		client, err := mapbox.NewClient(
+			mapbox.WithHTTPClient(&http.Client{
+				Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+					gotQuery = req.URL.Query()
+					return respFromFileContents(geocodeResponsePath("LA"))
+				}),
+			}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		freq := &mapbox.ForwardGeocodeRequest{Query: "Los Angeles", Request: tt.req}
+		if _, err := client.ForwardGeocode(context.Background(), freq); err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+
+		gotQuery.Del("access_token")
+		if !reflect.DeepEqual(gotQuery, tt.want) {
+			t.Errorf("#%d: query\ngot:  %v\nwant: %v", i, gotQuery, tt.want)
+		}
+	}
+}
+
+func TestLookupLonLatOrder(t *testing.T) {
+	// The White House, whose latitude is north and
+	// longitude is west, so that they can't be mistaken.
+	const lat, lon = 38.8977, -77.0365
+
+	var gotPath string
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			gotPath = req.URL.Path
+			return respFromFileContents(geocodeResponsePath("LA"))
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	tests := []struct {
+		lookup   func() (*mapbox.GeocodeResponse, error)
+		wantPath string
+	}{
+		0: {
+			lookup:   func() (*mapbox.GeocodeResponse, error) { return client.LookupLatLon(ctx, lat, lon) },
+			wantPath: "/geocoding/v5/mapbox.places/-77.0365,38.8977.json",
+		},
+		1: {
+			lookup: func() (*mapbox.GeocodeResponse, error) {
+				return client.LookupPoint(ctx, mapbox.Coordinate{Lat: lat, Lon: lon})
+			},
+			wantPath: "/geocoding/v5/mapbox.places/-77.0365,38.8977.json",
+		},
+		// The field order doesn't matter.
+		2: {
+			lookup: func() (*mapbox.GeocodeResponse, error) {
+				return client.LookupPoint(ctx, mapbox.Coordinate{Lon: lon, Lat: lat})
+			},
+			wantPath: "/geocoding/v5/mapbox.places/-77.0365,38.8977.json",
+		},
+	}
+
+	for i, tt := range tests {
+		if _, err := tt.lookup(); err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if g, w := gotPath, tt.wantPath; g != w {
+			t.Errorf("#%d: path got %q want %q", i, g, w)
+		}
+	}
+}
+
+func TestLookupPlaceNilRequest(t *testing.T) {
+	var gotQuery url.Values
+	client, err := mapbox.NewClient(
+		mapbox.WithAPIKey("test-key"),
+		mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				gotQuery = req.URL.Query()
+				return respFromFileContents(geocodeResponsePath("LA"))
+			}),
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// LookupPlace leaves the ForwardGeocodeRequest's Request nil.
+	if _, err := client.LookupPlace(context.Background(), "Los Angeles"); err != nil {
+		t.Fatal(err)
+	}
+	want := url.Values{"access_token": {"test-key"}}
+	if !reflect.DeepEqual(gotQuery, want) {
+		t.Errorf("query\ngot:  %v\nwant: %v", gotQuery, want)
+	}
+}
+
+func TestLookupPlaceNear(t *testing.T) {
+	tests := []struct {
+		near          *mapbox.LatLonPair
+		wantProximity string
+		wantErr       bool
+	}{
+		0: {near: &mapbox.LatLonPair{-89.6501, 39.7817}, wantProximity: "-89.6501,39.7817"},
+		1: {near: &mapbox.LatLonPair{-72.5898, 42.1015}, wantProximity: "-72.5898,42.1015"},
+		2: {near: nil},
+		3: {near: &mapbox.LatLonPair{-89.6501}, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		var gotQuery url.Values
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				gotQuery = req.URL.Query()
+				return respFromFileContents(geocodeResponsePath("LA"))
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = client.LookupPlaceNear(context.Background(), "Springfield", tt.near)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if g, w := gotQuery.Get("proximity"), tt.wantProximity; g != w {
+			t.Errorf("#%d: proximity got %q want %q", i, g, w)
+		}
+		if g := len(gotQuery["proximity"]); g > 1 {
+			t.Errorf("#%d: got %d proximity values want at most 1", i, g)
+		}
+	}
+}
+
+func TestLookupPlaceInCountries(t *testing.T) {
+	tests := []struct {
+		countries   []string
+		wantCountry string
+		wantErr     bool
+	}{
+		0: {countries: []string{"fr"}, wantCountry: "fr"},
+		1: {countries: []string{"fr", "be", "CH"}, wantCountry: "fr,be,CH"},
+		2: {countries: nil, wantCountry: ""},
+		3: {countries: []string{"USA"}, wantErr: true},
+		4: {countries: []string{"fr", "f"}, wantErr: true},
+		5: {countries: []string{"f1"}, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		var gotQuery url.Values
+		requests := 0
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				requests++
+				gotQuery = req.URL.Query()
+				return respFromFileContents(geocodeResponsePath("LA"))
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = client.LookupPlaceInCountries(context.Background(), "Paris", tt.countries...)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error", i)
+			}
+			if requests != 0 {
+				t.Errorf("#%d: made %d requests for invalid countries", i, requests)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if g, w := gotQuery.Get("country"), tt.wantCountry; g != w {
+			t.Errorf("#%d: country got %q want %q", i, g, w)
+		}
+		if g := len(gotQuery["country"]); g > 1 {
+			t.Errorf("#%d: got %d country values want at most 1", i, g)
+		}
+	}
+}
+
+func TestGeocodeRaw(t *testing.T) {
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			res, err := respFromFileContents(geocodeResponsePath("LA"))
+			if err == nil {
+				res.Header.Set("X-Request-Id", "req-1234")
+				res.Header.Set("Cache-Control", "max-age=300")
+			}
+			return res, err
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	gres, res, err := client.GeocodeRaw(ctx, &mapbox.ForwardGeocodeRequest{Query: "Los Angeles"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotBlob, wantBlob := jsonMarshal(gres), jsonMarshal(geocodeResponseFromFile("LA")); !bytes.Equal(gotBlob, wantBlob) {
+		t.Errorf("response\ngot:  %s\nwant: %s", gotBlob, wantBlob)
+	}
+	if g, w := res.Header.Get("X-Request-Id"), "req-1234"; g != w {
+		t.Errorf("X-Request-Id got %q want %q", g, w)
+	}
+	if g, w := res.Header.Get("Cache-Control"), "max-age=300"; g != w {
+		t.Errorf("Cache-Control got %q want %q", g, w)
+	}
+	if n, _ := res.Body.Read(make([]byte, 1)); n != 0 {
+		t.Errorf("expected the body to have been drained")
+	}
+
+	_, res, err = client.ReverseGeocodeRaw(ctx, &mapbox.ReverseGeocodeRequest{Query: "-118.2439,34.0544"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, w := res.Header.Get("X-Request-Id"), "req-1234"; g != w {
+		t.Errorf("reverse: X-Request-Id got %q want %q", g, w)
+	}
+}
+
+func TestGeocodeInvalidWorldview(t *testing.T) {
+	requests := 0
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			requests++
+			return respFromFileContents(geocodeResponsePath("LA"))
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for i, worldview := range []string{"CN", "uk", "china"} {
+		greq := &mapbox.GeocodeRequest{Worldview: worldview}
+		if _, err := client.ForwardGeocode(ctx, &mapbox.ForwardGeocodeRequest{Query: "Los Angeles", Request: greq}); err == nil {
+			t.Errorf("#%d: forward geocode with worldview %q: want non-nil error", i, worldview)
+		}
+		if _, err := client.ReverseGeocoding(ctx, &mapbox.ReverseGeocodeRequest{Query: "-118.2439,34.0544", Request: greq}); err == nil {
+			t.Errorf("#%d: reverse geocode with worldview %q: want non-nil error", i, worldview)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("made %d requests, want none", requests)
+	}
+}
+
+func TestGeocodeQueryPathEscaping(t *testing.T) {
+	tests := []struct {
+		query    string
+		wantPath string
+	}{
+		0: {
+			query:    "1600 Pennsylvania Ave #200",
+			wantPath: "/geocoding/v5/mapbox.places/1600%20Pennsylvania%20Ave%20%23200.json",
+		},
+		1: {
+			query:    "Who? What/Where",
+			wantPath: "/geocoding/v5/mapbox.places/Who%3F%20What%2FWhere.json",
+		},
+		2: {
+			query:    "Taquerias El Farolito",
+			wantPath: "/geocoding/v5/mapbox.places/Taquerias%20El%20Farolito.json",
+		},
+	}
+
+	for i, tt := range tests {
+		var gotPath, gotQuery string
+		client, err := mapbox.NewClient(
+			mapbox.WithHTTPClient(&http.Client{
+				Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+					gotPath = req.URL.EscapedPath()
+					gotQuery = req.URL.Query().Get("access_token")
+					return respFromFileContents(geocodeResponsePath("LA"))
+				}),
+			}),
+			mapbox.WithAPIKey("pk.test"),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := client.LookupPlace(context.Background(), tt.query); err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if gotPath != tt.wantPath {
+			t.Errorf("#%d: path\ngot:  %q\nwant: %q", i, gotPath, tt.wantPath)
+		}
+		if gotQuery != "pk.test" {
+			t.Errorf("#%d: access_token got %q", i, gotQuery)
+		}
+	}
+}
+
+func TestGeocodeErrorResponse(t *testing.T) {
+	tests := []struct {
+		status      string
+		code        int
+		body        string
+		wantMessage string
+	}{
+		0: {
+			status:      "401 Unauthorized",
+			code:        http.StatusUnauthorized,
+			body:        `{"message":"Not Authorized - Invalid Token"}`,
+			wantMessage: "Not Authorized - Invalid Token",
+		},
+		1: {
+			status:      "429 Too Many Requests",
+			code:        http.StatusTooManyRequests,
+			body:        `{"message":"Too Many Requests"}`,
+			wantMessage: "Too Many Requests",
+		},
+		2: {
+			status:      "502 Bad Gateway",
+			code:        http.StatusBadGateway,
+			body:        "<html>Bad Gateway</html>",
+			wantMessage: "Bad Gateway",
+		},
+	}
+
+	for i, tt := range tests {
+		client, err := mapbox.NewClient(
+			mapbox.WithHTTPClient(&http.Client{
+				Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+					return makeResp(tt.status, tt.code, ioutil.NopCloser(strings.NewReader(tt.body))), nil
+				}),
+			}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		gr, err := client.LookupPlace(context.Background(), "Los Angeles")
+		if gr != nil {
+			t.Errorf("#%d: got non-nil response", i)
+		}
+		me := new(mapbox.MapboxError)
+		if !errors.As(err, &me) {
+			t.Errorf("#%d: got err %v (%T); want a *MapboxError", i, err, err)
+			continue
+		}
+		if me.StatusCode != tt.code {
+			t.Errorf("#%d: StatusCode got %d want %d", i, me.StatusCode, tt.code)
+		}
+		if me.Message != tt.wantMessage {
+			t.Errorf("#%d: Message got %q want %q", i, me.Message, tt.wantMessage)
+		}
+	}
+}
+
+func TestGeocodeLanguage(t *testing.T) {
+	var gotLanguage string
+	client, err := mapbox.NewClient(
+		mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				gotLanguage = req.URL.Query().Get("language")
+				return respFromFileContents("./testdata/places-LA-fr.json")
+			}),
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gr, err := client.ForwardGeocode(context.Background(), &mapbox.ForwardGeocodeRequest{
+		Query:   "Los Angeles",
+		Request: &mapbox.GeocodeRequest{Language: []string{"fr", "en"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, w := gotLanguage, "fr,en"; g != w {
+		t.Errorf("language: got %q want %q", g, w)
+	}
+	if g, w := gr.Features[0].PlaceName, "Los Angeles, Californie, États-Unis"; g != w {
+		t.Errorf("PlaceName: got %q want %q", g, w)
+	}
+}
+
+func TestErrorOnEmpty(t *testing.T) {
+	hc := &http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			body := `{"type":"FeatureCollection","query":["nowhere"],"features":[]}`
+			return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(body))), nil
+		}),
+	}
+
+	// By default an empty response is returned.
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(hc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gr, err := client.LookupPlace(context.Background(), "nowhere")
+	if err != nil {
+		t.Fatalf("default: got err %v", err)
+	}
+	if len(gr.Features) != 0 {
+		t.Errorf("default: got %d features want 0", len(gr.Features))
+	}
+
+	client, err = mapbox.NewClient(mapbox.WithHTTPClient(hc), mapbox.WithErrorOnEmpty())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.LookupPlace(context.Background(), "nowhere"); err != mapbox.ErrNoResults {
+		t.Errorf("LookupPlace: got err %v want %v", err, mapbox.ErrNoResults)
+	}
+	if _, err := client.LookupLatLon(context.Background(), 0, 0); err != mapbox.ErrNoResults {
+		t.Errorf("LookupLatLon: got err %v want %v", err, mapbox.ErrNoResults)
+	}
+}
+
+func TestGeocodeRequestValidate(t *testing.T) {
+	proximity := &mapbox.LatLonPair{-77.0366, 38.8977}
+	tests := []struct {
+		freq         *mapbox.ForwardGeocodeRequest
+		rreq         *mapbox.ReverseGeocodeRequest
+		wantProblems int
+	}{
+		0: {
+			freq: &mapbox.ForwardGeocodeRequest{
+				Query: "White House",
+				Mode:  mapbox.GeocodePermanentPlaces,
+				Request: &mapbox.GeocodeRequest{
+					Types:       []mapbox.GeocodeType{mapbox.GTypeAddress, mapbox.GTypePlace},
+					Limit:       10,
+					BoundingBox: []float32{-77.1, 38.8, -77.0, 38.9},
+				},
+			},
+		},
+		1: {
+			freq: &mapbox.ForwardGeocodeRequest{
+				Query:   "White House",
+				Mode:    mapbox.GeocodePermanentPlaces,
+				Request: &mapbox.GeocodeRequest{Types: []mapbox.GeocodeType{mapbox.GTypePOI}},
+			},
+			wantProblems: 1,
+		},
+		// Every problem is reported at once.
+		2: {
+			freq: &mapbox.ForwardGeocodeRequest{
+				Query: "White House",
+				Request: &mapbox.GeocodeRequest{
+					Types:       []mapbox.GeocodeType{"building"},
+					Limit:       11,
+					Proximity:   proximity,
+					BoundingBox: []float32{-77.0, 38.8, -77.1, 38.9},
+				},
+			},
+			wantProblems: 4,
+		},
+		3: {
+			freq: &mapbox.ForwardGeocodeRequest{
+				Query:   "White House",
+				Mode:    mapbox.GeocodePermanentPlaces,
+				Request: &mapbox.GeocodeRequest{AutoComplete: true, ReverseMode: mapbox.ReverseModeDistance},
+			},
+			wantProblems: 2,
+		},
+		4: {
+			rreq: &mapbox.ReverseGeocodeRequest{
+				Query: "-77.0366,38.8977",
+				Request: &mapbox.GeocodeRequest{
+					Limit:     6,
+					Proximity: proximity,
+				},
+			},
+			wantProblems: 2,
+		},
+		5: {
+			rreq: &mapbox.ReverseGeocodeRequest{
+				Query: "-77.0366,38.8977",
+				Request: &mapbox.GeocodeRequest{
+					Limit:        3,
+					AutoComplete: true,
+					Types:        []mapbox.GeocodeType{mapbox.GTypePOI, mapbox.GTypeAddress},
+				},
+			},
+			wantProblems: 2,
+		},
+		6: {
+			rreq: &mapbox.ReverseGeocodeRequest{
+				Query:   "-77.0366,38.8977",
+				Request: &mapbox.GeocodeRequest{Limit: 5, Types: []mapbox.GeocodeType{mapbox.GTypeAddress}},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		requests := 0
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				requests++
+				return respFromFileContents(geocodeResponsePath("LA"))
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var validateErr error
+		if tt.freq != nil {
+			validateErr = tt.freq.Validate()
+			_, err = client.ForwardGeocode(context.Background(), tt.freq)
+		} else {
+			validateErr = tt.rreq.Validate()
+			_, err = client.ReverseGeocoding(context.Background(), tt.rreq)
+		}
+		if tt.wantProblems == 0 {
+			if validateErr != nil || err != nil {
+				t.Errorf("#%d: got errors %v and %v, want none", i, validateErr, err)
+			}
+			continue
+		}
+
+		if requests != 0 {
+			t.Errorf("#%d: made %d requests for an invalid request", i, requests)
+		}
+		if err == nil || validateErr == nil || err.Error() != validateErr.Error() {
+			t.Errorf("#%d: got err %v, want Validate's error %v", i, err, validateErr)
+			continue
+		}
+		var ve *mapbox.ValidationError
+		if !errors.As(err, &ve) {
+			t.Errorf("#%d: got %T want *mapbox.ValidationError", i, err)
+			continue
+		}
+		if g, w := len(ve.Problems), tt.wantProblems; g != w {
+			t.Errorf("#%d: got %d problems want %d: %v", i, g, w, ve)
+		}
+	}
+}
+
+func TestReverseGeocodeLimitTypes(t *testing.T) {
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			return respFromFileContents(geocodeResponsePath("LA"))
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		limit   uint
+		types   []mapbox.GeocodeType
+		wantErr bool
+	}{
+		0: {limit: 1, types: []mapbox.GeocodeType{mapbox.GTypeAddress, mapbox.GTypePOI}},
+		1: {limit: 5, types: []mapbox.GeocodeType{mapbox.GTypePOI}},
+		2: {limit: 5, types: []mapbox.GeocodeType{mapbox.GTypeAddress, mapbox.GTypePOI}, wantErr: true},
+		3: {limit: 5, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		_, err := client.ReverseGeocoding(context.Background(), &mapbox.ReverseGeocodeRequest{
+			Query:   "-118.2439,34.0544",
+			Request: &mapbox.GeocodeRequest{Limit: tt.limit, Types: tt.types},
+		})
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+		}
+	}
+}
+
+func TestGeocodeReverseMode(t *testing.T) {
+	tests := []struct {
+		mode      string
+		reverse   bool
+		wantQuery string
+		wantErr   bool
+	}{
+		0: {mode: mapbox.ReverseModeDistance, reverse: true, wantQuery: "distance"},
+		1: {mode: mapbox.ReverseModeScore, reverse: true, wantQuery: "score"},
+		2: {reverse: true},
+		3: {mode: "nearest", reverse: true, wantErr: true},
+		4: {mode: mapbox.ReverseModeDistance, wantErr: true},
+		5: {mode: mapbox.ReverseModeScore, wantErr: true},
+		6: {},
+	}
+
+	for i, tt := range tests {
+		var gotQuery url.Values
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				gotQuery = req.URL.Query()
+				return respFromFileContents(geocodeResponsePath("LA"))
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		greq := &mapbox.GeocodeRequest{ReverseMode: tt.mode}
+		if tt.reverse {
+			_, err = client.ReverseGeocoding(context.Background(), &mapbox.ReverseGeocodeRequest{
+				Query:   "-118.2439,34.0544",
+				Request: greq,
+			})
+		} else {
+			_, err = client.ForwardGeocode(context.Background(), &mapbox.ForwardGeocodeRequest{
+				Query:   "Los Angeles",
+				Request: greq,
+			})
+		}
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error", i)
+			}
+			if gotQuery != nil {
+				t.Errorf("#%d: an invalid request was sent", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if g, w := gotQuery.Get("reverseMode"), tt.wantQuery; g != w {
+			t.Errorf("#%d: reverseMode got %q want %q", i, g, w)
+		}
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	tests := []struct {
+		opts []mapbox.Option
+		want string
+	}{
+		0: {want: "orijtech-mapbox/v1"},
+		1: {opts: []mapbox.Option{mapbox.WithUserAgent("sre-egress/2.0")}, want: "sre-egress/2.0"},
+	}
+
+	for i, tt := range tests {
+		var gotUAs []string
+		hc := &http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				gotUAs = append(gotUAs, req.Header.Get("User-Agent"))
+				if strings.Contains(req.URL.Path, "/distances/") {
+					return respFromFileContents("./testdata/durations-3x3.json")
+				}
+				return respFromFileContents(geocodeResponsePath("LA"))
+			}),
+		}
+		client, err := mapbox.NewClient(append(tt.opts, mapbox.WithHTTPClient(hc))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := client.LookupPlace(context.Background(), "Los Angeles"); err != nil {
+			t.Fatalf("#%d: LookupPlace: %v", i, err)
+		}
+		dreq := &mapbox.DurationRequest{
+			Coordinates: []*mapbox.LatLonPair{{13.41894, 52.50055}, {14.10293, 52.50055}},
+		}
+		if _, err := client.RequestDuration(context.Background(), dreq); err != nil {
+			t.Fatalf("#%d: RequestDuration: %v", i, err)
+		}
+
+		if len(gotUAs) != 2 {
+			t.Fatalf("#%d: got %d requests want 2", i, len(gotUAs))
+		}
+		for j, ua := range gotUAs {
+			if ua != tt.want {
+				t.Errorf("#%d: request #%d: User-Agent got %q want %q", i, j, ua, tt.want)
+			}
+		}
+	}
+}
+
+func TestHTTPHeaders(t *testing.T) {
+	var gotHeaders []http.Header
+	var gotTokens []string
+	hc := &http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			gotHeaders = append(gotHeaders, req.Header)
+			gotTokens = append(gotTokens, req.URL.Query().Get("access_token"))
+			if strings.Contains(req.URL.Path, "/distances/") {
+				return respFromFileContents("./testdata/durations-3x3.json")
+			}
+			return respFromFileContents(geocodeResponsePath("LA"))
+		}),
+	}
+	client, err := mapbox.NewClient(
+		mapbox.WithHTTPClient(hc),
+		mapbox.WithAPIKey("pk.test"),
+		mapbox.WithUserAgent("sre-egress/2.0"),
+		mapbox.WithHTTPHeaders(http.Header{
+			"X-Gateway-Auth": {"secret"},
+			"X-Request-Id":   {"default"},
+			"User-Agent":     {"gateway"},
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.LookupPlace(context.Background(), "Los Angeles"); err != nil {
+		t.Fatalf("LookupPlace: %v", err)
+	}
+	ctx := mapbox.WithContextHeaders(context.Background(), http.Header{"X-Request-Id": {"req-42"}})
+	dreq := &mapbox.DurationRequest{
+		Coordinates: []*mapbox.LatLonPair{{13.41894, 52.50055}, {14.10293, 52.50055}},
+	}
+	if _, err := client.RequestDuration(ctx, dreq); err != nil {
+		t.Fatalf("RequestDuration: %v", err)
+	}
+
+	if len(gotHeaders) != 2 {
+		t.Fatalf("got %d requests want 2", len(gotHeaders))
+	}
+	wantRequestIDs := []string{"default", "req-42"}
+	for i, hdr := range gotHeaders {
+		if g, w := hdr.Get("X-Gateway-Auth"), "secret"; g != w {
+			t.Errorf("#%d: X-Gateway-Auth got %q want %q", i, g, w)
+		}
+		if g, w := hdr.Get("X-Request-Id"), wantRequestIDs[i]; g != w {
+			t.Errorf("#%d: X-Request-Id got %q want %q", i, g, w)
+		}
+		if g, w := hdr.Get("User-Agent"), "sre-egress/2.0"; g != w {
+			t.Errorf("#%d: User-Agent got %q want %q", i, g, w)
+		}
+		if g, w := gotTokens[i], "pk.test"; g != w {
+			t.Errorf("#%d: access_token got %q want %q", i, g, w)
+		}
+	}
+}
+
+func TestClientClose(t *testing.T) {
+	closed := make(chan bool, 1)
+	cst := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		http.ServeFile(rw, req, geocodeResponsePath("LA"))
+	}))
+	cst.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			closed <- true
+		}
+	}
+	cst.Start()
+	defer cst.Close()
+
+	client, err := mapbox.NewClient(
+		mapbox.WithBaseURL(cst.URL),
+		mapbox.WithHTTPClient(&http.Client{Transport: &http.Transport{}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.LookupPlace(context.Background(), "Los Angeles"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The kept alive connection must be idle but still open.
+	select {
+	case <-closed:
+		t.Fatal("connection closed before Close")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("idle connection was not closed")
+	}
+}
+
+func TestWithTokenInHeader(t *testing.T) {
+	const apiKey = "pk.test-token"
+	geocode := func(client *mapbox.Client) error {
+		_, err := client.LookupPlace(context.Background(), "Los Angeles")
+		return err
+	}
+	matrix := func(client *mapbox.Client) error {
+		_, err := client.Matrix(context.Background(), &mapbox.MatrixRequest{
+			Coordinates: []*mapbox.LatLonPair{{13.41894, 52.50055}, {14.10293, 52.50055}},
+			Sources:     []uint{0},
+		})
+		return err
+	}
+	static := func(client *mapbox.Client) error {
+		img, err := client.StaticImage(context.Background(), &mapbox.StaticImageRequest{
+			Center: &mapbox.LatLonPair{-77.03, 38.89}, Zoom: 10, Width: 300, Height: 200,
+		})
+		if err == nil {
+			img.Close()
+		}
+		return err
+	}
+
+	tests := []struct {
+		inHeader   bool
+		call       func(*mapbox.Client) error
+		wantHeader bool
+		wantQuery  string
+	}{
+		0: {inHeader: true, call: geocode, wantHeader: true},
+		1: {inHeader: true, call: matrix, wantHeader: true, wantQuery: "sources=0"},
+		// Static images don't accept the header.
+		2: {inHeader: true, call: static},
+		3: {call: geocode},
+		4: {call: matrix, wantQuery: "sources=0"},
+	}
+
+	for i, tt := range tests {
+		var gotReq *http.Request
+		opts := []mapbox.Option{
+			mapbox.WithAPIKey(apiKey),
+			mapbox.WithHTTPClient(&http.Client{
+				Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+					gotReq = req
+					if strings.Contains(req.URL.Path, "/distances/") {
+						return respFromFileContents("./testdata/matrix-1x2.json")
+					}
+					return respFromFileContents(geocodeResponsePath("LA"))
+				}),
+			}),
+		}
+		if tt.inHeader {
+			opts = append(opts, mapbox.WithTokenInHeader())
+		}
+		client, err := mapbox.NewClient(opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := tt.call(client); err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		gotAuth := gotReq.Header.Get("Authorization")
+		inURL := strings.Contains(gotReq.URL.RawQuery, "access_token="+apiKey)
+		if tt.wantHeader {
+			if g, w := gotAuth, "Bearer "+apiKey; g != w {
+				t.Errorf("#%d: Authorization got %q want %q", i, g, w)
+			}
+			if strings.Contains(gotReq.URL.String(), apiKey) {
+				t.Errorf("#%d: the token is in the URL %q", i, gotReq.URL)
+			}
+		} else {
+			if gotAuth != "" {
+				t.Errorf("#%d: got Authorization %q want none", i, gotAuth)
+			}
+			if !inURL {
+				t.Errorf("#%d: the token is not in the URL %q", i, gotReq.URL)
+			}
+		}
+		if tt.wantQuery != "" && !strings.Contains(gotReq.URL.RawQuery, tt.wantQuery) {
+			t.Errorf("#%d: query %q does not contain %q", i, gotReq.URL.RawQuery, tt.wantQuery)
+		}
+	}
+}
+
+// trackingBody records whether it was read to
+// the end and closed, like a reusable connection.
+type trackingBody struct {
+	r      io.Reader
+	eof    bool
+	closed bool
+}
+
+func (tb *trackingBody) Read(b []byte) (int, error) {
+	n, err := tb.r.Read(b)
+	if err == io.EOF {
+		tb.eof = true
+	}
+	return n, err
+}
+
+func (tb *trackingBody) Close() error {
+	tb.closed = true
+	return nil
+}
+
+func TestBodyDrained(t *testing.T) {
+	laJSON, err := ioutil.ReadFile(geocodeResponsePath("LA"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		body string
+		call func(*mapbox.Client) error
+	}{
+		0: {
+			body: string(laJSON),
+			call: func(client *mapbox.Client) error {
+				_, err := client.LookupPlace(context.Background(), "Los Angeles")
+				return err
+			},
+		},
+		1: {
+			body: `{"durations": [[0, 2910], [2903, 0]]}`,
+			call: func(client *mapbox.Client) error {
+				_, err := client.RequestDuration(context.Background(), &mapbox.DurationRequest{
+					Coordinates: []*mapbox.LatLonPair{{13.41894, 52.50055}, {14.10293, 52.50055}},
+				})
+				return err
+			},
+		},
+		// The listings, which aren't decoded with doRequest.
+		2: {
+			body: `[{"id": "cjdelivery00001", "name": "Delivery", "owner": "orijtech"}]`,
+			call: func(client *mapbox.Client) error {
+				_, _, err := client.ListStyles(context.Background(), "orijtech", nil)
+				return err
+			},
+		},
+		3: {
+			body: `[{"id": "orijtech.stores", "name": "Stores", "type": "vector"}]`,
+			call: func(client *mapbox.Client) error {
+				_, err := client.ListTilesets(context.Background(), "orijtech", nil)
+				return err
+			},
+		},
+		4: {
+			body: `{"type": "FeatureCollection", "features": []}`,
+			call: func(client *mapbox.Client) error {
+				_, _, err := client.ListFeatures(context.Background(), "orijtech", "stores", nil)
+				return err
+			},
+		},
+		5: {
+			body: `{}`,
+			call: func(client *mapbox.Client) error {
+				ctx := mapbox.WithContextAPIKey(context.Background(), "sk.test")
+				return client.DeleteFeature(ctx, "orijtech", "stores", "store-1")
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		// The JSON decoder stops before the trailing whitespace.
+		body := &trackingBody{r: strings.NewReader(tt.body + "\n\n\n")}
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				return makeResp("200 OK", http.StatusOK, body), nil
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := tt.call(client); err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if !body.eof {
+			t.Errorf("#%d: the body was not read to the end", i)
+		}
+		if !body.closed {
+			t.Errorf("#%d: the body was not closed", i)
+		}
+	}
+}
+
+func TestDefaultHTTPClient(t *testing.T) {
+	custom := &http.Client{Transport: &http.Transport{}}
+	tests := []struct {
+		opts                    []mapbox.Option
+		wantMaxIdleConnsPerHost int
+		wantIdleConnTimeout     time.Duration
+		wantClient              *http.Client
+	}{
+		0: {wantMaxIdleConnsPerHost: 32, wantIdleConnTimeout: 90 * time.Second},
+		1: {
+			opts:                    []mapbox.Option{mapbox.WithConnectionPool(8, time.Minute)},
+			wantMaxIdleConnsPerHost: 8,
+			wantIdleConnTimeout:     time.Minute,
+		},
+		// Non-positive values keep the defaults.
+		2: {
+			opts:                    []mapbox.Option{mapbox.WithConnectionPool(0, -1)},
+			wantMaxIdleConnsPerHost: 32,
+			wantIdleConnTimeout:     90 * time.Second,
+		},
+		3: {
+			opts:       []mapbox.Option{mapbox.WithHTTPClient(custom), mapbox.WithConnectionPool(8, time.Minute)},
+			wantClient: custom,
+		},
+	}
+
+	for i, tt := range tests {
+		client, err := mapbox.NewClient(tt.opts...)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		hc := client.HTTPClient()
+		if hc == nil || hc == http.DefaultClient {
+			t.Errorf("#%d: got %v, want a client other than http.DefaultClient", i, hc)
+			continue
+		}
+		if tt.wantClient != nil {
+			if hc != tt.wantClient {
+				t.Errorf("#%d: got %p want the client set by WithHTTPClient %p", i, hc, tt.wantClient)
+			}
+			continue
+		}
+		oct, ok := hc.Transport.(*ochttp.Transport)
+		if !ok {
+			t.Errorf("#%d: transport got %T want *ochttp.Transport", i, hc.Transport)
+			continue
+		}
+		pool, ok := oct.Base.(*http.Transport)
+		if !ok {
+			t.Errorf("#%d: base transport got %T want *http.Transport", i, oct.Base)
+			continue
+		}
+		if pool == http.DefaultTransport {
+			t.Errorf("#%d: the base transport is http.DefaultTransport", i)
+		}
+		if g, w := pool.MaxIdleConnsPerHost, tt.wantMaxIdleConnsPerHost; g != w {
+			t.Errorf("#%d: MaxIdleConnsPerHost got %d want %d", i, g, w)
+		}
+		if g, w := pool.IdleConnTimeout, tt.wantIdleConnTimeout; g != w {
+			t.Errorf("#%d: IdleConnTimeout got %s want %s", i, g, w)
+		}
+	}
+}
+
+func TestWithTransportWrapper(t *testing.T) {
+	var order []string
+	base := roundTrip(func(req *http.Request) (*http.Response, error) {
+		order = append(order, "base")
+		return respFromFileContents(geocodeResponsePath("LA"))
+	})
+	hc := &http.Client{Transport: base}
+
+	roundTrips := 0
+	client, err := mapbox.NewClient(
+		mapbox.WithHTTPClient(hc),
+		mapbox.WithTransportWrapper(func(rt http.RoundTripper) http.RoundTripper {
+			return roundTrip(func(req *http.Request) (*http.Response, error) {
+				roundTrips++
+				order = append(order, "counter")
+				return rt.RoundTrip(req)
+			})
+		}),
+		mapbox.WithTransportWrapper(func(rt http.RoundTripper) http.RoundTripper {
+			return roundTrip(func(req *http.Request) (*http.Response, error) {
+				order = append(order, "outer")
+				return rt.RoundTrip(req)
+			})
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.LookupPlace(context.Background(), "Los Angeles"); err != nil {
+		t.Fatal(err)
+	}
+	if g, w := roundTrips, 1; g != w {
+		t.Errorf("got %d round trips want %d", g, w)
+	}
+	if g, w := order, []string{"outer", "counter", "base"}; !reflect.DeepEqual(g, w) {
+		t.Errorf("order got %q want %q", g, w)
+	}
+	if _, ok := hc.Transport.(roundTrip); !ok {
+		t.Errorf("the caller's http.Client was modified")
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	const apiKey = "pk.secret-token"
+	tests := []struct {
+		rt        roundTrip
+		wantLines []string
+	}{
+		0: {
+			rt: func(req *http.Request) (*http.Response, error) {
+				return respFromFileContents(geocodeResponsePath("LA"))
+			},
+			wantLines: []string{
+				"mapbox: GET https://api.mapbox.com/geocoding/v5/mapbox.places/Los%20Angeles.json?access_token=REDACTED",
+				"mapbox: GET https://api.mapbox.com/geocoding/v5/mapbox.places/Los%20Angeles.json?access_token=REDACTED 200 in ",
+			},
+		},
+		1: {
+			rt: func(req *http.Request) (*http.Response, error) {
+				return nil, fmt.Errorf("dial failed for %s", req.URL)
+			},
+			wantLines: []string{
+				"mapbox: GET https://api.mapbox.com/geocoding/v5/mapbox.places/Los%20Angeles.json?access_token=REDACTED",
+				"mapbox: GET https://api.mapbox.com/geocoding/v5/mapbox.places/Los%20Angeles.json?access_token=REDACTED failed after ",
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		var lines []string
+		client, err := mapbox.NewClient(
+			mapbox.WithAPIKey(apiKey),
+			mapbox.WithHTTPClient(&http.Client{Transport: tt.rt}),
+			mapbox.WithLogger(func(format string, args ...interface{}) {
+				lines = append(lines, fmt.Sprintf(format, args...))
+			}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, _ = client.LookupPlace(context.Background(), "Los Angeles")
+		if g, w := len(lines), len(tt.wantLines); g != w {
+			t.Errorf("#%d: got %d lines want %d: %q", i, g, w, lines)
+			continue
+		}
+		for j, line := range lines {
+			if !strings.HasPrefix(line, tt.wantLines[j]) {
+				t.Errorf("#%d: line #%d got %q want prefix %q", i, j, line, tt.wantLines[j])
+			}
+			if strings.Contains(line, apiKey) {
+				t.Errorf("#%d: line #%d leaks the access token: %q", i, j, line)
+			}
+		}
+	}
+}
+
+type observation struct {
+	endpoint   string
+	statusCode int
+}
+
+type recordingMetrics struct {
+	observations []observation
+}
+
+func (rm *recordingMetrics) ObserveRequest(endpoint string, statusCode int, d time.Duration) {
+	rm.observations = append(rm.observations, observation{endpoint: endpoint, statusCode: statusCode})
+}
+
+func TestWithMetrics(t *testing.T) {
+	tests := []struct {
+		rt   roundTrip
+		call func(*mapbox.Client) error
+		want []observation
+	}{
+		0: {
+			rt: func(req *http.Request) (*http.Response, error) {
+				return respFromFileContents(geocodeResponsePath("LA"))
+			},
+			call: func(client *mapbox.Client) error {
+				_, err := client.LookupPlace(context.Background(), "Los Angeles")
+				return err
+			},
+			want: []observation{{endpoint: "geocoding", statusCode: http.StatusOK}},
+		},
+		1: {
+			rt: func(req *http.Request) (*http.Response, error) {
+				return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(`{"durations": [[0, 2910], [2903, 0]]}`))), nil
+			},
+			call: func(client *mapbox.Client) error {
+				_, err := client.RequestDuration(context.Background(), &mapbox.DurationRequest{
+					Coordinates: []*mapbox.LatLonPair{{13.41894, 52.50055}, {14.10293, 52.50055}},
+				})
+				return err
+			},
+			want: []observation{{endpoint: "matrix", statusCode: http.StatusOK}},
+		},
+		2: {
+			rt: func(req *http.Request) (*http.Response, error) {
+				return makeResp("401 Unauthorized", http.StatusUnauthorized, ioutil.NopCloser(strings.NewReader(`{"message": "Not Authorized - Invalid Token"}`))), nil
+			},
+			call: func(client *mapbox.Client) error {
+				_, err := client.LookupPlace(context.Background(), "Los Angeles")
+				return err
+			},
+			want: []observation{{endpoint: "geocoding", statusCode: http.StatusUnauthorized}},
+		},
+		// The transport failed so there is no status code.
+		3: {
+			rt: func(req *http.Request) (*http.Response, error) {
+				return nil, errors.New("connection refused")
+			},
+			call: func(client *mapbox.Client) error {
+				_, err := client.Matrix(context.Background(), &mapbox.MatrixRequest{
+					Coordinates: []*mapbox.LatLonPair{{13.41894, 52.50055}, {14.10293, 52.50055}},
+				})
+				return err
+			},
+			want: []observation{{endpoint: "matrix", statusCode: 0}},
+		},
+	}
+
+	for i, tt := range tests {
+		metrics := new(recordingMetrics)
+		client, err := mapbox.NewClient(
+			mapbox.WithHTTPClient(&http.Client{Transport: tt.rt}),
+			mapbox.WithMetrics(metrics),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_ = tt.call(client)
+		if g, w := metrics.observations, tt.want; !reflect.DeepEqual(g, w) {
+			t.Errorf("#%d: observations got %+v want %+v", i, g, w)
+		}
+	}
+}
+
+func TestErrorsRedactAccessToken(t *testing.T) {
+	const apiKey = "sk.secret-token"
+	tests := []struct {
+		opts []mapbox.Option
+	}{
+		// The base URL cannot be parsed.
+		0: {opts: []mapbox.Option{mapbox.WithBaseURL("://api.mapbox.com")}},
+		// The request fails in the transport.
+		1: {opts: []mapbox.Option{mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				return nil, fmt.Errorf("connection refused for %s", req.URL)
+			}),
+		})}},
+	}
+
+	for i, tt := range tests {
+		client, err := mapbox.NewClient(append(tt.opts, mapbox.WithAPIKey(apiKey))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, geocodeErr := client.LookupPlace(context.Background(), "Los Angeles")
+		_, durationErr := client.RequestDuration(context.Background(), &mapbox.DurationRequest{
+			Coordinates: []*mapbox.LatLonPair{{13.41894, 52.50055}, {14.10293, 52.50055}},
+		})
+		for j, err := range []error{geocodeErr, durationErr} {
+			if err == nil {
+				t.Errorf("#%d.%d: want non-nil error", i, j)
+				continue
+			}
+			if msg := err.Error(); strings.Contains(msg, apiKey) {
+				t.Errorf("#%d.%d: error leaks the access token: %q", i, j, msg)
+			} else if !strings.Contains(msg, "access_token=REDACTED") {
+				t.Errorf("#%d.%d: error %q does not show the redacted URL", i, j, msg)
+			}
+		}
+
+		for _, format := range []string{"%v", "%+v", "%s", "%#v"} {
+			if got := fmt.Sprintf(format, client); strings.Contains(got, apiKey) {
+				t.Errorf("#%d: %s of the client leaks the access token: %q", i, format, got)
+			}
+		}
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	// blocking waits for the request's context to be done, recording its deadline.
+	blocking := func(deadlines chan<- time.Time) http.RoundTripper {
+		return roundTrip(func(req *http.Request) (*http.Response, error) {
+			deadline, _ := req.Context().Deadline()
+			deadlines <- deadline
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		})
+	}
+
+	tests := []struct {
+		timeout     time.Duration
+		ctxTimeout  time.Duration
+		wantFromCtx bool
+	}{
+		// No caller deadline, so the client's applies.
+		0: {timeout: 20 * time.Millisecond},
+		// A tighter caller deadline is kept.
+		1: {timeout: time.Hour, ctxTimeout: 20 * time.Millisecond, wantFromCtx: true},
+		// As is a looser one.
+		2: {timeout: 10 * time.Millisecond, ctxTimeout: 40 * time.Millisecond, wantFromCtx: true},
+	}
+
+	for i, tt := range tests {
+		deadlines := make(chan time.Time, 1)
+		client, err := mapbox.NewClient(
+			mapbox.WithHTTPClient(&http.Client{Transport: blocking(deadlines)}),
+			mapbox.WithTimeout(tt.timeout),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx := context.Background()
+		var ctxDeadline time.Time
+		if tt.ctxTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, tt.ctxTimeout)
+			defer cancel()
+			ctxDeadline, _ = ctx.Deadline()
+		}
+
+		start := time.Now()
+		_, err = client.LookupPlace(ctx, "Los Angeles")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("#%d: got %v want %v", i, err, context.DeadlineExceeded)
+		}
+		deadline := <-deadlines
+		switch {
+		case tt.wantFromCtx:
+			if !deadline.Equal(ctxDeadline) {
+				t.Errorf("#%d: deadline got %v want the caller's %v", i, deadline, ctxDeadline)
+			}
+		case deadline.Before(start) || deadline.After(start.Add(tt.timeout+time.Second)):
+			t.Errorf("#%d: deadline %v is not about %v after %v", i, deadline, tt.timeout, start)
+		}
+	}
+}
+
+func TestWithTimeoutStreamedBody(t *testing.T) {
+	client, err := mapbox.NewClient(
+		mapbox.WithTimeout(time.Hour),
+		mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				// The body can only be read while the request's context is live.
+				body := ioutil.NopCloser(readerFunc(func(p []byte) (int, error) {
+					if err := req.Context().Err(); err != nil {
+						return 0, err
+					}
+					return copy(p, "PNG"), io.EOF
+				}))
+				res := makeResp("200 OK", http.StatusOK, body)
+				res.Header.Set("Content-Type", "image/png")
+				return res, nil
+			}),
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := client.StaticImage(context.Background(), &mapbox.StaticImageRequest{
+		Center: &mapbox.LatLonPair{-122.4194, 37.7749},
+		Zoom:   12,
+		Width:  300,
+		Height: 200,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer img.Close()
+
+	slurp, err := ioutil.ReadAll(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, w := string(slurp), "PNG"; g != w {
+		t.Errorf("got %q want %q", g, w)
+	}
+}
+
+type readerFunc func([]byte) (int, error)
+
+func (rf readerFunc) Read(p []byte) (int, error) { return rf(p) }
+
+func TestRequireAPIKey(t *testing.T) {
+	if os.Getenv("MAPBOX_API_KEY") != "" {
+		t.Skip("MAPBOX_API_KEY is set in the environment")
+	}
+
+	if _, err := mapbox.NewClient(mapbox.WithRequireAPIKey()); err == nil {
+		t.Error("no API key: want non-nil error")
+	}
+
+	client, err := mapbox.NewClient(mapbox.WithRequireAPIKey(), mapbox.WithAPIKey("pk.test"))
+	if err != nil {
+		t.Errorf("WithAPIKey: %v", err)
+	} else if g, w := client.APIKey(), "pk.test"; g != w {
+		t.Errorf("APIKey: got %q want %q", g, w)
+	}
+
+	// Keyless clients are allowed unless required.
+	if _, err := mapbox.NewClient(); err != nil {
+		t.Errorf("opt-out: %v", err)
+	}
+}
+
+func TestContextAPIKey(t *testing.T) {
+	var mu sync.Mutex
+	placesByToken := make(map[string]map[string]bool)
+	client, err := mapbox.NewClient(
+		mapbox.WithAPIKey("pk.default"),
+		mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				token := req.URL.Query().Get("access_token")
+				mu.Lock()
+				if placesByToken[token] == nil {
+					placesByToken[token] = make(map[string]bool)
+				}
+				placesByToken[token][req.URL.Path] = true
+				mu.Unlock()
+				return respFromFileContents(geocodeResponsePath("LA"))
+			}),
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keysToPlaces := map[string]string{
+		"pk.alice": "Los Angeles",
+		"pk.bob":   "San Francisco",
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		for key, place := range keysToPlaces {
+			wg.Add(1)
+			go func(key, place string) {
+				defer wg.Done()
+				ctx := mapbox.WithContextAPIKey(context.Background(), key)
+				if _, err := client.LookupPlace(ctx, place); err != nil {
+					t.Errorf("%s: %v", key, err)
+				}
+			}(key, place)
+		}
+	}
+	wg.Wait()
+
+	// Requests without a key in their context use the client's key.
+	if _, err := client.LookupPlace(context.Background(), "Edmonton"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]map[string]bool{
+		"pk.alice":   {"/geocoding/v5/mapbox.places/Los Angeles.json": true},
+		"pk.bob":     {"/geocoding/v5/mapbox.places/San Francisco.json": true},
+		"pk.default": {"/geocoding/v5/mapbox.places/Edmonton.json": true},
+	}
+	if !reflect.DeepEqual(placesByToken, want) {
+		t.Errorf("paths by token:\ngot:  %v\nwant: %v", placesByToken, want)
+	}
+}
+
+func TestGeocodeAll(t *testing.T) {
+	// The backend has 7 matches and returns as many as the limit allows.
+	var pool []*mapbox.GeocodeFeature
+	for i := 0; i < 7; i++ {
+		pool = append(pool, &mapbox.GeocodeFeature{Id: fmt.Sprintf("poi.%d", i), Type: "Feature"})
+	}
+
+	tests := []struct {
+		limit, maxResults uint
+		wantIds           int
+		wantLimits        []string
+	}{
+		0: {limit: 3, wantIds: 7, wantLimits: []string{"3", "6", "9"}},
+		1: {limit: 3, maxResults: 5, wantIds: 5, wantLimits: []string{"3", "6"}},
+		2: {limit: 0, wantIds: 7, wantLimits: []string{"5", "10"}},
+		3: {limit: 7, wantIds: 7, wantLimits: []string{"7", "10"}},
+	}
+
+	for i, tt := range tests {
+		var gotLimits []string
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				limitStr := req.URL.Query().Get("limit")
+				gotLimits = append(gotLimits, limitStr)
+				var limit int
+				fmt.Sscanf(limitStr, "%d", &limit)
+				if limit > len(pool) {
+					limit = len(pool)
+				}
+				blob := jsonMarshal(&mapbox.GeocodeResponse{Type: "FeatureCollection", Features: pool[:limit]})
+				return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(bytes.NewReader(blob))), nil
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		gres, err := client.GeocodeAll(context.Background(), "coffee", &mapbox.GeocodeRequest{
+			Limit:      tt.limit,
+			MaxResults: tt.maxResults,
+		})
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(gotLimits, tt.wantLimits) {
+			t.Errorf("#%d: limits got %v want %v", i, gotLimits, tt.wantLimits)
+		}
+		if g, w := len(gres.Features), tt.wantIds; g != w {
+			t.Errorf("#%d: got %d features want %d", i, g, w)
+		}
+		for j, feat := range gres.Features {
+			if g, w := feat.Id, pool[j].Id; g != w {
+				t.Errorf("#%d: feature #%d Id got %q want %q", i, j, g, w)
+			}
+		}
+	}
+}
+
+func jsonMarshal(v interface{}) []byte {
+	blob, _ := json.Marshal(v)
+	return blob
+}
+
+func makeResp(status string, code int, body io.ReadCloser) *http.Response {
+	return &http.Response{
+		Status:     status,
+		StatusCode: code,
+		Header:     make(http.Header),
+		Body:       body,
+	}
+}
+
+func TestBuildGeocodeURL(t *testing.T) {
+	var gotURL string
+	client, err := mapbox.NewClient(
+		mapbox.WithAPIKey("pk.test"),
+		mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				gotURL = req.URL.String()
+				return respFromFileContents(geocodeResponsePath("LA"))
+			}),
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		req     *mapbox.ForwardGeocodeRequest
+		want    string
+		wantErr bool
+	}{
+		0: {
+			req:  &mapbox.ForwardGeocodeRequest{Query: "Los Angeles"},
+			want: "https://api.mapbox.com/geocoding/v5/mapbox.places/Los%20Angeles.json?access_token=pk.test",
+		},
+		// The ";" batch separator and "?" are escaped in the query.
+		1: {
+			req: &mapbox.ForwardGeocodeRequest{
+				Query: "Café; Bar?",
+				Mode:  mapbox.GeocodePermanentPlaces,
+				Request: &mapbox.GeocodeRequest{
+					Proximity: &mapbox.LatLonPair{-77, 38.8},
+					Limit:     3,
+				},
+			},
+			want: "https://api.mapbox.com/geocoding/v5/mapbox.places-permanent/Caf%C3%A9%3B%20Bar%3F.json?access_token=pk.test&limit=3&proximity=-77%2C38.8",
+		},
+		2: {req: &mapbox.ForwardGeocodeRequest{Query: "LA", Request: &mapbox.GeocodeRequest{Limit: 11}}, wantErr: true},
+		3: {req: nil, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		got, err := client.BuildGeocodeURL(context.Background(), tt.req)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("#%d: URL\ngot:  %s\nwant: %s", i, got, tt.want)
+		}
+
+		// It must be the URL that is actually requested.
+		if _, err := client.ForwardGeocode(context.Background(), tt.req); err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if gotURL != got {
+			t.Errorf("#%d: requested\n%s\nbut built\n%s", i, gotURL, got)
+		}
+	}
+}
+
+func TestBuildURLsAccessToken(t *testing.T) {
+	greq := &mapbox.ForwardGeocodeRequest{Query: "Los Angeles"}
+	mreq := &mapbox.MatrixRequest{Coordinates: []*mapbox.LatLonPair{{13.41894, 52.50055}, {14.10293, 52.50055}}}
+	tests := []struct {
+		opts        []mapbox.Option
+		ctxKey      string
+		wantGeocode string
+		wantMatrix  string
+	}{
+		0: {
+			opts:        []mapbox.Option{mapbox.WithAPIKey("pk.test")},
+			wantGeocode: "https://api.mapbox.com/geocoding/v5/mapbox.places/Los%20Angeles.json?access_token=pk.test",
+			wantMatrix:  "https://api.mapbox.com/distances/v1/mapbox/driving?access_token=pk.test",
+		},
+		// The per-context key is the one used.
+		1: {
+			opts:        []mapbox.Option{mapbox.WithAPIKey("pk.test")},
+			ctxKey:      "pk.user",
+			wantGeocode: "https://api.mapbox.com/geocoding/v5/mapbox.places/Los%20Angeles.json?access_token=pk.user",
+			wantMatrix:  "https://api.mapbox.com/distances/v1/mapbox/driving?access_token=pk.user",
+		},
+		// The token is left out for the Authorization header.
+		2: {
+			opts:        []mapbox.Option{mapbox.WithAPIKey("pk.test"), mapbox.WithTokenInHeader()},
+			wantGeocode: "https://api.mapbox.com/geocoding/v5/mapbox.places/Los%20Angeles.json",
+			wantMatrix:  "https://api.mapbox.com/distances/v1/mapbox/driving",
+		},
+		// The token is escaped.
+		3: {
+			opts:        []mapbox.Option{mapbox.WithAPIKey("pk.a+b&c")},
+			wantGeocode: "https://api.mapbox.com/geocoding/v5/mapbox.places/Los%20Angeles.json?access_token=pk.a%2Bb%26c",
+			wantMatrix:  "https://api.mapbox.com/distances/v1/mapbox/driving?access_token=pk.a%2Bb%26c",
+		},
+	}
+
+	for i, tt := range tests {
+		client, err := mapbox.NewClient(tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.Background()
+		if tt.ctxKey != "" {
+			ctx = mapbox.WithContextAPIKey(ctx, tt.ctxKey)
+		}
+
+		if got, err := client.BuildGeocodeURL(ctx, greq); err != nil {
+			t.Errorf("#%d: geocode: %v", i, err)
+		} else if got != tt.wantGeocode {
+			t.Errorf("#%d: geocode URL\ngot:  %s\nwant: %s", i, got, tt.wantGeocode)
+		}
+		if got, err := client.BuildMatrixURL(ctx, mreq); err != nil {
+			t.Errorf("#%d: matrix: %v", i, err)
+		} else if got != tt.wantMatrix {
+			t.Errorf("#%d: matrix URL\ngot:  %s\nwant: %s", i, got, tt.wantMatrix)
+		}
 	}
 }
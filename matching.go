@@ -0,0 +1,228 @@
+package mapbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go.opencensus.io/trace"
+)
+
+type MapMatchRequest struct {
+	// Profile is one of ProfileDriving, ProfileDrivingTraffic,
+	// ProfileWalking or ProfileCycling. If unset, it
+	// defaults to ProfileDriving.
+	Profile Profile `json:"profile,omitempty"`
+
+	// Coordinates is the trace of 2 to 100 [lon, lat] pairs.
+	Coordinates []*LatLonPair `json:"coordinates"`
+
+	// Radiuses if set, are the distances in meters
+	// that each coordinate can be snapped by.
+	Radiuses []float32 `json:"radiuses,omitempty"`
+
+	// Timestamps if set, are the Unix times
+	// in seconds of each of the coordinates.
+	Timestamps []int64 `json:"timestamps,omitempty"`
+
+	Steps bool `json:"steps,omitempty"`
+
+	// Tidy if set has Mapbox remove clusters
+	// and resample the trace before matching it.
+	Tidy bool `json:"tidy,omitempty"`
+}
+
+// PreTidy returns a copy of mreq without each coordinate that is
+// within minDistance meters of the previously kept one, along with
+// its radius and timestamp, which Tidy would otherwise have to do
+// server side. The first coordinate is always kept.
+func (mreq *MapMatchRequest) PreTidy(minDistance float64) *MapMatchRequest {
+	tidied := *mreq
+	tidied.Coordinates, tidied.Radiuses, tidied.Timestamps = nil, nil, nil
+	var last *LatLonPair
+	for i, coord := range mreq.Coordinates {
+		if last != nil && coord != nil && len(*coord) == 2 && Haversine(last, coord) < minDistance {
+			continue
+		}
+		if coord != nil && len(*coord) == 2 {
+			last = coord
+		}
+		tidied.Coordinates = append(tidied.Coordinates, coord)
+		if i < len(mreq.Radiuses) {
+			tidied.Radiuses = append(tidied.Radiuses, mreq.Radiuses[i])
+		}
+		if i < len(mreq.Timestamps) {
+			tidied.Timestamps = append(tidied.Timestamps, mreq.Timestamps[i])
+		}
+	}
+	return &tidied
+}
+
+type Matching struct {
+	Route
+
+	// Confidence is in the range [0, 1] with
+	// 0 for a very unlikely match.
+	Confidence float32 `json:"confidence"`
+}
+
+// UnmarshalJSON is needed since the embedded
+// Route's would otherwise skip Confidence.
+func (m *Matching) UnmarshalJSON(b []byte) error {
+	if err := m.Route.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	recv := new(struct {
+		Confidence float32 `json:"confidence"`
+	})
+	if err := json.Unmarshal(b, recv); err != nil {
+		return err
+	}
+	m.Confidence = recv.Confidence
+	return nil
+}
+
+type Tracepoint struct {
+	Name              string      `json:"name"`
+	Location          *LatLonPair `json:"location"`
+	MatchingsIndex    int         `json:"matchings_index"`
+	WaypointIndex     int         `json:"waypoint_index"`
+	AlternativesCount int         `json:"alternatives_count"`
+}
+
+type MapMatchResponse struct {
+	Code      string      `json:"code"`
+	Message   string      `json:"message,omitempty"`
+	Matchings []*Matching `json:"matchings,omitempty"`
+
+	// Tracepoints has an entry for each of the input
+	// coordinates, with nil for those that were
+	// considered outliers and were not matched.
+	Tracepoints []*Tracepoint `json:"tracepoints,omitempty"`
+}
+
+const (
+	minMapMatchCoordinates = 2
+	maxMapMatchCoordinates = 100
+)
+
+var (
+	errNilMapMatchRequest  = errors.New("mapbox: expecting a non-nil MapMatchRequest")
+	errMapMatchCoordinates = fmt.Errorf("mapbox: expecting between %d and %d coordinates", minMapMatchCoordinates, maxMapMatchCoordinates)
+	errRadiusesCount       = errors.New("mapbox: expecting exactly one radius per coordinate")
+	errTimestampsCount     = errors.New("mapbox: expecting exactly one timestamp per coordinate")
+)
+
+func (mreq *MapMatchRequest) validate() error {
+	if mreq == nil {
+		return errNilMapMatchRequest
+	}
+	if err := validateProfile(mreq.Profile); err != nil {
+		return err
+	}
+	if n := len(mreq.Coordinates); n < minMapMatchCoordinates || n > maxMapMatchCoordinates {
+		return errMapMatchCoordinates
+	}
+	for i, coord := range mreq.Coordinates {
+		if coord == nil || len(*coord) != 2 {
+			return fmt.Errorf("mapbox: coordinate #%d is not a [lon, lat] pair", i)
+		}
+	}
+	if len(mreq.Radiuses) > 0 && len(mreq.Radiuses) != len(mreq.Coordinates) {
+		return errRadiusesCount
+	}
+	if len(mreq.Timestamps) > 0 && len(mreq.Timestamps) != len(mreq.Coordinates) {
+		return errTimestampsCount
+	}
+	return nil
+}
+
+// MapMatch snaps a noisy GPS trace to the road network.
+// If no match is found, it returns a *CodeError.
+// Request format:
+// GET /matching/v5/mapbox/{profile}/{coordinates}
+func (c *Client) MapMatch(ctx context.Context, mreq *MapMatchRequest) (*MapMatchResponse, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).MapMatch")
+	defer span.End()
+
+	if err := mreq.validate(); err != nil {
+		span.Annotate(nil, "Invalid request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
+		return nil, err
+	}
+
+	profile := mreq.Profile
+	if profile == "" {
+		profile = defaultProfile
+	}
+
+	values := make(url.Values)
+	if len(mreq.Radiuses) > 0 {
+		radiuses := make([]string, len(mreq.Radiuses))
+		for i, radius := range mreq.Radiuses {
+			radiuses[i] = fmt.Sprintf("%g", radius)
+		}
+		values.Add("radiuses", strings.Join(radiuses, ";"))
+	}
+	if len(mreq.Timestamps) > 0 {
+		timestamps := make([]string, len(mreq.Timestamps))
+		for i, ts := range mreq.Timestamps {
+			timestamps[i] = fmt.Sprintf("%d", ts)
+		}
+		values.Add("timestamps", strings.Join(timestamps, ";"))
+	}
+	if mreq.Steps {
+		values.Add("steps", "true")
+	}
+	if mreq.Tidy {
+		values.Add("tidy", "true")
+	}
+	values.Add("access_token", c.apiKeyFor(ctx))
+
+	outURL := fmt.Sprintf("%s/matching/v5/mapbox/%s/%s?%s",
+		c._baseURL(), profile, c.coordinatesPath(mreq.Coordinates), values.Encode())
+
+	mres := new(MapMatchResponse)
+	if err := c.doRequest(ctx, span, "GET", outURL, nil, mres); err != nil {
+		return nil, err
+	}
+	if mres.Code != CodeOk {
+		err := &CodeError{Code: mres.Code, Message: mres.Message}
+		span.SetStatus(trace.Status{Code: trace.StatusCodeNotFound, Message: err.Error()})
+		return nil, err
+	}
+	return mres, nil
+}
+
+// Nearest snaps point, a [lon, lat] pair, to the nearest road
+// that profile can use, returning the road's name, the snapped
+// location and its distance from point. If there is no such road
+// within Mapbox's default radius, it returns a *CodeError with
+// the CodeNoMatch code.
+func (c *Client) Nearest(ctx context.Context, point *LatLonPair, profile Profile) (*Waypoint, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).Nearest")
+	defer span.End()
+
+	// Map matching needs at least 2 coordinates so
+	// match a trace that stays at the point.
+	mres, err := c.MapMatch(ctx, &MapMatchRequest{
+		Profile:     profile,
+		Coordinates: []*LatLonPair{point, point},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(mres.Tracepoints) == 0 || mres.Tracepoints[0] == nil || mres.Tracepoints[0].Location == nil {
+		err := &CodeError{Code: CodeNoMatch, Message: "no road near the point"}
+		span.SetStatus(trace.Status{Code: trace.StatusCodeNotFound, Message: err.Error()})
+		return nil, err
+	}
+	tp := mres.Tracepoints[0]
+	wp := &Waypoint{Name: tp.Name, Location: tp.Location}
+	setDistancesToInput([]*Waypoint{wp}, []*LatLonPair{point}, nil)
+	span.SetStatus(trace.Status{Code: trace.StatusCodeOK})
+	return wp, nil
+}
@@ -0,0 +1,202 @@
+package mapbox_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/orijtech/mapbox"
+)
+
+func TestMapMatch(t *testing.T) {
+	var gotURL string
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			gotURL = req.URL.String()
+			return respFromFileContents("./testdata/matching-SF.json")
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mres, err := client.MapMatch(context.Background(), &mapbox.MapMatchRequest{
+		Coordinates: []*mapbox.LatLonPair{
+			{-122.40252, 37.78881},
+			{-122.40113, 37.78999},
+			{-122.40071, 37.79033},
+		},
+		Radiuses:   []float32{10, 25.5, 10},
+		Timestamps: []int64{1510000000, 1510000010, 1510000020},
+		Steps:      true,
+		Tidy:       true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantSubstrs := []string{
+		"/matching/v5/mapbox/driving/-122.40252,37.78881;-122.40113,37.78999;-122.40071,37.79033?",
+		"radiuses=10%3B25.5%3B10",
+		"timestamps=1510000000%3B1510000010%3B1510000020",
+		"steps=true",
+		"tidy=true",
+	}
+	for _, want := range wantSubstrs {
+		if !strings.Contains(gotURL, want) {
+			t.Errorf("URL %q does not contain %q", gotURL, want)
+		}
+	}
+
+	if g, w := len(mres.Matchings), 1; g != w {
+		t.Fatalf("got %d matchings want %d", g, w)
+	}
+	if g, w := mres.Matchings[0].Confidence, float32(0.8167); g != w {
+		t.Errorf("confidence: got %v want %v", g, w)
+	}
+	if g, w := len(mres.Matchings[0].Legs), 2; g != w {
+		t.Errorf("got %d legs want %d", g, w)
+	}
+	if g, w := len(mres.Tracepoints), 3; g != w {
+		t.Fatalf("got %d tracepoints want %d", g, w)
+	}
+	if mres.Tracepoints[1] != nil {
+		t.Errorf("the outlier tracepoint should be nil, got %#v", mres.Tracepoints[1])
+	}
+	if g, w := mres.Tracepoints[2].Name, "Market Street"; g != w {
+		t.Errorf("tracepoint name: got %q want %q", g, w)
+	}
+}
+
+func TestMapMatchNoMatch(t *testing.T) {
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			body := `{"code":"NoMatch","message":"Could not match the trace."}`
+			return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(body))), nil
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mres, err := client.MapMatch(context.Background(), &mapbox.MapMatchRequest{
+		Coordinates: []*mapbox.LatLonPair{
+			{-122.40252, 37.78881},
+			{-122.40113, 37.78999},
+		},
+	})
+	if mres != nil {
+		t.Errorf("got non-nil response: %#v", mres)
+	}
+	ce := new(mapbox.CodeError)
+	if !errors.As(err, &ce) {
+		t.Fatalf("got err %v (%T); want a *CodeError", err, err)
+	}
+	if g, w := ce.Code, mapbox.CodeNoMatch; g != w {
+		t.Errorf("code: got %q want %q", g, w)
+	}
+}
+
+func TestMapMatchPreTidy(t *testing.T) {
+	mreq := &mapbox.MapMatchRequest{
+		Coordinates: []*mapbox.LatLonPair{
+			{-122.40252, 37.78881},
+			{-122.40252, 37.78881}, // A duplicate.
+			{-122.40250, 37.78883}, // About 3m of jitter.
+			{-122.40113, 37.78999},
+			{-122.40112, 37.78999}, // Under 1m away.
+			{-122.40071, 37.79033},
+		},
+		Radiuses:   []float32{5, 6, 7, 8, 9, 10},
+		Timestamps: []int64{1510000000, 1510000001, 1510000002, 1510000010, 1510000011, 1510000020},
+		Steps:      true,
+	}
+
+	tidied := mreq.PreTidy(5)
+	want := &mapbox.MapMatchRequest{
+		Coordinates: []*mapbox.LatLonPair{
+			{-122.40252, 37.78881},
+			{-122.40113, 37.78999},
+			{-122.40071, 37.79033},
+		},
+		Radiuses:   []float32{5, 8, 10},
+		Timestamps: []int64{1510000000, 1510000010, 1510000020},
+		Steps:      true,
+	}
+	if !reflect.DeepEqual(tidied, want) {
+		t.Errorf("got %+v\nwant %+v", tidied, want)
+	}
+	if g, w := len(mreq.Coordinates), 6; g != w {
+		t.Errorf("the original request was modified, got %d coordinates want %d", g, w)
+	}
+
+	// A threshold of zero keeps every point.
+	if g, w := len(mreq.PreTidy(0).Coordinates), 6; g != w {
+		t.Errorf("zero threshold: got %d coordinates want %d", g, w)
+	}
+}
+
+func TestNearest(t *testing.T) {
+	var gotPath string
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			gotPath = req.URL.Path
+			return respFromFileContents("./testdata/matching-nearest.json")
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pin := &mapbox.LatLonPair{-122.40252, 37.78881}
+	wp, err := client.Nearest(context.Background(), pin, "walking")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, w := gotPath, "/matching/v5/mapbox/walking/-122.40252,37.78881;-122.40252,37.78881"; g != w {
+		t.Errorf("path got %q want %q", g, w)
+	}
+	if g, w := wp.Name, "Market Street"; g != w {
+		t.Errorf("name got %q want %q", g, w)
+	}
+	if g, w := wp.Location, (&mapbox.LatLonPair{-122.402478, 37.788754}); !reflect.DeepEqual(g, w) {
+		t.Errorf("location got %v want %v", g, w)
+	}
+	// The pin was dropped about 7m off the road.
+	if d := wp.DistanceToInput; d < 6 || d > 8 {
+		t.Errorf("distance to input got %.2fm want about 7m", d)
+	}
+}
+
+func TestNearestNoRoad(t *testing.T) {
+	tests := []struct {
+		body string
+	}{
+		0: {body: `{"code":"NoMatch","message":"Could not match the trace."}`},
+		1: {body: `{"code":"Ok","matchings":[],"tracepoints":[null,null]}`},
+	}
+
+	for i, tt := range tests {
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(tt.body))), nil
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wp, err := client.Nearest(context.Background(), &mapbox.LatLonPair{-40.0, 30.0}, "")
+		if wp != nil {
+			t.Errorf("#%d: got non-nil waypoint: %#v", i, wp)
+		}
+		ce := new(mapbox.CodeError)
+		if !errors.As(err, &ce) || ce.Code != mapbox.CodeNoMatch {
+			t.Errorf("#%d: got err %v want a *CodeError with code %q", i, err, mapbox.CodeNoMatch)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package mapbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+// MatrixRequest is the request for the travel times and
+// distances between many coordinates at once.
+type MatrixRequest struct {
+	// Profile is one of ProfileDriving, ProfileDrivingTraffic,
+	// ProfileWalking or ProfileCycling. If unset, it
+	// defaults to ProfileDriving.
+	Profile Profile `json:"-"`
+
+	// Coordinates are the [lon, lat] pairs, of which
+	// there can be at most 25, or 10 for "driving-traffic".
+	Coordinates []*LatLonPair `json:"coordinates"`
+
+	// Annotations if set is the list of matrices to return
+	// e.g. []string{AnnotationDuration, AnnotationDistance}
+	// otherwise only the durations are returned.
+	Annotations []string `json:"annotations,omitempty"`
+
+	// Sources and Destinations if set are the indices of the
+	// Coordinates to route from and to respectively, otherwise
+	// all the coordinates are used. The response's matrices then
+	// have a row per source and a column per destination.
+	Sources      []uint `json:"-"`
+	Destinations []uint `json:"-"`
+
+	// Approaches if set has one of ApproachUnrestricted,
+	// ApproachCurb or "" for the default, per coordinate.
+	Approaches []string `json:"-"`
+
+	// Bearings if set has an {angle, range} per coordinate, in
+	// degrees, for the direction of travel when snapping it. An
+	// empty {0, 0} entry leaves its coordinate unconstrained.
+	Bearings [][2]uint `json:"-"`
+
+	// Radiuses if set has the maximum distance in meters, per
+	// coordinate, that it can be snapped by, with RadiusUnlimited
+	// for no limit and 0 for the default.
+	Radiuses []float32 `json:"-"`
+
+	// DepartAt if set is when to leave, so that the durations
+	// account for the expected traffic. It is only allowed with
+	// the "driving-traffic" profile.
+	DepartAt time.Time `json:"-"`
+}
+
+type MatrixResponse struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	// Durations[i][j] is the travel time in seconds from
+	// Sources[i] to Destinations[j], or NoPathDuration if
+	// there is no route between them.
+	Durations []*LatLonPair `json:"durations,omitempty"`
+
+	// Distances is only populated if AnnotationDistance
+	// was requested, and its values are in meters.
+	Distances []*LatLonPair `json:"distances,omitempty"`
+
+	// Sources and Destinations are where the
+	// respective coordinates snapped to.
+	Sources      []*Waypoint `json:"sources,omitempty"`
+	Destinations []*Waypoint `json:"destinations,omitempty"`
+}
+
+const (
+	minMatrixCoordinates        = 2
+	maxMatrixCoordinates        = 25
+	maxMatrixTrafficCoordinates = 10
+)
+
+var (
+	errNilMatrixRequest = errors.New("mapbox: expecting a non-nil MatrixRequest")
+	errDepartAtProfile  = errors.New(`mapbox: DepartAt is only allowed with the "driving-traffic" profile`)
+	errMatrixTooFew     = fmt.Errorf("mapbox: expecting at least %d coordinates", minMatrixCoordinates)
+)
+
+func (mreq *MatrixRequest) validate() error {
+	if mreq == nil {
+		return errNilMatrixRequest
+	}
+	if err := validateProfile(mreq.Profile); err != nil {
+		return err
+	}
+	profile, limit := mreq.Profile, maxMatrixCoordinates
+	if profile == "" {
+		profile = defaultProfile
+	}
+	if profile == ProfileDrivingTraffic {
+		limit = maxMatrixTrafficCoordinates
+	} else if !mreq.DepartAt.IsZero() {
+		return errDepartAtProfile
+	}
+	n := len(mreq.Coordinates)
+	if n < minMatrixCoordinates {
+		// Mapbox would only reject it with an opaque 422.
+		return errMatrixTooFew
+	}
+	if n > limit {
+		return fmt.Errorf("mapbox: the %q profile allows at most %d coordinates, got %d", profile, limit, n)
+	}
+	for i, coord := range mreq.Coordinates {
+		if coord == nil || len(*coord) != 2 {
+			return fmt.Errorf("mapbox: coordinate #%d is not a [lon, lat] pair", i)
+		}
+	}
+	for _, index := range mreq.Sources {
+		if int(index) >= n {
+			return fmt.Errorf("mapbox: source index %d is out of range of %d coordinates", index, n)
+		}
+	}
+	for _, index := range mreq.Destinations {
+		if int(index) >= n {
+			return fmt.Errorf("mapbox: destination index %d is out of range of %d coordinates", index, n)
+		}
+	}
+	if err := validateApproaches(mreq.Approaches, n); err != nil {
+		return err
+	}
+	if err := validateRadiuses(mreq.Radiuses, n); err != nil {
+		return err
+	}
+	return validateBearings(mreq.Bearings, n)
+}
+
+// departAtLayout is the ISO 8601 format that depart_at expects.
+const departAtLayout = "2006-01-02T15:04:05Z"
+
+// matrixURL returns the URL to POST mreq to, with apiKey as
+// its access_token unless apiKey is empty. The other parameters
+// aren't escaped since Mapbox expects their ";" and "," as is.
+func (c *Client) matrixURL(apiKey string, mreq *MatrixRequest) string {
+	profile := mreq.Profile
+	if profile == "" {
+		profile = defaultProfile
+	}
+	var params []string
+	if apiKey != "" {
+		params = append(params, "access_token="+url.QueryEscape(apiKey))
+	}
+	if len(mreq.Annotations) > 0 {
+		params = append(params, "annotations="+strings.Join(mreq.Annotations, ","))
+	}
+	if len(mreq.Sources) > 0 {
+		params = append(params, "sources="+joinIndices(mreq.Sources))
+	}
+	if len(mreq.Destinations) > 0 {
+		params = append(params, "destinations="+joinIndices(mreq.Destinations))
+	}
+	if len(mreq.Approaches) > 0 {
+		params = append(params, "approaches="+strings.Join(mreq.Approaches, ";"))
+	}
+	if len(mreq.Bearings) > 0 {
+		params = append(params, "bearings="+joinBearings(mreq.Bearings))
+	}
+	if len(mreq.Radiuses) > 0 {
+		params = append(params, "radiuses="+joinRadiuses(mreq.Radiuses))
+	}
+	if !mreq.DepartAt.IsZero() {
+		params = append(params, "depart_at="+mreq.DepartAt.UTC().Format(departAtLayout))
+	}
+	outURL := fmt.Sprintf("%s/distances/%s/mapbox/%s", c._baseURL(), c.APIVersion(), profile)
+	if len(params) > 0 {
+		outURL += "?" + strings.Join(params, "&")
+	}
+	return outURL
+}
+
+// BuildMatrixURL returns the URL that Matrix would POST req to,
+// for callers that make the request themselves, with the access
+// token for ctx unless WithTokenInHeader was set, in which case
+// it must be sent as "Authorization: Bearer <token>" instead.
+// The coordinates aren't part of it, they are sent in the
+// JSON body as {"coordinates": [[lon, lat], ...]}.
+func (c *Client) BuildMatrixURL(ctx context.Context, req *MatrixRequest) (string, error) {
+	if err := req.validate(); err != nil {
+		return "", err
+	}
+	var apiKey string
+	if !c._tokenInHeader() {
+		apiKey = c.apiKeyFor(ctx)
+	}
+	return c.matrixURL(apiKey, req), nil
+}
+
+func joinIndices(indices []uint) string {
+	strs := make([]string, len(indices))
+	for i, index := range indices {
+		strs[i] = strconv.FormatUint(uint64(index), 10)
+	}
+	return strings.Join(strs, ";")
+}
+
+// Matrix returns the travel times, and any other requested
+// annotations, between the sources and destinations, along
+// with where each of those coordinates snapped to.
+// If the matrix couldn't be computed, it returns a *CodeError, or
+// a *NoSegmentError if a coordinate couldn't be snapped.
+func (c *Client) Matrix(ctx context.Context, mreq *MatrixRequest) (*MatrixResponse, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).Matrix")
+	defer span.End()
+
+	if err := mreq.validate(); err != nil {
+		span.Annotate(nil, "Invalid request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
+		return nil, err
+	}
+	mres, err := c.matrix(ctx, span, mreq)
+	if err != nil {
+		return nil, err
+	}
+	span.SetStatus(trace.Status{Code: trace.StatusCodeOK})
+	return mres, nil
+}
+
+// matrix sends the already validated mreq, annotating span.
+func (c *Client) matrix(ctx context.Context, span *trace.Span, mreq *MatrixRequest) (*MatrixResponse, error) {
+	span.Annotate([]trace.Attribute{
+		trace.Int64Attribute("coordinates", int64(len(mreq.Coordinates))),
+	}, "Requesting durations")
+
+	// Only the coordinates and annotations go in the body,
+	// with the coordinates formatted to the client's precision.
+	body := struct {
+		Coordinates [][2]json.Number `json:"coordinates"`
+		Annotations []string         `json:"annotations,omitempty"`
+	}{Annotations: mreq.Annotations}
+	for _, coord := range mreq.Coordinates {
+		body.Coordinates = append(body.Coordinates, [2]json.Number{
+			json.Number(c.formatDegrees(shortest((*coord)[0]))),
+			json.Number(c.formatDegrees(shortest((*coord)[1]))),
+		})
+	}
+	blob, err := json.Marshal(&body)
+	if err != nil {
+		span.Annotate(nil, "Failed to JSON serialize request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return nil, err
+	}
+	// The matrix is only computed and not stored,
+	// so the POST is safe to retry.
+	ctx = withRetryablePOST(ctx)
+	mres := new(MatrixResponse)
+	if err := c.doRequest(ctx, span, "POST", c.matrixURL(c.apiKeyFor(ctx), mreq), bytes.NewReader(blob), mres); err != nil {
+		return nil, err
+	}
+	if mres.Code != "" && mres.Code != CodeOk {
+		err := codeError(mres.Code, mres.Message, mreq.Radiuses)
+		span.SetStatus(trace.Status{Code: trace.StatusCodeNotFound, Message: err.Error()})
+		return nil, err
+	}
+	setDistancesToInput(mres.Sources, mreq.Coordinates, mreq.Sources)
+	setDistancesToInput(mres.Destinations, mreq.Coordinates, mreq.Destinations)
+	return mres, nil
+}
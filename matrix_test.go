@@ -0,0 +1,314 @@
+package mapbox_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/orijtech/mapbox"
+)
+
+var matrixCoordinates = []*mapbox.LatLonPair{
+	{13.41894, 52.50055},
+	{14.10293, 52.50055},
+	{13.50116, 53.10293},
+}
+
+func TestMatrix(t *testing.T) {
+	tests := []struct {
+		req          *mapbox.MatrixRequest
+		fixture      string
+		wantQuery    []string
+		wantDuration []*mapbox.LatLonPair
+		wantDistance []*mapbox.LatLonPair
+		wantSources  []string
+		wantDests    []string
+	}{
+		// Symmetric, from and to every coordinate.
+		0: {
+			req: &mapbox.MatrixRequest{
+				Coordinates: matrixCoordinates,
+				Annotations: []string{mapbox.AnnotationDuration, mapbox.AnnotationDistance},
+			},
+			fixture:   "./testdata/matrix-3x3.json",
+			wantQuery: []string{"annotations=duration,distance"},
+			wantDuration: []*mapbox.LatLonPair{
+				{0, 2910, 4695},
+				{2903, 0, 5839},
+				{4695, 5745, 0},
+			},
+			wantDistance: []*mapbox.LatLonPair{
+				{0, 51234.5, 82101.2},
+				{50998.1, 0, 99211.8},
+				{81972.4, 98804.3, 0},
+			},
+			wantSources: []string{"Mulackstraße", "", "Dorfstraße"},
+			wantDests:   []string{"Mulackstraße", "", "Dorfstraße"},
+		},
+		// Asymmetric, from the first to the others.
+		1: {
+			req: &mapbox.MatrixRequest{
+				Profile:      "walking",
+				Coordinates:  matrixCoordinates,
+				Sources:      []uint{0},
+				Destinations: []uint{1, 2},
+				Approaches:   []string{mapbox.ApproachCurb, "", mapbox.ApproachUnrestricted},
+			},
+			fixture: "./testdata/matrix-1x2.json",
+			wantQuery: []string{
+				"sources=0", "destinations=1;2",
+				"approaches=curb;;unrestricted",
+			},
+			wantDuration: []*mapbox.LatLonPair{{2910, 4695}},
+			wantSources:  []string{"Mulackstraße"},
+			wantDests:    []string{"", "Dorfstraße"},
+		},
+	}
+
+	for i, tt := range tests {
+		var gotQuery string
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				gotQuery = req.URL.RawQuery
+				return respFromFileContents(tt.fixture)
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mres, err := client.Matrix(context.Background(), tt.req)
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		for _, want := range tt.wantQuery {
+			if !strings.Contains(gotQuery, want) {
+				t.Errorf("#%d: query %q does not contain %q", i, gotQuery, want)
+			}
+		}
+		if !reflect.DeepEqual(mres.Durations, tt.wantDuration) {
+			t.Errorf("#%d: durations\ngot:  %v\nwant: %v", i, mres.Durations, tt.wantDuration)
+		}
+		if !reflect.DeepEqual(mres.Distances, tt.wantDistance) {
+			t.Errorf("#%d: distances\ngot:  %v\nwant: %v", i, mres.Distances, tt.wantDistance)
+		}
+		if g, w := waypointNames(mres.Sources), tt.wantSources; !reflect.DeepEqual(g, w) {
+			t.Errorf("#%d: sources got %q want %q", i, g, w)
+		}
+		if g, w := waypointNames(mres.Destinations), tt.wantDests; !reflect.DeepEqual(g, w) {
+			t.Errorf("#%d: destinations got %q want %q", i, g, w)
+		}
+		for j, wp := range mres.Sources {
+			if wp.Location == nil || len(*wp.Location) != 2 {
+				t.Errorf("#%d: source #%d has no snapped location", i, j)
+			}
+		}
+	}
+}
+
+func waypointNames(waypoints []*mapbox.Waypoint) []string {
+	names := make([]string, len(waypoints))
+	for i, wp := range waypoints {
+		names[i] = wp.Name
+	}
+	return names
+}
+
+func TestMatrixErrors(t *testing.T) {
+	tests := []struct {
+		req     *mapbox.MatrixRequest
+		body    string
+		wantErr string
+	}{
+		0: {req: nil},
+		1: {req: &mapbox.MatrixRequest{Coordinates: matrixCoordinates, Approaches: []string{mapbox.ApproachCurb}}},
+		2: {req: &mapbox.MatrixRequest{Coordinates: matrixCoordinates, Approaches: []string{"curb", "curb", "kerb"}}},
+		3: {
+			req:  &mapbox.MatrixRequest{Coordinates: matrixCoordinates},
+			body: `{"code": "NoRoute", "message": "Impossible route between points"}`,
+		},
+		4: {req: &mapbox.MatrixRequest{}, wantErr: "at least 2 coordinates"},
+		5: {req: &mapbox.MatrixRequest{Coordinates: matrixCoordinates[:1]}, wantErr: "at least 2 coordinates"},
+	}
+
+	for i, tt := range tests {
+		requests := 0
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				requests++
+				return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(tt.body))), nil
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = client.Matrix(context.Background(), tt.req)
+		if err == nil {
+			t.Errorf("#%d: want non-nil error", i)
+			continue
+		}
+		if tt.body == "" {
+			if requests != 0 {
+				t.Errorf("#%d: made %d requests, want none", i, requests)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("#%d: got %q want it to contain %q", i, err, tt.wantErr)
+			}
+			continue
+		}
+		if ce, ok := err.(*mapbox.CodeError); !ok || ce.Code != "NoRoute" {
+			t.Errorf("#%d: got %v want a NoRoute *CodeError", i, err)
+		}
+	}
+}
+
+func TestMatrixWaypoints(t *testing.T) {
+	// The last destination snapped about 1.1km north of its input.
+	body := `{
+		"code": "Ok",
+		"durations": [[2910, 4695]],
+		"sources": [{"name": "Mulackstraße", "location": [13.418946, 52.500538]}],
+		"destinations": [
+			{"name": "", "location": [14.102928, 52.500545]},
+			{"name": "Dorfstraße", "location": [13.50116, 53.11293]}
+		]
+	}`
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(body))), nil
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mres, err := client.Matrix(context.Background(), &mapbox.MatrixRequest{
+		Coordinates:  matrixCoordinates,
+		Sources:      []uint{0},
+		Destinations: []uint{1, 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		wp           *mapbox.Waypoint
+		wantName     string
+		wantLocation *mapbox.LatLonPair
+		minDistance  float32
+		maxDistance  float32
+	}{
+		0: {mres.Sources[0], "Mulackstraße", &mapbox.LatLonPair{13.418946, 52.500538}, 1, 2},
+		1: {mres.Destinations[0], "", &mapbox.LatLonPair{14.102928, 52.500545}, 0, 1},
+		2: {mres.Destinations[1], "Dorfstraße", &mapbox.LatLonPair{13.50116, 53.11293}, 1100, 1125},
+	}
+
+	for i, tt := range tests {
+		if tt.wp.Name != tt.wantName {
+			t.Errorf("#%d: name got %q want %q", i, tt.wp.Name, tt.wantName)
+		}
+		if !reflect.DeepEqual(tt.wp.Location, tt.wantLocation) {
+			t.Errorf("#%d: location got %v want %v", i, tt.wp.Location, tt.wantLocation)
+		}
+		if d := tt.wp.DistanceToInput; d < tt.minDistance || d > tt.maxDistance {
+			t.Errorf("#%d: distance to input %.2fm not in [%g, %g]", i, d, tt.minDistance, tt.maxDistance)
+		}
+	}
+}
+
+func TestBuildMatrixURL(t *testing.T) {
+	var gotURL string
+	client, err := mapbox.NewClient(
+		mapbox.WithAPIKey("pk.test"),
+		mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				gotURL = req.URL.String()
+				return respFromFileContents("./testdata/matrix-1x2.json")
+			}),
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		req     *mapbox.MatrixRequest
+		want    string
+		wantErr bool
+	}{
+		0: {
+			req:  &mapbox.MatrixRequest{Coordinates: matrixCoordinates},
+			want: "https://api.mapbox.com/distances/v1/mapbox/driving?access_token=pk.test",
+		},
+		1: {
+			req: &mapbox.MatrixRequest{
+				Profile:      mapbox.ProfileWalking,
+				Coordinates:  matrixCoordinates,
+				Annotations:  []string{mapbox.AnnotationDuration, mapbox.AnnotationDistance},
+				Sources:      []uint{0},
+				Destinations: []uint{1, 2},
+				Approaches:   []string{mapbox.ApproachCurb, "", mapbox.ApproachUnrestricted},
+			},
+			want: "https://api.mapbox.com/distances/v1/mapbox/walking?access_token=pk.test" +
+				"&annotations=duration,distance&sources=0&destinations=1;2&approaches=curb;;unrestricted",
+		},
+		2: {req: &mapbox.MatrixRequest{Coordinates: matrixCoordinates, Approaches: []string{mapbox.ApproachCurb}}, wantErr: true},
+		3: {req: nil, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		got, err := client.BuildMatrixURL(context.Background(), tt.req)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("#%d: URL\ngot:  %s\nwant: %s", i, got, tt.want)
+		}
+
+		// It must be the URL that is actually requested.
+		if _, err := client.Matrix(context.Background(), tt.req); err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if gotURL != got {
+			t.Errorf("#%d: requested\n%s\nbut built\n%s", i, gotURL, got)
+		}
+	}
+}
+
+func TestRequestDurationRadiuses(t *testing.T) {
+	var gotQuery string
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			gotQuery = req.URL.RawQuery
+			body := `{"code": "NoSegment", "message": "Could not find a matching segment for input coordinates"}`
+			return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(body))), nil
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.RequestDuration(context.Background(), &mapbox.DurationRequest{
+		Coordinates: matrixCoordinates,
+		Radiuses:    []float32{25, mapbox.RadiusUnlimited, 0.5},
+	})
+	if want := "radiuses=25;unlimited;0.5"; !strings.Contains(gotQuery, want) {
+		t.Errorf("query %q does not contain %q", gotQuery, want)
+	}
+	if _, ok := err.(*mapbox.NoSegmentError); !ok {
+		t.Errorf("got %v want a *NoSegmentError", err)
+	}
+}
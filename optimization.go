@@ -0,0 +1,181 @@
+package mapbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	SourceAny   = "any"
+	SourceFirst = "first"
+
+	DestinationAny  = "any"
+	DestinationLast = "last"
+)
+
+// Distribution is a pair of coordinate indices
+// where Pickup must be visited before Dropoff.
+type Distribution struct {
+	Pickup  uint `json:"pickup"`
+	Dropoff uint `json:"dropoff"`
+}
+
+type OptimizationRequest struct {
+	// Profile is one of ProfileDriving, ProfileDrivingTraffic,
+	// ProfileWalking or ProfileCycling. If unset, it
+	// defaults to ProfileDriving.
+	Profile Profile `json:"profile,omitempty"`
+
+	// Coordinates are the 2 to 12 [lon, lat] pairs to visit.
+	Coordinates []*LatLonPair `json:"coordinates"`
+
+	// Source is either SourceFirst or SourceAny.
+	// If unset, it defaults to SourceAny for a
+	// round trip and to SourceFirst otherwise.
+	Source string `json:"source,omitempty"`
+
+	// Destination is either DestinationLast or DestinationAny.
+	// If unset, it defaults to DestinationAny for a
+	// round trip and to DestinationLast otherwise.
+	Destination string `json:"destination,omitempty"`
+
+	// Roundtrip if set returns to the first location.
+	Roundtrip bool `json:"roundtrip,omitempty"`
+
+	Distributions []*Distribution `json:"distributions,omitempty"`
+}
+
+type OptimizationWaypoint struct {
+	Name     string      `json:"name"`
+	Location *LatLonPair `json:"location"`
+
+	// WaypointIndex is the position of
+	// this waypoint in its trip.
+	WaypointIndex int `json:"waypoint_index"`
+	TripsIndex    int `json:"trips_index"`
+}
+
+type OptimizationResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+
+	// Waypoints are in the order of the input coordinates,
+	// so sorting them by WaypointIndex yields the visiting order.
+	Waypoints []*OptimizationWaypoint `json:"waypoints,omitempty"`
+	Trips     []*Route                `json:"trips,omitempty"`
+}
+
+const (
+	minOptimizationCoordinates = 2
+	maxOptimizationCoordinates = 12
+)
+
+var (
+	errNilOptimizationRequest  = errors.New("mapbox: expecting a non-nil OptimizationRequest")
+	errOptimizationCoordinates = fmt.Errorf("mapbox: expecting between %d and %d coordinates", minOptimizationCoordinates, maxOptimizationCoordinates)
+	errInvalidSource           = errors.New(`mapbox: Source must be "first" or "any"`)
+	errInvalidDestination      = errors.New(`mapbox: Destination must be "last" or "any"`)
+	errOneWayTripEndpoints     = errors.New(`mapbox: a trip that is not a round trip must have Source "first" and Destination "last"`)
+)
+
+func (oreq *OptimizationRequest) validate() error {
+	if oreq == nil {
+		return errNilOptimizationRequest
+	}
+	if err := validateProfile(oreq.Profile); err != nil {
+		return err
+	}
+	if n := len(oreq.Coordinates); n < minOptimizationCoordinates || n > maxOptimizationCoordinates {
+		return errOptimizationCoordinates
+	}
+	for i, coord := range oreq.Coordinates {
+		if coord == nil || len(*coord) != 2 {
+			return fmt.Errorf("mapbox: coordinate #%d is not a [lon, lat] pair", i)
+		}
+	}
+	switch oreq.Source {
+	case "", SourceAny, SourceFirst:
+	default:
+		return errInvalidSource
+	}
+	switch oreq.Destination {
+	case "", DestinationAny, DestinationLast:
+	default:
+		return errInvalidDestination
+	}
+	if !oreq.Roundtrip && (oreq.Source == SourceAny || oreq.Destination == DestinationAny) {
+		return errOneWayTripEndpoints
+	}
+	n := uint(len(oreq.Coordinates))
+	for i, dist := range oreq.Distributions {
+		if dist == nil || dist.Pickup >= n || dist.Dropoff >= n {
+			return fmt.Errorf("mapbox: distribution #%d is out of the coordinates' range", i)
+		}
+	}
+	return nil
+}
+
+// Optimize returns the trips that visit all the
+// coordinates in the fastest order.
+// Request format:
+// GET /optimized-trips/v1/mapbox/{profile}/{coordinates}
+func (c *Client) Optimize(ctx context.Context, oreq *OptimizationRequest) (*OptimizationResponse, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).Optimize")
+	defer span.End()
+
+	if err := oreq.validate(); err != nil {
+		span.Annotate(nil, "Invalid request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
+		return nil, err
+	}
+
+	profile := oreq.Profile
+	if profile == "" {
+		profile = defaultProfile
+	}
+	source, destination := oreq.Source, oreq.Destination
+	if source == "" {
+		source = SourceFirst
+		if oreq.Roundtrip {
+			source = SourceAny
+		}
+	}
+	if destination == "" {
+		destination = DestinationLast
+		if oreq.Roundtrip {
+			destination = DestinationAny
+		}
+	}
+
+	values := make(url.Values)
+	values.Add("source", source)
+	values.Add("destination", destination)
+	values.Add("roundtrip", fmt.Sprintf("%v", oreq.Roundtrip))
+	if len(oreq.Distributions) > 0 {
+		dists := make([]string, len(oreq.Distributions))
+		for i, dist := range oreq.Distributions {
+			dists[i] = fmt.Sprintf("%d,%d", dist.Pickup, dist.Dropoff)
+		}
+		values.Add("distributions", strings.Join(dists, ";"))
+	}
+	values.Add("access_token", c.apiKeyFor(ctx))
+
+	outURL := fmt.Sprintf("%s/optimized-trips/v1/mapbox/%s/%s?%s",
+		c._baseURL(), profile, c.coordinatesPath(oreq.Coordinates), values.Encode())
+
+	ores := new(OptimizationResponse)
+	if err := c.doRequest(ctx, span, "GET", outURL, nil, ores); err != nil {
+		return nil, err
+	}
+	if ores.Code != CodeOk {
+		err := &CodeError{Code: ores.Code, Message: ores.Message}
+		span.SetStatus(trace.Status{Code: trace.StatusCodeNotFound, Message: err.Error()})
+		return nil, err
+	}
+	return ores, nil
+}
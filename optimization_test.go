@@ -0,0 +1,98 @@
+package mapbox_test
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/orijtech/mapbox"
+)
+
+func TestOptimize(t *testing.T) {
+	var gotURL string
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			gotURL = req.URL.String()
+			return respFromFileContents("./testdata/optimized-trips-SF.json")
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ores, err := client.Optimize(context.Background(), &mapbox.OptimizationRequest{
+		Coordinates: []*mapbox.LatLonPair{
+			{-122.42, 37.78},
+			{-122.42, 37.76},
+			{-122.45, 37.77},
+		},
+		Roundtrip:     true,
+		Distributions: []*mapbox.Distribution{{Pickup: 2, Dropoff: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantSubstrs := []string{
+		"/optimized-trips/v1/mapbox/driving/-122.42,37.78;-122.42,37.76;-122.45,37.77?",
+		"source=any",
+		"destination=any",
+		"roundtrip=true",
+		"distributions=2%2C1",
+	}
+	for _, want := range wantSubstrs {
+		if !strings.Contains(gotURL, want) {
+			t.Errorf("URL %q does not contain %q", gotURL, want)
+		}
+	}
+
+	if g, w := len(ores.Trips), 1; g != w {
+		t.Fatalf("got %d trips want %d", g, w)
+	}
+	if g, w := len(ores.Trips[0].Legs), 3; g != w {
+		t.Errorf("got %d legs want %d", g, w)
+	}
+
+	// Reconstruct the visiting order.
+	visits := append([]*mapbox.OptimizationWaypoint(nil), ores.Waypoints...)
+	sort.Slice(visits, func(i, j int) bool {
+		return visits[i].WaypointIndex < visits[j].WaypointIndex
+	})
+	var gotOrder []string
+	for _, wp := range visits {
+		gotOrder = append(gotOrder, wp.Name)
+	}
+	if g, w := strings.Join(gotOrder, ","), "Market Street,Fell Street,Valencia Street"; g != w {
+		t.Errorf("visiting order:\ngot:  %q\nwant: %q", g, w)
+	}
+}
+
+func TestOptimizeValidation(t *testing.T) {
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			t.Errorf("unexpected request to %q", req.URL)
+			return makeResp("Bad Request", http.StatusBadRequest, http.NoBody), nil
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	coords := []*mapbox.LatLonPair{{-122.42, 37.78}, {-122.42, 37.76}}
+	tests := []*mapbox.OptimizationRequest{
+		0: nil,
+		1: {Coordinates: coords[:1]},
+		2: {Coordinates: coords, Roundtrip: true, Source: "last"},
+		3: {Coordinates: coords, Roundtrip: true, Destination: "first"},
+		4: {Coordinates: coords, Source: mapbox.SourceAny},
+		5: {Coordinates: coords, Roundtrip: true, Distributions: []*mapbox.Distribution{{Pickup: 0, Dropoff: 2}}},
+	}
+
+	for i, oreq := range tests {
+		if _, err := client.Optimize(context.Background(), oreq); err == nil {
+			t.Errorf("#%d: want non-nil error", i)
+		}
+	}
+}
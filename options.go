@@ -2,6 +2,10 @@ package mapbox
 
 import (
 	"net/http"
+	"strings"
+	"time"
+
+	"go.opencensus.io/trace"
 )
 
 type Option interface {
@@ -19,3 +23,253 @@ func (whc *withHTTPClient) apply(c *Client) {
 func WithHTTPClient(c *http.Client) Option {
 	return &withHTTPClient{c}
 }
+
+type withAPIKey string
+
+func (wak withAPIKey) apply(c *Client) {
+	c.apiKey = string(wak)
+}
+
+// WithAPIKey sets the API key used by the client,
+// instead of the MAPBOX_API_KEY environment variable.
+func WithAPIKey(key string) Option {
+	return withAPIKey(key)
+}
+
+type withBaseURL string
+
+func (wbu withBaseURL) apply(c *Client) {
+	c.baseURL = strings.TrimSuffix(string(wbu), "/")
+}
+
+// WithBaseURL sets the scheme and host that the client
+// sends its requests to e.g. "https://api.mapbox.com"
+// which is useful for proxies and tests.
+func WithBaseURL(u string) Option {
+	return withBaseURL(u)
+}
+
+type withRetry int
+
+func (wr withRetry) apply(c *Client) {
+	c.maxRetries = int(wr)
+}
+
+// WithRetry retries requests that failed with a 429
+// or a 5XX status up to maxRetries times, waiting
+// between attempts as advised by the Retry-After
+// header or otherwise with a jittered exponential backoff.
+func WithRetry(maxRetries int) Option {
+	return withRetry(maxRetries)
+}
+
+type withErrorOnEmpty bool
+
+func (woe withErrorOnEmpty) apply(c *Client) {
+	c.errorOnEmpty = bool(woe)
+}
+
+// WithErrorOnEmpty makes LookupPlace, ForwardGeocode and
+// ReverseGeocoding return ErrNoResults instead of a response
+// without any features, when nothing matched the query.
+func WithErrorOnEmpty() Option {
+	return withErrorOnEmpty(true)
+}
+
+type withUserAgent string
+
+func (wua withUserAgent) apply(c *Client) {
+	c.userAgent = string(wua)
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(ua string) Option {
+	return withUserAgent(ua)
+}
+
+type withRequireAPIKey bool
+
+func (wrk withRequireAPIKey) apply(c *Client) {
+	c.requireAPIKey = bool(wrk)
+}
+
+// WithRequireAPIKey makes NewClient fail if neither WithAPIKey
+// nor the MAPBOX_API_KEY environment variable provide a key.
+// Without it, keyless clients can still be created e.g. for tests.
+func WithRequireAPIKey() Option {
+	return withRequireAPIKey(true)
+}
+
+type withTraceSampler struct {
+	sampler trace.Sampler
+}
+
+func (wts withTraceSampler) apply(c *Client) {
+	c.sampler = wts.sampler
+}
+
+// WithTraceSampler sets the sampler for the spans that the
+// client creates e.g. trace.NeverSample() for high-QPS services.
+// Without it, the process' default sampler is used.
+func WithTraceSampler(sampler trace.Sampler) Option {
+	return withTraceSampler{sampler: sampler}
+}
+
+type withBatchContinueOnError bool
+
+func (wbc withBatchContinueOnError) apply(c *Client) {
+	c.continueOnError = bool(wbc)
+}
+
+// WithBatchContinueOnError makes BatchReverse carry on with the
+// other lookups after any error, instead of cancelling them on
+// the first error that retrying wouldn't fix.
+func WithBatchContinueOnError() Option {
+	return withBatchContinueOnError(true)
+}
+
+type withHTTPHeaders http.Header
+
+func (whh withHTTPHeaders) apply(c *Client) {
+	c.headers = http.Header(whh).Clone()
+}
+
+// WithHTTPHeaders sets headers to send with every request
+// e.g. for an authenticating gateway. Headers set on a request's
+// context by WithContextHeaders take precedence over them, and
+// the User-Agent can only be changed by WithUserAgent.
+func WithHTTPHeaders(headers http.Header) Option {
+	return withHTTPHeaders(headers)
+}
+
+type withCache struct {
+	cache Cache
+}
+
+func (wc withCache) apply(c *Client) {
+	c.cache = wc.cache
+}
+
+// WithCache caches successful geocoding responses in the
+// GeocodePermanentPlaces mode in cache for a day, so that identical
+// requests made with the same access token don't hit the network.
+// The temporary mode's results may not be stored so aren't cached.
+func WithCache(cache Cache) Option {
+	return withCache{cache: cache}
+}
+
+type withRateLimit int
+
+func (wrl withRateLimit) apply(c *Client) {
+	if c.limiter == nil {
+		c.limiter = newRateLimiter(systemClock{})
+	}
+	c.limiter.setRate(int(wrl))
+}
+
+// WithRateLimit paces requests, including retries, to at most rps
+// per second. Once Mapbox reports that no requests remain, requests
+// are also held back until its reset, or until their context is done.
+func WithRateLimit(rps int) Option {
+	return withRateLimit(rps)
+}
+
+type withTimeout time.Duration
+
+func (wt withTimeout) apply(c *Client) {
+	c.timeout = time.Duration(wt)
+}
+
+// WithTimeout bounds each request, including its retries and
+// the reading of its response, to d if its context doesn't
+// already have a deadline. A caller's deadline always wins.
+func WithTimeout(d time.Duration) Option {
+	return withTimeout(d)
+}
+
+type withLogger func(format string, args ...interface{})
+
+func (wl withLogger) apply(c *Client) {
+	c.logf = wl
+}
+
+// WithLogger logs the method and URL of every request, with the
+// access token redacted, followed by its status code and latency,
+// e.g. WithLogger(log.Printf) while debugging.
+func WithLogger(logf func(format string, args ...interface{})) Option {
+	return withLogger(logf)
+}
+
+type withTransportWrapper func(http.RoundTripper) http.RoundTripper
+
+func (wtw withTransportWrapper) apply(c *Client) {
+	c.wrappers = append(c.wrappers, wtw)
+}
+
+// WithTransportWrapper layers middleware around the transport of
+// the client's http.Client, keeping its proxy and TLS settings.
+// The wrapped transport sees every attempt of retried requests,
+// and wrappers given in several options are applied in order.
+func WithTransportWrapper(wrap func(http.RoundTripper) http.RoundTripper) Option {
+	return withTransportWrapper(wrap)
+}
+
+type withMetrics struct {
+	metrics Metrics
+}
+
+func (wm withMetrics) apply(c *Client) {
+	c.metrics = wm.metrics
+}
+
+// WithMetrics reports every request to metrics, with its endpoint,
+// status code and latency. Without it, nothing is recorded.
+func WithMetrics(metrics Metrics) Option {
+	return withMetrics{metrics: metrics}
+}
+
+type withConnectionPool struct {
+	maxIdleConnsPerHost int
+	idleConnTimeout     time.Duration
+}
+
+func (wcp withConnectionPool) apply(c *Client) {
+	c.maxIdleConnsPerHost = wcp.maxIdleConnsPerHost
+	c.idleConnTimeout = wcp.idleConnTimeout
+}
+
+// WithConnectionPool tunes the default transport to keep up to
+// maxIdleConnsPerHost idle connections to Mapbox, each for up to
+// idleConnTimeout, instead of 32 for 90 seconds. A non-positive
+// value keeps its default. It has no effect with WithHTTPClient.
+func WithConnectionPool(maxIdleConnsPerHost int, idleConnTimeout time.Duration) Option {
+	return withConnectionPool{maxIdleConnsPerHost: maxIdleConnsPerHost, idleConnTimeout: idleConnTimeout}
+}
+
+type withTokenInHeader bool
+
+func (wth withTokenInHeader) apply(c *Client) {
+	c.tokenInHeader = bool(wth)
+}
+
+// WithTokenInHeader sends the access token in the Authorization
+// header instead of the URL, keeping it out of server and proxy
+// logs, for the geocoding, directions, matrix, map matching,
+// isochrone and optimization APIs. The other APIs don't accept
+// it there so their requests keep it in the URL.
+func WithTokenInHeader() Option {
+	return withTokenInHeader(true)
+}
+
+type withCoordinatePrecision int
+
+func (wcp withCoordinatePrecision) apply(c *Client) {
+	c.precision = int(wcp)
+}
+
+// WithCoordinatePrecision formats the coordinates sent to Mapbox,
+// in URLs and queries, with at most n decimals instead of 6, and
+// without trailing zeros. A non-positive n keeps the default.
+func WithCoordinatePrecision(n int) Option {
+	return withCoordinatePrecision(n)
+}
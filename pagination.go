@@ -0,0 +1,90 @@
+package mapbox
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// pageParams are the query parameters that
+// identify a page, in order of precedence.
+var pageParams = []string{"start", "page"}
+
+// parseNextLink returns the page token of the rel="next" URL in
+// the Link header, which is its start query parameter or else its
+// page one, e.g. "abc" for
+// `<https://api.mapbox.com/styles/v1/user?start=abc>; rel="next"`.
+// hasMore is false when there is no next link, on the last page.
+func parseNextLink(h http.Header) (nextStart string, hasMore bool) {
+	params, hasMore := nextPage(h)
+	for _, key := range pageParams {
+		if token := params.Get(key); token != "" {
+			return token, true
+		}
+	}
+	return "", hasMore
+}
+
+// nextPage returns the page parameters, of pageParams, of the
+// rel="next" URL in the Link header, and false if there is none.
+func nextPage(h http.Header) (url.Values, bool) {
+	u, ok := nextLinkURL(h)
+	if !ok {
+		return nil, false
+	}
+	query := u.Query()
+	params := make(url.Values)
+	for _, key := range pageParams {
+		if token := query.Get(key); token != "" {
+			params.Set(key, token)
+		}
+	}
+	return params, true
+}
+
+// nextLinkURL returns the rel="next" URL in the Link header. Each
+// link's target is read up to its closing ">" rather than splitting
+// the header on commas, which the URLs' query strings can contain.
+func nextLinkURL(h http.Header) (*url.URL, bool) {
+	for _, link := range h.Values("Link") {
+		for {
+			start := strings.Index(link, "<")
+			if start < 0 {
+				break
+			}
+			end := strings.Index(link[start:], ">")
+			if end < 0 {
+				break
+			}
+			target := link[start+1 : start+end]
+			link = link[start+end+1:]
+
+			// The link's parameters run up to the next link's target.
+			params := link
+			if next := strings.Index(link, "<"); next >= 0 {
+				params, link = link[:next], link[next:]
+			} else {
+				link = ""
+			}
+			if !isNextRel(params) {
+				continue
+			}
+			if u, err := url.Parse(target); err == nil {
+				return u, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// isNextRel reports whether params, e.g. `; rel="next",`,
+// are those of a rel="next" link.
+func isNextRel(params string) bool {
+	for _, param := range strings.Split(params, ";") {
+		param = strings.Replace(strings.Trim(param, " \t,"), " ", "", -1)
+		if param == `rel="next"` || param == "rel=next" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package mapbox
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParseNextLink(t *testing.T) {
+	tests := []struct {
+		links       []string
+		wantStart   string
+		wantHasMore bool
+	}{
+		// The last page has no Link header.
+		0: {},
+		1: {
+			links:       []string{`<https://api.mapbox.com/styles/v1/orijtech?limit=2&start=cjdelivery00002>; rel="next"`},
+			wantStart:   "cjdelivery00002",
+			wantHasMore: true,
+		},
+		2: {
+			links:       []string{`<https://api.mapbox.com/tilesets/v1/orijtech?page=3>; rel=next`},
+			wantStart:   "3",
+			wantHasMore: true,
+		},
+		// start takes precedence over page.
+		3: {
+			links:       []string{`<https://api.mapbox.com/datasets/v1/orijtech?page=3&start=store-2>; rel="next"`},
+			wantStart:   "store-2",
+			wantHasMore: true,
+		},
+		// Only the next link counts, whichever order the links are in.
+		4: {
+			links: []string{
+				`<https://api.mapbox.com/styles/v1/orijtech?start=a>; rel="prev", ` +
+					`<https://api.mapbox.com/styles/v1/orijtech?start=c>; rel="next"`,
+			},
+			wantStart:   "c",
+			wantHasMore: true,
+		},
+		5: {links: []string{`<https://api.mapbox.com/styles/v1/orijtech?start=a>; rel="prev"`}},
+		6: {links: []string{`https://api.mapbox.com/styles/v1/orijtech?start=a; rel="next"`}},
+		// A next link without a token still means there are more pages.
+		7: {
+			links:       []string{`<https://api.mapbox.com/styles/v1/orijtech>; rel="next"`},
+			wantHasMore: true,
+		},
+		// Commas in the URLs don't split the links.
+		8: {
+			links: []string{
+				`<https://api.mapbox.com/datasets/v1/orijtech?bbox=-77.1,38.8,-76.9,39&start=a>; rel="prev", ` +
+					`<https://api.mapbox.com/datasets/v1/orijtech?bbox=-77.1,38.8,-76.9,39&start=store-2>; rel="next"`,
+			},
+			wantStart:   "store-2",
+			wantHasMore: true,
+		},
+	}
+
+	for i, tt := range tests {
+		h := make(http.Header)
+		for _, link := range tt.links {
+			h.Add("Link", link)
+		}
+		start, hasMore := parseNextLink(h)
+		if start != tt.wantStart {
+			t.Errorf("#%d: start got %q want %q", i, start, tt.wantStart)
+		}
+		if hasMore != tt.wantHasMore {
+			t.Errorf("#%d: hasMore got %t want %t", i, hasMore, tt.wantHasMore)
+		}
+	}
+}
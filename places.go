@@ -1,12 +1,16 @@
 package mapbox
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 
 	"go.opencensus.io/trace"
 )
@@ -28,89 +32,440 @@ func (gm GeocodeMode) String() string {
 // LookupPlace looks up the coordinates and information of a place
 // for example "Los Angeles" or "Edmonton".
 func (c *Client) LookupPlace(ctx context.Context, query string) (*GeocodeResponse, error) {
-	ctx, span := trace.StartSpan(ctx, "mapbox.(*Client).LookupPlace")
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).LookupPlace")
 	defer span.End()
 
-	return c.doGeoCodingRequest(ctx, span, &ReverseGeocodeRequest{
+	gres, _, err := c.forwardGeocode(ctx, span, &ForwardGeocodeRequest{
 		Query: query,
 	})
+	return gres, err
+}
+
+// LookupPlaceNear is LookupPlace with the results nearest to
+// the [lon, lat] pair near ranked first, for example to prefer
+// the "Springfield" closest to the user's location.
+func (c *Client) LookupPlaceNear(ctx context.Context, query string, near *LatLonPair) (*GeocodeResponse, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).LookupPlaceNear")
+	defer span.End()
+
+	gres, _, err := c.forwardGeocode(ctx, span, &ForwardGeocodeRequest{
+		Query:   query,
+		Request: &GeocodeRequest{Proximity: near},
+	})
+	return gres, err
+}
+
+// LookupPlaceInCountries is LookupPlace with the results limited
+// to countries, each an ISO 3166-1 alpha-2 code such as "fr", for
+// example to find Paris, France rather than Paris, Texas.
+func (c *Client) LookupPlaceInCountries(ctx context.Context, query string, countries ...string) (*GeocodeResponse, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).LookupPlaceInCountries")
+	defer span.End()
+
+	gres, _, err := c.forwardGeocode(ctx, span, &ForwardGeocodeRequest{
+		Query:   query,
+		Request: &GeocodeRequest{Country: countries},
+	})
+	return gres, err
 }
 
 // LookupLatLon is a helper to reverse geocoding
-// lookup a latitude and longitude pair.
+// lookup a latitude and longitude pair. Prefer
+// LookupPoint, whose coordinates can't be transposed.
 func (c *Client) LookupLatLon(ctx context.Context, lat, lon float64) (*GeocodeResponse, error) {
-	ctx, span := trace.StartSpan(ctx, "mapbox.(*Client).LookupLatLon")
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).LookupLatLon")
+	defer span.End()
+
+	// Mapbox expects the longitude first.
+	gres, _, err := c.reverseGeocode(ctx, span, &ReverseGeocodeRequest{
+		Query: c.formatDegrees(lon) + "," + c.formatDegrees(lat),
+	})
+	return gres, err
+}
+
+// LookupPoint is LookupLatLon with the
+// latitude and longitude named by point.
+func (c *Client) LookupPoint(ctx context.Context, point Coordinate) (*GeocodeResponse, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).LookupPoint")
+	defer span.End()
+
+	gres, _, err := c.reverseGeocode(ctx, span, &ReverseGeocodeRequest{
+		Query: c.formatPair(LatLonPair{point.Lon, point.Lat}),
+	})
+	return gres, err
+}
+
+// LookupPlacePermanent is LookupPlace in the GeocodePermanentPlaces
+// mode, whose results may be stored e.g. cached in a database.
+func (c *Client) LookupPlacePermanent(ctx context.Context, query string) (*GeocodeResponse, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).LookupPlacePermanent")
+	defer span.End()
+
+	gres, _, err := c.forwardGeocode(ctx, span, &ForwardGeocodeRequest{
+		Query: query,
+		Mode:  GeocodePermanentPlaces,
+	})
+	return gres, err
+}
+
+// LookupLatLonPermanent is LookupLatLon in the GeocodePermanentPlaces
+// mode, whose results may be stored e.g. cached in a database.
+func (c *Client) LookupLatLonPermanent(ctx context.Context, lat, lon float64) (*GeocodeResponse, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).LookupLatLonPermanent")
 	defer span.End()
 
-	return c.ReverseGeocoding(ctx, &ReverseGeocodeRequest{
-		Query: fmt.Sprintf("%f,%f", lon, lat),
+	gres, _, err := c.reverseGeocode(ctx, span, &ReverseGeocodeRequest{
+		Query: c.formatDegrees(lon) + "," + c.formatDegrees(lat),
+		Mode:  GeocodePermanentPlaces,
 	})
+	return gres, err
+}
+
+// ForwardGeocode converts place names to coordinates
+// "1600 Pennsylvania Ave NW" -> -77.036,38.897.
+func (c *Client) ForwardGeocode(ctx context.Context, req *ForwardGeocodeRequest) (*GeocodeResponse, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).ForwardGeocode")
+	defer span.End()
+
+	gres, _, err := c.forwardGeocode(ctx, span, req)
+	return gres, err
+}
+
+// GeocodeRaw is ForwardGeocode that also returns the HTTP response,
+// for its headers such as X-Request-Id, with its body already read
+// and closed. The HTTP response is nil for a cached result, or if
+// the request couldn't be made.
+func (c *Client) GeocodeRaw(ctx context.Context, req *ForwardGeocodeRequest) (*GeocodeResponse, *http.Response, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).GeocodeRaw")
+	defer span.End()
+
+	return c.forwardGeocode(ctx, span, req)
+}
+
+// forwardGeocode is GeocodeRaw recording to span,
+// the span of whichever method it is called from.
+func (c *Client) forwardGeocode(ctx context.Context, span *trace.Span, req *ForwardGeocodeRequest) (*GeocodeResponse, *http.Response, error) {
+	if err := req.Validate(); err != nil {
+		span.Annotate(nil, "Invalid request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
+		return nil, nil, err
+	}
+	gres := new(GeocodeResponse)
+	res, err := c.geocode(ctx, span, req.Mode, escapeQuery(req.Query), req.Request, gres)
+	if err != nil {
+		return nil, res, err
+	}
+	if len(gres.Features) == 0 && c.errOnEmpty() {
+		return nil, res, ErrNoResults
+	}
+	return gres, res, nil
 }
 
 // ReverseGeocoding Converts coordinates to place names
 // -77.036,38.897 -> 1600 Pennsylvania Ave NW.
 func (c *Client) ReverseGeocoding(ctx context.Context, req *ReverseGeocodeRequest) (*GeocodeResponse, error) {
-	ctx, span := trace.StartSpan(ctx, "mapbox.(*Client).ReverseGeocoding")
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).ReverseGeocoding")
 	defer span.End()
 
-	return c.doGeoCodingRequest(ctx, span, req)
+	gres, _, err := c.reverseGeocode(ctx, span, req)
+	return gres, err
 }
 
-// Request format:
-// GET /geocoding/v5/{mode}/{query}.json
-func (c *Client) doGeoCodingRequest(ctx context.Context, span *trace.Span, req *ReverseGeocodeRequest) (*GeocodeResponse, error) {
-	asURLValues, err := toURLValues(req.Request)
+// ReverseGeocodeRaw is ReverseGeocoding that also returns
+// the HTTP response, as GeocodeRaw does.
+func (c *Client) ReverseGeocodeRaw(ctx context.Context, req *ReverseGeocodeRequest) (*GeocodeResponse, *http.Response, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).ReverseGeocodeRaw")
+	defer span.End()
+
+	return c.reverseGeocode(ctx, span, req)
+}
+
+// reverseGeocode is ReverseGeocodeRaw recording to
+// span, the span of whichever method it is called from.
+func (c *Client) reverseGeocode(ctx context.Context, span *trace.Span, req *ReverseGeocodeRequest) (*GeocodeResponse, *http.Response, error) {
+	if err := req.Validate(); err != nil {
+		span.Annotate(nil, "Invalid request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
+		return nil, nil, err
+	}
+	gres := new(GeocodeResponse)
+	res, err := c.geocode(ctx, span, req.Mode, escapeQuery(req.Query), req.Request, gres)
 	if err != nil {
-		span.Annotate(nil, "Failed to convert request to url.Values")
-		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+		return nil, res, err
+	}
+	if len(gres.Features) == 0 && c.errOnEmpty() {
+		return nil, res, ErrNoResults
+	}
+	return gres, res, nil
+}
+
+// ErrNoResults is returned by clients created WithErrorOnEmpty
+// when a geocoding request matches nothing.
+var ErrNoResults = errors.New("mapbox: no results")
+
+func (c *Client) errOnEmpty() bool {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.errorOnEmpty
+}
+
+const maxBatchGeocodeQueries = 50
+
+var (
+	errNoBatchQueries      = errors.New("mapbox: expecting at least one query")
+	errTooManyBatchQueries = fmt.Errorf("mapbox: at most %d queries can be batched", maxBatchGeocodeQueries)
+)
+
+// BatchGeocode forward geocodes up to 50 queries in a single request,
+// using the GeocodePermanentPlaces mode. The returned responses are
+// in the same order as the queries.
+func (c *Client) BatchGeocode(ctx context.Context, queries []string, greq *GeocodeRequest) ([]*GeocodeResponse, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).BatchGeocode")
+	defer span.End()
+
+	switch n := len(queries); {
+	case n == 0:
+		return nil, errNoBatchQueries
+	case n > maxBatchGeocodeQueries:
+		return nil, errTooManyBatchQueries
+	}
+
+	if err := (&ForwardGeocodeRequest{Mode: GeocodePermanentPlaces, Request: greq}).Validate(); err != nil {
+		span.Annotate(nil, "Invalid request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
 		return nil, err
 	}
 
-	asURLValues.Add("access_token", c.APIKey())
+	return c.batchGeocode(ctx, span, escapeQuery(queries...), len(queries), greq)
+}
 
-	// GET /geocoding/v5/{mode}/{query}.json
-	outURL := fmt.Sprintf("%s/geocoding/v5/%s/%s.json?%s",
-		baseURL, req.Mode, req.Query, asURLValues.Encode())
-	hreq, err := http.NewRequest("GET", outURL, nil)
-	if err != nil {
-		span.Annotate(nil, "Failed to create http request")
-		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+// BatchReverseGeocode reverse geocodes up to 50 [lon, lat] points in
+// a single request, using the GeocodePermanentPlaces mode, unlike
+// BatchReverse which makes a temporary mode request per point. The
+// returned responses are in the same order as the points.
+func (c *Client) BatchReverseGeocode(ctx context.Context, points []*LatLonPair, greq *GeocodeRequest) ([]*GeocodeResponse, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).BatchReverseGeocode")
+	defer span.End()
+
+	switch n := len(points); {
+	case n == 0:
+		return nil, errNoBatchQueries
+	case n > maxBatchGeocodeQueries:
+		return nil, errTooManyBatchQueries
+	}
+
+	if err := (&ReverseGeocodeRequest{Mode: GeocodePermanentPlaces, Request: greq}).Validate(); err != nil {
+		span.Annotate(nil, "Invalid request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
 		return nil, err
 	}
-	hreq = hreq.WithContext(ctx)
 
-	httpClient := c._httpClient()
-	res, err := httpClient.Do(hreq)
-	if err != nil {
-		span.Annotate(nil, "Failed to make http request")
+	queries := make([]string, len(points))
+	for i, point := range points {
+		if point == nil || len(*point) != 2 {
+			err := fmt.Errorf("mapbox: point #%d is not a [lon, lat] pair", i)
+			span.Annotate(nil, "Invalid request")
+			span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
+			return nil, err
+		}
+		queries[i] = c.formatPair(*point)
+	}
+
+	return c.batchGeocode(ctx, span, escapeQuery(queries...), len(queries), greq)
+}
+
+// batchGeocode sends the n already escaped and joined queries
+// as one request, returning a response per query in order.
+func (c *Client) batchGeocode(ctx context.Context, span *trace.Span, query string, n int, greq *GeocodeRequest) ([]*GeocodeResponse, error) {
+	if n == 1 {
+		// Mapbox returns a lone FeatureCollection rather
+		// than a list of them for a single query.
+		gres := new(GeocodeResponse)
+		if err := c.doGeoCodingRequest(ctx, span, GeocodePermanentPlaces, query, greq, gres); err != nil {
+			return nil, err
+		}
+		return []*GeocodeResponse{gres}, nil
+	}
+
+	var gresL []*GeocodeResponse
+	if err := c.doGeoCodingRequest(ctx, span, GeocodePermanentPlaces, query, greq, &gresL); err != nil {
+		return nil, err
+	}
+	if len(gresL) != n {
+		err := fmt.Errorf("mapbox: got %d results for %d queries", len(gresL), n)
 		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
 		return nil, err
 	}
+	return gresL, nil
+}
+
+// escapeQuery path escapes each query, joining
+// them with the ";" batch separator. Commas are
+// left as is since they separate coordinates.
+func escapeQuery(queries ...string) string {
+	escaped := make([]string, len(queries))
+	for i, query := range queries {
+		escaped[i] = strings.Replace(url.PathEscape(query), "%2C", ",", -1)
+	}
+	return strings.Join(escaped, ";")
+}
 
-	defer res.Body.Close()
-	if !statusOK(res.StatusCode) {
-		span.Annotate(nil, "Bad response")
-		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: res.Status})
-		return nil, fmt.Errorf("%s", res.Status)
+const (
+	defaultGeocodeLimit    = 5
+	maxForwardGeocodeLimit = 10
+	maxReverseGeocodeLimit = 5
+)
+
+// GeocodeAll forward geocodes query, paging through the results
+// by widening the limit of each successive request, up to the
+// 10 results that Mapbox allows, and merging the features that
+// weren't seen before by their Id. It stops once a page returns
+// fewer features than requested or MaxResults features were found.
+func (c *Client) GeocodeAll(ctx context.Context, query string, greq *GeocodeRequest) (*GeocodeResponse, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).GeocodeAll")
+	defer span.End()
+
+	page := new(GeocodeRequest)
+	if greq != nil {
+		*page = *greq
+	}
+	step := page.Limit
+	if step == 0 {
+		step = defaultGeocodeLimit
 	}
 
-	blob, err := ioutil.ReadAll(res.Body)
+	merged := new(GeocodeResponse)
+	seen := make(map[string]bool)
+	for limit := step; ; limit += step {
+		if limit > maxForwardGeocodeLimit {
+			limit = maxForwardGeocodeLimit
+		}
+		page.Limit = limit
+
+		gres, _, err := c.forwardGeocode(ctx, span, &ForwardGeocodeRequest{Query: query, Request: page})
+		if err != nil {
+			return nil, err
+		}
+		merged.Type, merged.Query = gres.Type, gres.Query
+		for _, feat := range gres.Features {
+			if feat == nil || seen[feat.Id] {
+				continue
+			}
+			seen[feat.Id] = true
+			merged.Features = append(merged.Features, feat)
+			if page.MaxResults > 0 && uint(len(merged.Features)) >= page.MaxResults {
+				return merged, nil
+			}
+		}
+
+		if uint(len(gres.Features)) < limit || limit >= maxForwardGeocodeLimit {
+			return merged, nil
+		}
+	}
+}
+
+// Request format:
+// GET /geocoding/v5/{mode}/{query}.json
+// where query must already be path escaped.
+func (c *Client) doGeoCodingRequest(ctx context.Context, span *trace.Span, mode GeocodeMode, query string, greq *GeocodeRequest, recv interface{}) error {
+	_, err := c.geocode(ctx, span, mode, query, greq, recv)
+	return err
+}
+
+// geocode is doGeoCodingRequest that also returns the HTTP
+// response, with its body read and closed, unless recv came
+// from the cache.
+func (c *Client) geocode(ctx context.Context, span *trace.Span, mode GeocodeMode, query string, greq *GeocodeRequest, recv interface{}) (*http.Response, error) {
+	if greq == nil {
+		// A typed nil would be serialized as "null",
+		// so use the defaults for every parameter.
+		greq = new(GeocodeRequest)
+	}
+	asURLValues, err := c.toURLValues(greq)
 	if err != nil {
-		span.Annotate(nil, "Failed to read body")
+		span.Annotate(nil, "Failed to convert request to url.Values")
 		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
 		return nil, err
 	}
 
-	gres := new(GeocodeResponse)
-	if err := json.Unmarshal(blob, gres); err != nil {
-		span.Annotate(nil, "Failed to unmarshal JSON response")
-		span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: err.Error()})
+	// Only the permanent mode's results may be stored, and they
+	// are only served back for the same access token.
+	var cache Cache
+	if mode == GeocodePermanentPlaces {
+		cache = c._cache()
+	}
+	apiKey := c.apiKeyFor(ctx)
+	cacheKey := cacheKeyFor(c.geocodeURL(mode, query, asURLValues), apiKey)
+	if cache != nil {
+		if slurp, ok := cache.Get(cacheKey); ok {
+			span.Annotate(nil, "Cache hit")
+			return nil, unmarshalResponse(span, slurp, recv)
+		}
+	}
+
+	asURLValues.Add("access_token", apiKey)
+	outURL := c.geocodeURL(mode, query, asURLValues)
+
+	res, err := c.doHTTPRequest(ctx, span, "GET", outURL, nil)
+	if err != nil {
 		return nil, err
 	}
-	return gres, nil
+	defer func() {
+		drainAndClose(res.Body)
+		res.Body = http.NoBody
+	}()
+
+	if cache == nil {
+		return res, decodeResponse(span, res, res.Body, recv)
+	}
+
+	// Keep a copy of the body as it is decoded, to cache it.
+	buf := new(bytes.Buffer)
+	if err := decodeResponse(span, res, io.TeeReader(res.Body, buf), recv); err != nil {
+		return res, err
+	}
+	cache.Set(cacheKey, buf.Bytes(), defaultCacheTTL)
+	return res, nil
+}
+
+// geocodeURL returns the URL to geocode query, already escaped,
+// in mode with values as its query parameters.
+// GET /geocoding/v5/{mode}/{query}.json
+func (c *Client) geocodeURL(mode GeocodeMode, query string, values url.Values) string {
+	outURL := fmt.Sprintf("%s/geocoding/v5/%s/%s.json", c._baseURL(), mode, query)
+	if len(values) > 0 {
+		outURL += "?" + values.Encode()
+	}
+	return outURL
 }
 
-func toURLValues(v interface{}) (url.Values, error) {
+// BuildGeocodeURL returns the URL that ForwardGeocode would request
+// for req, for callers that make the request themselves, with the
+// access token for ctx unless WithTokenInHeader was set, in which
+// case it must be sent as "Authorization: Bearer <token>" instead.
+// It doesn't use the cache.
+func (c *Client) BuildGeocodeURL(ctx context.Context, req *ForwardGeocodeRequest) (string, error) {
+	if err := req.Validate(); err != nil {
+		return "", err
+	}
+	greq := req.Request
+	if greq == nil {
+		greq = new(GeocodeRequest)
+	}
+	values, err := c.toURLValues(greq)
+	if err != nil {
+		return "", err
+	}
+	if !c._tokenInHeader() {
+		values.Add("access_token", c.apiKeyFor(ctx))
+	}
+	return c.geocodeURL(req.Mode, escapeQuery(req.Query), values), nil
+}
+
+// toURLValues converts v, a request, to query parameters
+// formatting any coordinates with the client's precision.
+func (c *Client) toURLValues(v interface{}) (url.Values, error) {
 	// First JSON serialize it
 	blob, err := json.Marshal(v)
 	if err != nil {
@@ -132,21 +487,32 @@ func toURLValues(v interface{}) (url.Values, error) {
 			outValues.Add(key, typ)
 		case uint:
 			outValues.Add(key, fmt.Sprintf("%d", typ))
+		case float64:
+			// All JSON numbers are unmarshaled as float64
+			// so format integers such as limit without decimals.
+			outValues.Add(key, strconv.FormatFloat(typ, 'f', -1, 64))
 		case bool:
 			outValues.Add(key, fmt.Sprintf("%v", typ))
-		case []float32:
-			for _, fV := range typ {
-				outValues.Add(key, fmt.Sprintf("%f", fV))
-			}
 		case []string:
 			for _, strV := range typ {
 				outValues.Add(key, strV)
 			}
-		case *LatLonPair:
-			for _, fV := range *typ {
-				outValues.Add(key, fmt.Sprintf("%f", fV))
+		case []interface{}:
+			// Lists such as the proximity point or the types
+			// are sent as a single comma joined value
+			// e.g. "lon,lat" or "address,poi".
+			var strs []string
+			for _, iv := range typ {
+				switch elem := iv.(type) {
+				case float64:
+					strs = append(strs, c.formatDegrees(elem))
+				case string:
+					strs = append(strs, elem)
+				}
+			}
+			if len(strs) > 0 && len(strs) == len(typ) {
+				outValues.Add(key, strings.Join(strs, ","))
 			}
-			outValues.Add(key, fmt.Sprintf("%v", typ))
 		default:
 		}
 	}
@@ -154,17 +520,249 @@ func toURLValues(v interface{}) (url.Values, error) {
 	return outValues, nil
 }
 
+// ForwardGeocodeRequest is the request to convert
+// a place name in Query into coordinates.
+type ForwardGeocodeRequest struct {
+	Query   string      `json:"query"`
+	Mode    GeocodeMode `json:"mode"`
+	Request *GeocodeRequest
+}
+
+// ReverseGeocodeRequest is the request to convert
+// coordinates in Query, formatted as "lon,lat", into place names.
 type ReverseGeocodeRequest struct {
 	Query   string      `json:"query"`
 	Mode    GeocodeMode `json:"mode"`
 	Request *GeocodeRequest
 }
 
+var (
+	errProximity                = errors.New("mapbox: expecting proximity as a [lon, lat] pair")
+	errBoundingBoxWithProximity = errors.New("mapbox: bbox cannot be combined with proximity in a forward geocode")
+	errAutoCompleteOnReverse    = errors.New("mapbox: autocomplete is not supported in a reverse geocode")
+	errReverseLimitTypes        = errors.New("mapbox: a reverse geocode with a limit greater than 1 must specify exactly one type")
+	errAutoCompletePermanent    = errors.New("mapbox: autocomplete results cannot be stored so are not supported in the permanent mode")
+	errReverseModeOnForward     = errors.New("mapbox: reverseMode is only supported in a reverse geocode")
+	errNilGeocodeRequest        = errors.New("mapbox: expecting a non-nil geocode request")
+	errForwardOnlyOnReverse     = errors.New("mapbox: proximity and bbox are not supported in a reverse geocode")
+	errForwardLimit             = fmt.Errorf("mapbox: a forward geocode's limit must be at most %d", maxForwardGeocodeLimit)
+	errReverseLimit             = fmt.Errorf("mapbox: a reverse geocode's limit must be at most %d", maxReverseGeocodeLimit)
+)
+
+const (
+	// ReverseModeDistance orders a reverse geocode's
+	// results nearest first, as for what is under a pin.
+	ReverseModeDistance = "distance"
+
+	// ReverseModeScore orders a reverse geocode's results by
+	// prominence first, which is what Mapbox defaults to.
+	ReverseModeScore = "score"
+)
+
+// validateMode checks that mode is known and, for the
+// permanent mode, that greq doesn't ask for temporary results.
+func validateMode(mode GeocodeMode, greq *GeocodeRequest) error {
+	switch mode {
+	case "", GeocodePlaces:
+		return nil
+	case GeocodePermanentPlaces:
+		if greq != nil && greq.AutoComplete {
+			return errAutoCompletePermanent
+		}
+		return nil
+	default:
+		return fmt.Errorf("mapbox: unknown geocode mode %q", mode)
+	}
+}
+
+// ValidationError is every problem that Validate
+// found with a request, in the order it checked them.
+type ValidationError struct {
+	Problems []error
+}
+
+func (ve *ValidationError) Error() string {
+	if len(ve.Problems) == 1 {
+		return ve.Problems[0].Error()
+	}
+	msgs := make([]string, len(ve.Problems))
+	for i, problem := range ve.Problems {
+		msgs[i] = strings.TrimPrefix(problem.Error(), "mapbox: ")
+	}
+	return fmt.Sprintf("mapbox: %d problems: %s", len(msgs), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the problems, for errors.Is and errors.As.
+func (ve *ValidationError) Unwrap() []error { return ve.Problems }
+
+func (ve *ValidationError) add(err error) {
+	if err != nil {
+		ve.Problems = append(ve.Problems, err)
+	}
+}
+
+// err returns ve only if it has any problems, since a
+// nil *ValidationError would be a non-nil error.
+func (ve *ValidationError) err() error {
+	if len(ve.Problems) == 0 {
+		return nil
+	}
+	return ve
+}
+
+// Validate checks the request for all the problems that Mapbox
+// would reject it for, such as its types, limit, bbox, proximity
+// and their compatibility with its mode, returning them together
+// as a *ValidationError. It is called before every forward geocode.
+func (freq *ForwardGeocodeRequest) Validate() error {
+	if freq == nil {
+		return errNilGeocodeRequest
+	}
+	ve := new(ValidationError)
+	ve.add(validateMode(freq.Mode, freq.Request))
+	ve.add(validateTypes(freq.Mode, freq.Request))
+	ve.add(validateWorldview(freq.Request))
+	ve.add(validateCountries(freq.Request))
+	greq := freq.Request
+	if greq == nil {
+		return ve.err()
+	}
+	if greq.ReverseMode != "" {
+		ve.add(errReverseModeOnForward)
+	}
+	if greq.Limit > maxForwardGeocodeLimit {
+		ve.add(errForwardLimit)
+	}
+	if greq.Proximity != nil && len(*greq.Proximity) != 2 {
+		ve.add(errProximity)
+	}
+	if len(greq.BoundingBox) > 0 {
+		if greq.Proximity != nil {
+			ve.add(errBoundingBoxWithProximity)
+		}
+		ve.add(validateBoundingBox(greq.BoundingBox))
+	}
+	return ve.err()
+}
+
+// validateBoundingBox checks that bbox is
+// [minLon, minLat, maxLon, maxLat] as Mapbox expects.
+func validateBoundingBox(bbox []float32) error {
+	if len(bbox) != 4 {
+		return fmt.Errorf("mapbox: expecting bbox as [minLon, minLat, maxLon, maxLat], got %d values", len(bbox))
+	}
+	minLon, minLat, maxLon, maxLat := bbox[0], bbox[1], bbox[2], bbox[3]
+	if minLon > maxLon {
+		return fmt.Errorf("mapbox: bbox minLon %v is greater than maxLon %v", minLon, maxLon)
+	}
+	if minLat > maxLat {
+		return fmt.Errorf("mapbox: bbox minLat %v is greater than maxLat %v", minLat, maxLat)
+	}
+	return nil
+}
+
+// Validate is ForwardGeocodeRequest.Validate for a reverse
+// geocode, which also rejects the forward only parameters.
+// It is called before every reverse geocode.
+func (rreq *ReverseGeocodeRequest) Validate() error {
+	if rreq == nil {
+		return errNilGeocodeRequest
+	}
+	ve := new(ValidationError)
+	ve.add(validateMode(rreq.Mode, rreq.Request))
+	ve.add(validateTypes(rreq.Mode, rreq.Request))
+	ve.add(validateWorldview(rreq.Request))
+	ve.add(validateCountries(rreq.Request))
+	greq := rreq.Request
+	if greq == nil {
+		return ve.err()
+	}
+	if greq.AutoComplete {
+		ve.add(errAutoCompleteOnReverse)
+	}
+	if greq.Proximity != nil || len(greq.BoundingBox) > 0 {
+		ve.add(errForwardOnlyOnReverse)
+	}
+	if greq.Limit > maxReverseGeocodeLimit {
+		ve.add(errReverseLimit)
+	} else if greq.Limit > 1 && len(greq.Types) != 1 {
+		ve.add(errReverseLimitTypes)
+	}
+	switch greq.ReverseMode {
+	case "", ReverseModeDistance, ReverseModeScore:
+	default:
+		ve.add(fmt.Errorf("mapbox: unknown reverseMode %q, expecting %q or %q",
+			greq.ReverseMode, ReverseModeDistance, ReverseModeScore))
+	}
+	return ve.err()
+}
+
+// validateTypes checks that each type is known and, for the
+// permanent mode, that it isn't a POI which it doesn't support.
+func validateTypes(mode GeocodeMode, greq *GeocodeRequest) error {
+	if greq == nil {
+		return nil
+	}
+	for _, typ := range greq.Types {
+		switch typ {
+		case GTypeCountry, GTypeRegion, GTypePostcode, GTypeDistrict, GTypePlace,
+			GTypeLocality, GTypeNeighborhood, GTypeAddress:
+		case GTypePOI, GTypePOILandmark:
+			if mode == GeocodePermanentPlaces {
+				return fmt.Errorf("mapbox: the %q type is not supported in the permanent mode", typ)
+			}
+		default:
+			return fmt.Errorf("mapbox: unknown geocode type %q", typ)
+		}
+	}
+	return nil
+}
+
+const (
+	WorldviewUS = "us"
+	WorldviewCN = "cn"
+	WorldviewIN = "in"
+	WorldviewJP = "jp"
+)
+
+func validateWorldview(greq *GeocodeRequest) error {
+	if greq == nil {
+		return nil
+	}
+	switch greq.Worldview {
+	case "", WorldviewUS, WorldviewCN, WorldviewIN, WorldviewJP:
+		return nil
+	default:
+		return fmt.Errorf("mapbox: unknown worldview %q, expecting one of %q, %q, %q or %q",
+			greq.Worldview, WorldviewUS, WorldviewCN, WorldviewIN, WorldviewJP)
+	}
+}
+
+// validateCountries checks that each country is an
+// ISO 3166-1 alpha-2 code e.g. "fr" rather than "fra".
+func validateCountries(greq *GeocodeRequest) error {
+	if greq == nil {
+		return nil
+	}
+	for _, country := range greq.Country {
+		if len(country) != 2 || !isASCIILetter(country[0]) || !isASCIILetter(country[1]) {
+			return fmt.Errorf("mapbox: country %q is not a 2 letter ISO 3166-1 alpha-2 code", country)
+		}
+	}
+	return nil
+}
+
+func isASCIILetter(b byte) bool {
+	return ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
+
 type GeocodeType string
 
 const (
+	GTypeCountry      GeocodeType = "country"
 	GTypeRegion       GeocodeType = "region"
 	GTypePostcode     GeocodeType = "postcode"
+	GTypeDistrict     GeocodeType = "district"
 	GTypePlace        GeocodeType = "place"
 	GTypeLocality     GeocodeType = "locality"
 	GTypeNeighborhood GeocodeType = "neighborhood"
@@ -181,14 +779,104 @@ type GeocodeRequest struct {
 	Limit uint          `json:"limit,omitempty"`
 	Types []GeocodeType `json:"types,omitempty"`
 
-	Proximity    *LatLonPair `json:"proximity,omitempty"`
-	BoundingBox  []float32   `json:"bbox,omitempty"`
-	AutoComplete bool        `json:"autocomplete,omitempty"`
+	Proximity *LatLonPair `json:"proximity,omitempty"`
+
+	// BoundingBox if set limits forward geocoding results to
+	// [minLon, minLat, maxLon, maxLat] and is sent as one
+	// comma joined bbox parameter.
+	BoundingBox  []float32 `json:"bbox,omitempty"`
+	AutoComplete bool      `json:"autocomplete,omitempty"`
+
+	// Language is a set of one or more IETF language tags
+	// e.g. "fr" or "de", with any later ones as fallbacks.
+	Language []string `json:"language,omitempty"`
+
+	// FuzzyMatch if explicitly set to false disables the
+	// approximate matching of queries, which Mapbox enables
+	// by default, hence it being a pointer.
+	FuzzyMatch *bool `json:"fuzzyMatch,omitempty"`
+
+	// Routing if set requests the routable points of addresses, in
+	// forward and reverse geocodes, see GeocodeFeature.RoutablePoint.
+	Routing bool `json:"routing,omitempty"`
+
+	// Worldview if set is one of WorldviewUS, WorldviewCN,
+	// WorldviewIN or WorldviewJP, for the disputed borders to
+	// match those of the map. Mapbox defaults to WorldviewUS.
+	Worldview string `json:"worldview,omitempty"`
+
+	// ReverseMode if set is ReverseModeDistance or
+	// ReverseModeScore, to order the results of a reverse
+	// geocode. It is rejected in a forward geocode.
+	ReverseMode string `json:"reverseMode,omitempty"`
+
+	// MaxResults caps the number of features
+	// returned by GeocodeAll and is not sent to Mapbox.
+	MaxResults uint `json:"-"`
 }
 
+const (
+	GeometryPoint      = "Point"
+	GeometryLineString = "LineString"
+	GeometryPolygon    = "Polygon"
+)
+
 type Geometry struct {
-	Type        string    `json:"type"`
+	Type string `json:"type"`
+
+	// Coordinates is set for a "Point" as [lon, lat].
 	Coordinates []float32 `json:"coordinates"`
+
+	// Line is set for a "LineString".
+	Line []*LatLonPair `json:"-"`
+
+	// Polygon is set for a "Polygon", its first ring
+	// is the exterior and any other rings are holes.
+	Polygon [][]*LatLonPair `json:"-"`
+}
+
+type geometryJSON struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+func (g Geometry) MarshalJSON() ([]byte, error) {
+	gj := &geometryJSON{Type: g.Type, Coordinates: g.Coordinates}
+	switch g.Type {
+	case GeometryLineString:
+		gj.Coordinates = g.Line
+	case GeometryPolygon:
+		gj.Coordinates = g.Polygon
+	}
+	return json.Marshal(gj)
+}
+
+// UnmarshalJSON decodes the coordinates of Points,
+// LineStrings and Polygons. The coordinates of any
+// other geometry type are not retained.
+func (g *Geometry) UnmarshalJSON(b []byte) error {
+	recv := new(struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	})
+	if err := json.Unmarshal(b, recv); err != nil {
+		return err
+	}
+
+	*g = Geometry{Type: recv.Type}
+	if len(recv.Coordinates) == 0 {
+		return nil
+	}
+	switch g.Type {
+	case GeometryPoint, "":
+		return json.Unmarshal(recv.Coordinates, &g.Coordinates)
+	case GeometryLineString:
+		return json.Unmarshal(recv.Coordinates, &g.Line)
+	case GeometryPolygon:
+		return json.Unmarshal(recv.Coordinates, &g.Polygon)
+	default:
+		return nil
+	}
 }
 
 type GeocodeContext struct {
@@ -208,14 +896,144 @@ type GeocodeFeature struct {
 	Properties *GeocodeProperty  `json:"properties"`
 	Context    []*GeocodeContext `json:"context"`
 
+	// BoundingBox and Center are in [lon, lat] order,
+	// prefer Bounds and CenterCoordinate to read them.
 	BoundingBox []float32 `json:"bbox"`
 	Center      []float32 `json:"center"`
 	Geometry    *Geometry `json:"geometry"`
 	Attribution string    `json:"attribution"`
 }
 
+// UnmarshalJSON accepts the numeric ids that
+// features from tilesets, rather than geocoding, have.
+func (gf *GeocodeFeature) UnmarshalJSON(b []byte) error {
+	type feature GeocodeFeature
+	recv := &struct {
+		*feature
+		Id json.RawMessage `json:"id"`
+	}{feature: (*feature)(gf)}
+	if err := json.Unmarshal(b, recv); err != nil {
+		return err
+	}
+
+	gf.Id = ""
+	if len(recv.Id) == 0 || string(recv.Id) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(recv.Id, &gf.Id); err != nil {
+		// Otherwise it is a number.
+		gf.Id = string(recv.Id)
+	}
+	return nil
+}
+
+// ContextOf returns the feature's context entry of the given
+// kind e.g. "region" or "place", matched on its id prefix, or the
+// feature itself as an entry if it is of that kind, and false if
+// there is neither.
+func (gf *GeocodeFeature) ContextOf(kind string) (*GeocodeContext, bool) {
+	if gf == nil {
+		return nil, false
+	}
+	prefix := kind + "."
+	if strings.HasPrefix(gf.Id, prefix) {
+		shortCode, _ := gf.Properties.str("short_code")
+		wikidata, _ := gf.Properties.Wikidata()
+		return &GeocodeContext{Id: gf.Id, Text: gf.Text, ShortCode: shortCode, Wikidata: wikidata}, true
+	}
+	for _, gc := range gf.Context {
+		if gc != nil && strings.HasPrefix(gc.Id, prefix) {
+			return gc, true
+		}
+	}
+	return nil, false
+}
+
+// CountryCode returns the ISO 3166-1 alpha-2 code
+// of the feature's country e.g. "us".
+func (gf *GeocodeFeature) CountryCode() (string, bool) {
+	country, ok := gf.ContextOf("country")
+	if !ok || country.ShortCode == "" {
+		return "", false
+	}
+	return country.ShortCode, true
+}
+
+// Region returns the feature's region e.g. a state,
+// whose ShortCode is its ISO 3166-2 code e.g. "US-CA".
+func (gf *GeocodeFeature) Region() (*GeocodeContext, bool) {
+	return gf.ContextOf("region")
+}
+
+// Wikidata returns the Wikidata id of the feature itself e.g.
+// "Q62", which POIs and addresses usually don't have. Those
+// of the places containing it can be read with ContextOf.
+func (gf *GeocodeFeature) Wikidata() (string, bool) {
+	if gf == nil {
+		return "", false
+	}
+	return gf.Properties.Wikidata()
+}
+
+// RoutablePoint returns the [lon, lat] pair to navigate to for an
+// address, such as its entrance from the street rather than its
+// rooftop Center, if the request set Routing. Mapbox nests the
+// points either directly in the "routable_points" property or
+// under its "points", and the first of them is returned.
+func (gf *GeocodeFeature) RoutablePoint() (*LatLonPair, bool) {
+	if gf == nil || gf.Properties == nil {
+		return nil, false
+	}
+	routable := (*gf.Properties)["routable_points"]
+	if nested, ok := routable.(map[string]interface{}); ok {
+		routable = nested["points"]
+	}
+	points, _ := routable.([]interface{})
+	if len(points) == 0 {
+		return nil, false
+	}
+	point, _ := points[0].(map[string]interface{})
+	coords, _ := point["coordinates"].([]interface{})
+	if len(coords) != 2 {
+		return nil, false
+	}
+	lon, lonOk := coords[0].(float64)
+	lat, latOk := coords[1].(float64)
+	if !lonOk || !latOk {
+		return nil, false
+	}
+	return &LatLonPair{float32(lon), float32(lat)}, true
+}
+
+// GeocodeProperty holds a feature's properties. The common ones
+// can be read with its accessors, which report false if the
+// property is missing or isn't a string, and any others by key.
 type GeocodeProperty map[string]interface{}
 
+func (gp *GeocodeProperty) str(key string) (string, bool) {
+	if gp == nil {
+		return "", false
+	}
+	value, ok := (*gp)[key].(string)
+	return value, ok
+}
+
+// Category returns the comma separated categories
+// of a POI e.g. "coffee, tea, cafe".
+func (gp *GeocodeProperty) Category() (string, bool) { return gp.str("category") }
+
+// Address returns the street address of a POI.
+func (gp *GeocodeProperty) Address() (string, bool) { return gp.str("address") }
+
+// Phone returns the telephone number of a POI.
+func (gp *GeocodeProperty) Phone() (string, bool) { return gp.str("tel") }
+
+// Maki returns the name of the Maki icon for a POI e.g. "cafe".
+func (gp *GeocodeProperty) Maki() (string, bool) { return gp.str("maki") }
+
+// Wikidata returns the Wikidata id of the feature e.g. "Q62".
+func (gp *GeocodeProperty) Wikidata() (string, bool) { return gp.str("wikidata") }
+
 type GeocodeResponse struct {
 	Type     string            `json:"type,omitempty"`
 	Query    *LatLonPair       `json:"query,omitempty"`
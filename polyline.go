@@ -0,0 +1,112 @@
+package mapbox
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	errTruncatedPolyline = errors.New("mapbox: truncated polyline")
+	errPolylineOverflow  = errors.New("mapbox: polyline value overflows")
+)
+
+func polylineFactor(precision int) (float64, error) {
+	switch precision {
+	case 5, 6:
+		return math.Pow10(precision), nil
+	default:
+		return 0, fmt.Errorf("mapbox: polyline precision must be 5 or 6, got %d", precision)
+	}
+}
+
+// DecodePolyline decodes an encoded polyline, such as a route's
+// geometry, of precision 5 ("polyline") or 6 ("polyline6") into
+// [lon, lat] pairs. Note that being float32, the pairs may be off
+// by a few millionths of a degree at precision 6.
+func DecodePolyline(s string, precision int) ([]*LatLonPair, error) {
+	factor, err := polylineFactor(precision)
+	if err != nil {
+		return nil, err
+	}
+
+	var points []*LatLonPair
+	var lat, lon int64
+	for i := 0; i < len(s); {
+		dlat, n, err := decodePolylineValue(s[i:])
+		if err != nil {
+			return nil, err
+		}
+		i += n
+		dlon, n, err := decodePolylineValue(s[i:])
+		if err != nil {
+			return nil, err
+		}
+		i += n
+
+		lat += dlat
+		lon += dlon
+		points = append(points, &LatLonPair{float32(float64(lon) / factor), float32(float64(lat) / factor)})
+	}
+	return points, nil
+}
+
+// decodePolylineValue returns the first value
+// in s and the number of bytes it occupied.
+func decodePolylineValue(s string) (int64, int, error) {
+	var result int64
+	var shift uint
+	for i := 0; i < len(s); i++ {
+		b := int64(s[i]) - 63
+		if b < 0 || b > 63 {
+			return 0, 0, fmt.Errorf("mapbox: invalid polyline character %q", s[i])
+		}
+		if shift > 60 {
+			return 0, 0, errPolylineOverflow
+		}
+		result |= (b & 0x1f) << shift
+		shift += 5
+		if b < 0x20 {
+			if result&1 != 0 {
+				return ^(result >> 1), i + 1, nil
+			}
+			return result >> 1, i + 1, nil
+		}
+	}
+	return 0, 0, errTruncatedPolyline
+}
+
+// EncodePolyline encodes [lon, lat] pairs into a polyline
+// of precision 5 ("polyline") or 6 ("polyline6").
+func EncodePolyline(points []*LatLonPair, precision int) (string, error) {
+	factor, err := polylineFactor(precision)
+	if err != nil {
+		return "", err
+	}
+
+	var buf []byte
+	var prevLat, prevLon int64
+	for i, point := range points {
+		if point == nil || len(*point) != 2 {
+			return "", fmt.Errorf("mapbox: point #%d is not a [lon, lat] pair", i)
+		}
+		lat := int64(math.Round(float64((*point)[1]) * factor))
+		lon := int64(math.Round(float64((*point)[0]) * factor))
+		buf = encodePolylineValue(buf, lat-prevLat)
+		buf = encodePolylineValue(buf, lon-prevLon)
+		prevLat, prevLon = lat, lon
+	}
+	return string(buf), nil
+}
+
+func encodePolylineValue(buf []byte, v int64) []byte {
+	u := v << 1
+	if v < 0 {
+		u = ^u
+	}
+	for u >= 0x20 {
+		buf = append(buf, byte((0x20|(u&0x1f))+63))
+		u >>= 5
+	}
+	return append(buf, byte(u+63))
+}
@@ -0,0 +1,111 @@
+package mapbox_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/orijtech/mapbox"
+)
+
+func TestPolylineRoundTrip(t *testing.T) {
+	tests := []struct {
+		polyline  string
+		precision int
+		want      []*mapbox.LatLonPair
+	}{
+		0: {
+			polyline:  "_p~iF~ps|U_ulLnnqC_mqNvxq`@",
+			precision: 5,
+			want: []*mapbox.LatLonPair{
+				{-120.2, 38.5},
+				{-120.95, 40.7},
+				{-126.453, 43.252},
+			},
+		},
+		1: {
+			polyline:  "o{aeiA`_}|qC_`@}@gWmcB",
+			precision: 6,
+			want: []*mapbox.LatLonPair{
+				{-77.036545, 38.897096},
+				{-77.036514, 38.897624},
+				{-77.034907, 38.898012},
+			},
+		},
+		2: {
+			polyline:  "",
+			precision: 6,
+		},
+	}
+
+	for i, tt := range tests {
+		got, err := mapbox.DecodePolyline(tt.polyline, tt.precision)
+		if err != nil {
+			t.Errorf("#%d: decode: %v", i, err)
+			continue
+		}
+		if err := pointsWithin(got, tt.want, tt.precision); err != "" {
+			t.Errorf("#%d: decode: %s", i, err)
+		}
+
+		encoded, err := mapbox.EncodePolyline(tt.want, tt.precision)
+		if err != nil {
+			t.Errorf("#%d: encode: %v", i, err)
+			continue
+		}
+		// float32 coordinates only survive an exact
+		// round trip at precision 5.
+		if tt.precision == 5 && encoded != tt.polyline {
+			t.Errorf("#%d: encode\ngot:  %q\nwant: %q", i, encoded, tt.polyline)
+		}
+		redecoded, err := mapbox.DecodePolyline(encoded, tt.precision)
+		if err != nil {
+			t.Errorf("#%d: decoding the encoded: %v", i, err)
+			continue
+		}
+		if err := pointsWithin(redecoded, tt.want, tt.precision); err != "" {
+			t.Errorf("#%d: round trip: %s", i, err)
+		}
+	}
+}
+
+// pointsWithin reports how got differs from want
+// beyond the float32 error at the polyline precision.
+func pointsWithin(got, want []*mapbox.LatLonPair, precision int) string {
+	if len(got) != len(want) {
+		return fmt.Sprintf("got %d points want %d", len(got), len(want))
+	}
+	tolerance := math.Max(1.5*math.Pow10(-precision), 1e-5)
+	for i, point := range got {
+		for j := range *point {
+			if diff := math.Abs(float64((*point)[j] - (*want[i])[j])); diff > tolerance {
+				return fmt.Sprintf("point #%d got %v want %v", i, *point, *want[i])
+			}
+		}
+	}
+	return ""
+}
+
+func TestDecodePolylineErrors(t *testing.T) {
+	tests := []struct {
+		polyline  string
+		precision int
+	}{
+		0: {polyline: "_p~iF~ps|U_ulLnnqC_mqNvxq`@", precision: 7},
+		// Truncated in the middle of a value.
+		1: {polyline: "_p~iF~ps|U_ulLnnqC_mqNvxq", precision: 5},
+		// A latitude without its longitude.
+		2: {polyline: "_p~iF", precision: 5},
+		// Characters outside of the polyline alphabet.
+		3: {polyline: "_p~iF ps|U", precision: 5},
+		4: {polyline: "\x00\x01", precision: 5},
+		// A value that never terminates.
+		5: {polyline: "~~~~~~~~~~~~~~~~~~~~", precision: 5},
+	}
+
+	for i, tt := range tests {
+		if points, err := mapbox.DecodePolyline(tt.polyline, tt.precision); err == nil {
+			t.Errorf("#%d: want non-nil error, got points %v", i, points)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package mapbox
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitStatus is the rate limit that Mapbox
+// reported in the headers of the latest response.
+type RateLimitStatus struct {
+	// Limit is the number of requests allowed per period.
+	Limit int
+
+	// Remaining is the number of requests
+	// left in the current period.
+	Remaining int
+
+	// Reset is when the current period ends.
+	Reset time.Time
+}
+
+type clock interface {
+	Now() time.Time
+	After(time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// rateLimiter is a token bucket that paces requests to rps
+// with bursts of up to rps, and which also holds requests
+// back until the reset once Mapbox reports none remaining.
+type rateLimiter struct {
+	clock clock
+
+	mu     sync.Mutex
+	rps    float64
+	tokens float64
+	last   time.Time
+
+	status    RateLimitStatus
+	hasStatus bool
+}
+
+func newRateLimiter(clk clock) *rateLimiter {
+	return &rateLimiter{clock: clk}
+}
+
+func (rl *rateLimiter) setRate(rps int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.rps = float64(rps)
+	rl.tokens = rl.rps
+	rl.last = time.Time{}
+}
+
+// reserve takes a token and returns how long to
+// wait before the request can be sent.
+func (rl *rateLimiter) reserve() time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if rl.rps <= 0 {
+		return 0
+	}
+	now := rl.clock.Now()
+	if !rl.last.IsZero() {
+		rl.tokens = math.Min(rl.rps, rl.tokens+now.Sub(rl.last).Seconds()*rl.rps)
+	}
+	rl.last = now
+	rl.tokens--
+
+	var wait time.Duration
+	if rl.tokens < 0 {
+		wait = time.Duration(-rl.tokens / rl.rps * float64(time.Second))
+	}
+	if rl.hasStatus && rl.status.Remaining <= 0 {
+		if untilReset := rl.status.Reset.Sub(now); untilReset > wait {
+			wait = untilReset
+		}
+	}
+	return wait
+}
+
+// cancel returns the token of a request that was never sent.
+func (rl *rateLimiter) cancel() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if rl.rps > 0 {
+		rl.tokens = math.Min(rl.rps, rl.tokens+1)
+	}
+}
+
+// update records the rate limit headers of res, if any.
+func (rl *rateLimiter) update(res *http.Response) {
+	remaining, err := strconv.Atoi(res.Header.Get("X-Rate-Limit-Remaining"))
+	if err != nil {
+		return
+	}
+	status := RateLimitStatus{Remaining: remaining}
+	status.Limit, _ = strconv.Atoi(res.Header.Get("X-Rate-Limit-Limit"))
+	if secs, err := strconv.ParseInt(res.Header.Get("X-Rate-Limit-Reset"), 10, 64); err == nil {
+		status.Reset = time.Unix(secs, 0)
+	}
+
+	rl.mu.Lock()
+	rl.status, rl.hasStatus = status, true
+	rl.mu.Unlock()
+}
+
+func (rl *rateLimiter) currentStatus() (RateLimitStatus, bool) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	return rl.status, rl.hasStatus
+}
+
+type rateLimitTransport struct {
+	limiter *rateLimiter
+	base    http.RoundTripper
+}
+
+var _ http.RoundTripper = (*rateLimitTransport)(nil)
+
+func (rlt *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := rlt.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if wait := rlt.limiter.reserve(); wait > 0 {
+		select {
+		case <-req.Context().Done():
+			rlt.limiter.cancel()
+			return nil, req.Context().Err()
+		case <-rlt.limiter.clock.After(wait):
+		}
+	}
+
+	res, err := base.RoundTrip(req)
+	if err == nil {
+		rlt.limiter.update(res)
+	}
+	return res, err
+}
+
+// RateLimit returns the rate limit reported by the latest
+// response, and false if none has reported it yet.
+func (c *Client) RateLimit() (RateLimitStatus, bool) {
+	if c.limiter == nil {
+		return RateLimitStatus{}, false
+	}
+	return c.limiter.currentStatus()
+}
@@ -0,0 +1,154 @@
+package mapbox
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock advances its time by however long it is waited on.
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+func (fc *fakeClock) Now() time.Time {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	return fc.now
+}
+
+func (fc *fakeClock) After(d time.Duration) <-chan time.Time {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	fc.waits = append(fc.waits, d)
+	fc.now = fc.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- fc.now
+	return ch
+}
+
+func (fc *fakeClock) advance(d time.Duration) {
+	fc.mu.Lock()
+	fc.now = fc.now.Add(d)
+	fc.mu.Unlock()
+}
+
+type headerTransport func() http.Header
+
+func (ht headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Header:     ht(),
+		Body:       ioutil.NopCloser(strings.NewReader("{}")),
+	}, nil
+}
+
+func TestRateLimiterPacing(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1500000000, 0)}
+	rl := newRateLimiter(clk)
+	rl.setRate(2)
+
+	// The first 2 requests are a burst,
+	// then they are paced 500ms apart.
+	var waits []time.Duration
+	for i := 0; i < 4; i++ {
+		waits = append(waits, rl.reserve())
+	}
+	want := []time.Duration{0, 0, 500 * time.Millisecond, time.Second}
+	for i := range want {
+		if waits[i] != want[i] {
+			t.Errorf("#%d: wait got %v want %v", i, waits[i], want[i])
+		}
+	}
+
+	// After a quiet second the bucket refills.
+	clk.advance(3 * time.Second)
+	if wait := rl.reserve(); wait != 0 {
+		t.Errorf("after refilling: wait got %v want 0", wait)
+	}
+}
+
+func TestRateLimitTransportHonorsReset(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1500000000, 0)}
+	rl := newRateLimiter(clk)
+	rl.setRate(100)
+
+	reset := clk.Now().Add(42 * time.Second)
+	remaining := 1
+	rlt := &rateLimitTransport{
+		limiter: rl,
+		base: headerTransport(func() http.Header {
+			remaining--
+			hdr := make(http.Header)
+			hdr.Set("X-Rate-Limit-Limit", "600")
+			hdr.Set("X-Rate-Limit-Remaining", strconv.Itoa(remaining))
+			hdr.Set("X-Rate-Limit-Reset", strconv.FormatInt(reset.Unix(), 10))
+			return hdr
+		}),
+	}
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", "https://api.mapbox.com/", nil)
+		res, err := rlt.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		res.Body.Close()
+	}
+
+	// The first response reported no requests remaining,
+	// so the second request must have waited for the reset.
+	if len(clk.waits) != 1 {
+		t.Fatalf("got waits %v want one", clk.waits)
+	}
+	if g, w := clk.waits[0], 42*time.Second; g != w {
+		t.Errorf("wait got %v want %v", g, w)
+	}
+
+	status, ok := rl.currentStatus()
+	if !ok {
+		t.Fatal("expected a rate limit status")
+	}
+	want := RateLimitStatus{Limit: 600, Remaining: -1, Reset: reset}
+	if status != want {
+		t.Errorf("status got %+v want %+v", status, want)
+	}
+}
+
+func TestRateLimitTransportCancelled(t *testing.T) {
+	rl := newRateLimiter(systemClock{})
+	rl.setRate(1)
+	rl.update(&http.Response{Header: http.Header{
+		"X-Rate-Limit-Remaining": {"0"},
+		"X-Rate-Limit-Reset":     {strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)},
+	}})
+
+	requests := 0
+	rlt := &rateLimitTransport{
+		limiter: rl,
+		base: headerTransport(func() http.Header {
+			requests++
+			return make(http.Header)
+		}),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequest("GET", "https://api.mapbox.com/", nil)
+	if _, err := rlt.RoundTrip(req.WithContext(ctx)); err != context.DeadlineExceeded {
+		t.Errorf("got %v want %v", err, context.DeadlineExceeded)
+	}
+	if requests != 0 {
+		t.Errorf("made %d requests want none", requests)
+	}
+}
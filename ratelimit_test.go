@@ -0,0 +1,41 @@
+package mapbox_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/orijtech/mapbox"
+)
+
+func TestRateLimitStatus(t *testing.T) {
+	reset := time.Unix(1500000060, 0)
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			res, err := respFromFileContents(geocodeResponsePath("LA"))
+			res.Header.Set("X-Rate-Limit-Limit", "600")
+			res.Header.Set("X-Rate-Limit-Remaining", "599")
+			res.Header.Set("X-Rate-Limit-Reset", "1500000060")
+			return res, err
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := client.RateLimit(); ok {
+		t.Error("expected no rate limit status before any request")
+	}
+	if _, err := client.LookupPlace(context.Background(), "Los Angeles"); err != nil {
+		t.Fatal(err)
+	}
+	status, ok := client.RateLimit()
+	if !ok {
+		t.Fatal("expected a rate limit status")
+	}
+	want := mapbox.RateLimitStatus{Limit: 600, Remaining: 599, Reset: reset}
+	if !status.Reset.Equal(want.Reset) || status.Limit != want.Limit || status.Remaining != want.Remaining {
+		t.Errorf("got %+v want %+v", status, want)
+	}
+}
@@ -0,0 +1,137 @@
+package mapbox
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type retryTransport struct {
+	maxRetries int
+	base       http.RoundTripper
+}
+
+var _ http.RoundTripper = (*retryTransport)(nil)
+
+const (
+	minRetryBackoff = 100 * time.Millisecond
+	maxRetryBackoff = 10 * time.Second
+)
+
+type retryablePOSTKey struct{}
+
+// withRetryablePOST marks the POST requests made
+// with ctx as idempotent and hence safe to retry.
+func withRetryablePOST(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryablePOSTKey{}, true)
+}
+
+func retryableRequest(req *http.Request) bool {
+	switch req.Method {
+	case "GET", "HEAD":
+		return true
+	case "POST":
+		ok, _ := req.Context().Value(retryablePOSTKey{}).(bool)
+		return ok && (req.Body == nil || req.GetBody != nil)
+	default:
+		return false
+	}
+}
+
+func retryableResponse(res *http.Response) bool {
+	return retryableStatus(res.StatusCode)
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// retryableError reports whether err is worth
+// retrying i.e. it is a throttled or server error.
+func retryableError(err error) bool {
+	var me *MapboxError
+	return errors.As(err, &me) && retryableStatus(me.StatusCode)
+}
+
+// retryAfter parses the Retry-After header, in either seconds
+// or as a date, returning false if it is missing or invalid.
+func retryAfter(h http.Header) (time.Duration, bool) {
+	ra := h.Get("Retry-After")
+	if ra == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(ra); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(ra); err == nil {
+		if wait := time.Until(at); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func (rt *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := rt.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if !retryableRequest(req) {
+		return base.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		areq := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			areq = req.Clone(ctx)
+			areq.Body = body
+		}
+
+		res, err := base.RoundTrip(areq)
+		if err != nil || attempt >= rt.maxRetries || !retryableResponse(res) {
+			return res, err
+		}
+
+		wait := retryBackoff(attempt, res)
+		drainAndClose(res.Body)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryBackoff returns the wait advised by the Retry-After
+// header, which is either in seconds or an HTTP date, or
+// otherwise a jittered exponential backoff for attempt.
+// Either way it is at most maxRetryBackoff, so that a server
+// advising a wait of hours doesn't hold the caller that long.
+func retryBackoff(attempt int, res *http.Response) time.Duration {
+	if wait, ok := retryAfter(res.Header); ok {
+		if wait > maxRetryBackoff {
+			wait = maxRetryBackoff
+		}
+		return wait
+	}
+
+	backoff := minRetryBackoff << uint(attempt)
+	if backoff <= 0 || backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	// Wait in the range [backoff/2, backoff).
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(half)))
+}
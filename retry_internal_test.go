@@ -0,0 +1,27 @@
+package mapbox
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryBackoffCapsRetryAfter(t *testing.T) {
+	tests := []struct {
+		retryAfter string
+		want       time.Duration
+	}{
+		0: {retryAfter: "2", want: 2 * time.Second},
+		1: {retryAfter: "86400", want: maxRetryBackoff},
+		2: {retryAfter: time.Now().Add(24 * time.Hour).UTC().Format(http.TimeFormat), want: maxRetryBackoff},
+		3: {retryAfter: "0", want: 0},
+	}
+
+	for i, tt := range tests {
+		res := &http.Response{Header: make(http.Header)}
+		res.Header.Set("Retry-After", tt.retryAfter)
+		if got := retryBackoff(0, res); got != tt.want {
+			t.Errorf("#%d: Retry-After %q: got %s want %s", i, tt.retryAfter, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package mapbox_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/orijtech/mapbox"
+)
+
+// flakyTransport fails with the given responses,
+// in order, before succeeding with the fixture.
+type flakyTransport struct {
+	failures []*http.Response
+	fixture  string
+
+	attempts int
+	bodies   []string
+}
+
+func (ft *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ft.attempts++
+	if req.Body != nil {
+		slurp, _ := ioutil.ReadAll(req.Body)
+		ft.bodies = append(ft.bodies, string(slurp))
+	}
+	if len(ft.failures) > 0 {
+		res := ft.failures[0]
+		ft.failures = ft.failures[1:]
+		return res, nil
+	}
+	return respFromFileContents(ft.fixture)
+}
+
+func failure(code int, retryAfter string) *http.Response {
+	res := makeResp(http.StatusText(code), code, ioutil.NopCloser(strings.NewReader("{}")))
+	if retryAfter != "" {
+		res.Header.Set("Retry-After", retryAfter)
+	}
+	return res
+}
+
+func TestRetryGeocode(t *testing.T) {
+	ft := &flakyTransport{
+		failures: []*http.Response{
+			failure(http.StatusTooManyRequests, "0"),
+			failure(http.StatusServiceUnavailable, ""),
+			failure(http.StatusTooManyRequests, time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)),
+		},
+		fixture: geocodeResponsePath("LA"),
+	}
+	client, err := mapbox.NewClient(
+		mapbox.WithHTTPClient(&http.Client{Transport: ft}),
+		mapbox.WithRetry(3),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.LookupPlace(context.Background(), "Los Angeles"); err != nil {
+		t.Fatal(err)
+	}
+	if g, w := ft.attempts, 4; g != w {
+		t.Errorf("attempts: got %d want %d", g, w)
+	}
+}
+
+func TestRetryRequestDuration(t *testing.T) {
+	ft := &flakyTransport{
+		failures: []*http.Response{
+			failure(http.StatusBadGateway, ""),
+			failure(http.StatusBadGateway, ""),
+		},
+		fixture: "./testdata/durations-3x3.json",
+	}
+	client, err := mapbox.NewClient(
+		mapbox.WithHTTPClient(&http.Client{Transport: ft}),
+		mapbox.WithRetry(2),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := &mapbox.DurationRequest{
+		Coordinates: []*mapbox.LatLonPair{
+			{13.41894, 52.50055},
+			{14.10293, 52.50055},
+		},
+	}
+	if _, err := client.RequestDuration(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if g, w := ft.attempts, 3; g != w {
+		t.Errorf("attempts: got %d want %d", g, w)
+	}
+	// Every attempt must have resent the same body.
+	for i, body := range ft.bodies {
+		if body == "" || body != ft.bodies[0] {
+			t.Errorf("attempt #%d: got body %q want %q", i, body, ft.bodies[0])
+		}
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	ft := &flakyTransport{
+		failures: []*http.Response{
+			failure(http.StatusServiceUnavailable, "0"),
+			failure(http.StatusServiceUnavailable, "0"),
+			failure(http.StatusServiceUnavailable, "0"),
+		},
+		fixture: geocodeResponsePath("LA"),
+	}
+	client, err := mapbox.NewClient(
+		mapbox.WithHTTPClient(&http.Client{Transport: ft}),
+		mapbox.WithRetry(1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.LookupPlace(context.Background(), "Los Angeles")
+	me := new(mapbox.MapboxError)
+	if !errors.As(err, &me) || me.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("got err %v; want a 503 *MapboxError", err)
+	}
+	if g, w := ft.attempts, 2; g != w {
+		t.Errorf("attempts: got %d want %d", g, w)
+	}
+}
+
+func TestRetryRespectsContext(t *testing.T) {
+	ft := &flakyTransport{
+		failures: []*http.Response{
+			failure(http.StatusTooManyRequests, "60"),
+		},
+		fixture: geocodeResponsePath("LA"),
+	}
+	client, err := mapbox.NewClient(
+		mapbox.WithHTTPClient(&http.Client{Transport: ft}),
+		mapbox.WithRetry(3),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = client.LookupPlace(ctx, "Los Angeles")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got err %v; want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("took %s, Retry-After should have been cut short by the context", elapsed)
+	}
+	if g, w := ft.attempts, 1; g != w {
+		t.Errorf("attempts: got %d want %d", g, w)
+	}
+}
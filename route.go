@@ -0,0 +1,433 @@
+package mapbox
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Profile is the mode of travel that
+// the navigation APIs route for.
+type Profile string
+
+const (
+	ProfileDriving        Profile = "driving"
+	ProfileDrivingTraffic Profile = "driving-traffic"
+	ProfileWalking        Profile = "walking"
+	ProfileCycling        Profile = "cycling"
+)
+
+const defaultProfile = ProfileDriving
+
+// Valid reports whether p is one of the known profiles.
+func (p Profile) Valid() bool {
+	switch p {
+	case ProfileDriving, ProfileDrivingTraffic, ProfileWalking, ProfileCycling:
+		return true
+	default:
+		return false
+	}
+}
+
+// String returns p as it appears in the request path.
+func (p Profile) String() string { return string(p) }
+
+// validateProfile allows an unset profile, which
+// defaults to ProfileDriving, or a known one.
+func validateProfile(p Profile) error {
+	if p == "" || p.Valid() {
+		return nil
+	}
+	return fmt.Errorf("mapbox: unknown profile %q", p)
+}
+
+// Route is a path through the road network
+// as returned by the navigation APIs.
+type Route struct {
+	// Distance is in meters.
+	Distance float32 `json:"distance"`
+
+	// Duration is in seconds.
+	Duration float32 `json:"duration"`
+
+	Weight     float32 `json:"weight"`
+	WeightName string  `json:"weight_name,omitempty"`
+
+	// Geometry is the polyline encoded path of the route, unless
+	// GeoJSON geometries were requested, in which case it is
+	// empty and GeometryGeoJSON is set instead. DecodedGeometry
+	// returns the path's coordinates in either case.
+	Geometry        string    `json:"geometry,omitempty"`
+	GeometryGeoJSON *Geometry `json:"-"`
+
+	Legs []*RouteLeg `json:"legs,omitempty"`
+
+	// precision is that of Geometry, if unset it is 5.
+	precision int
+}
+
+// UnmarshalJSON decodes the route's
+// geometry as either a polyline or GeoJSON.
+func (r *Route) UnmarshalJSON(b []byte) error {
+	type route Route
+	recv := &struct {
+		*route
+		Geometry json.RawMessage `json:"geometry"`
+	}{route: (*route)(r)}
+	if err := json.Unmarshal(b, recv); err != nil {
+		return err
+	}
+
+	r.Geometry, r.GeometryGeoJSON = "", nil
+	switch geom := bytes.TrimSpace(recv.Geometry); {
+	case len(geom) == 0 || string(geom) == "null":
+		return nil
+	case geom[0] == '"':
+		return json.Unmarshal(geom, &r.Geometry)
+	default:
+		r.GeometryGeoJSON = new(Geometry)
+		return json.Unmarshal(geom, r.GeometryGeoJSON)
+	}
+}
+
+// DecodedGeometry returns the [lon, lat] pairs of the route's
+// path, which are empty if the route has no geometry such as
+// when requested with OverviewFalse.
+func (r *Route) DecodedGeometry() ([]*LatLonPair, error) {
+	if geom := r.GeometryGeoJSON; geom != nil {
+		return geom.Line, nil
+	}
+	if r.Geometry == "" {
+		return nil, nil
+	}
+	precision := r.precision
+	if precision == 0 {
+		precision = 5
+	}
+	return DecodePolyline(r.Geometry, precision)
+}
+
+// RouteLeg is the part of a Route between two waypoints.
+type RouteLeg struct {
+	// Distance is in meters.
+	Distance float32 `json:"distance"`
+
+	// Duration is in seconds.
+	Duration float32 `json:"duration"`
+
+	Weight  float32 `json:"weight"`
+	Summary string  `json:"summary,omitempty"`
+
+	// Steps are only set if the request asked for them.
+	Steps []*RouteStep `json:"steps,omitempty"`
+
+	// Annotation is only set if the request asked for annotations.
+	Annotation *LegAnnotation `json:"annotation,omitempty"`
+}
+
+// LegAnnotation has the requested annotations of each segment of
+// the leg's geometry, that is between each pair of its points.
+type LegAnnotation struct {
+	// Duration is in seconds.
+	Duration []float32 `json:"duration,omitempty"`
+
+	// Distance is in meters.
+	Distance []float32 `json:"distance,omitempty"`
+
+	// Speed is in meters per second.
+	Speed []float32 `json:"speed,omitempty"`
+
+	// Congestion is one of "unknown", "low",
+	// "moderate", "heavy" or "severe".
+	Congestion []string `json:"congestion,omitempty"`
+}
+
+// RouteStep is a single maneuver along a RouteLeg
+// and the stretch of road that follows it.
+type RouteStep struct {
+	Maneuver *StepManeuver `json:"maneuver"`
+
+	// Name is the name of the road, if any.
+	Name string `json:"name"`
+
+	// Distance is in meters.
+	Distance float32 `json:"distance"`
+
+	// Duration is in seconds.
+	Duration float32 `json:"duration"`
+
+	// VoiceInstructions and BannerInstructions are set when
+	// they were requested, in the order they are to be given.
+	VoiceInstructions  []*VoiceInstruction  `json:"voiceInstructions,omitempty"`
+	BannerInstructions []*BannerInstruction `json:"bannerInstructions,omitempty"`
+}
+
+// VoiceInstruction is an announcement to be spoken during a RouteStep.
+type VoiceInstruction struct {
+	// DistanceAlongGeometry is the distance in meters
+	// before the end of the step at which to announce it.
+	DistanceAlongGeometry float32 `json:"distanceAlongGeometry"`
+
+	Announcement string `json:"announcement"`
+
+	// SSMLAnnouncement is Announcement marked up in the
+	// Speech Synthesis Markup Language for speech engines.
+	SSMLAnnouncement string `json:"ssmlAnnouncement,omitempty"`
+}
+
+// BannerInstruction is the visual guidance to show during a RouteStep.
+type BannerInstruction struct {
+	// DistanceAlongGeometry is the distance in meters
+	// before the end of the step at which to show it.
+	DistanceAlongGeometry float32 `json:"distanceAlongGeometry"`
+
+	Primary *Banner `json:"primary"`
+
+	// Secondary and Sub if set are shown below Primary,
+	// with Sub typically holding the lanes.
+	Secondary *Banner `json:"secondary,omitempty"`
+	Sub       *Banner `json:"sub,omitempty"`
+}
+
+// Banner is the text of a BannerInstruction,
+// also split into components for rendering.
+type Banner struct {
+	Text string `json:"text"`
+
+	// Type and Modifier are those of the upcoming maneuver.
+	Type     string `json:"type,omitempty"`
+	Modifier string `json:"modifier,omitempty"`
+
+	Components []*BannerComponent `json:"components"`
+}
+
+// BannerComponent is a part of a Banner,
+// of a Type such as "text", "icon" or "lane".
+type BannerComponent struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+
+	// Abbreviation if set is a shorter form of Text,
+	// with those of the lowest priority to be used first.
+	Abbreviation         string `json:"abbr,omitempty"`
+	AbbreviationPriority int    `json:"abbr_priority,omitempty"`
+
+	// Directions and Active are set for lanes, with
+	// Active for those that lead to the maneuver.
+	Directions []string `json:"directions,omitempty"`
+	Active     bool     `json:"active,omitempty"`
+}
+
+// StepManeuver is the action at the start of a RouteStep.
+type StepManeuver struct {
+	// Type is e.g. "depart", "turn" or "arrive".
+	Type string `json:"type"`
+
+	// Modifier if set is the direction
+	// of the maneuver e.g. "left".
+	Modifier string `json:"modifier,omitempty"`
+
+	// Instruction is a human readable
+	// description e.g. "Turn left onto Main St".
+	Instruction string `json:"instruction"`
+
+	// Location is the [lon, lat] pair of the maneuver.
+	Location *LatLonPair `json:"location"`
+
+	// Exit if set is the number of the exit to take, counting
+	// from 1, for roundabout and rotary maneuvers.
+	Exit int `json:"exit,omitempty"`
+}
+
+const metersPerMile = 1609.344
+
+// DistanceKilometers returns the route's Distance in kilometers.
+func (r *Route) DistanceKilometers() float64 { return float64(r.Distance) / 1000 }
+
+// DistanceMiles returns the route's Distance in miles.
+func (r *Route) DistanceMiles() float64 { return float64(r.Distance) / metersPerMile }
+
+// DurationMinutes returns the route's Duration in minutes.
+func (r *Route) DurationMinutes() float64 { return float64(r.Duration) / 60 }
+
+// DistanceKilometers returns the leg's Distance in kilometers.
+func (rl *RouteLeg) DistanceKilometers() float64 { return float64(rl.Distance) / 1000 }
+
+// DistanceMiles returns the leg's Distance in miles.
+func (rl *RouteLeg) DistanceMiles() float64 { return float64(rl.Distance) / metersPerMile }
+
+// DurationMinutes returns the leg's Duration in minutes.
+func (rl *RouteLeg) DurationMinutes() float64 { return float64(rl.Duration) / 60 }
+
+// coordinatesPath formats coordinates as
+// the path segment "lon,lat;lon,lat;...".
+func (c *Client) coordinatesPath(coords []*LatLonPair) string {
+	pairs := make([]string, len(coords))
+	for i, coord := range coords {
+		pairs[i] = c.formatPair(*coord)
+	}
+	return strings.Join(pairs, ";")
+}
+
+const (
+	ApproachUnrestricted = "unrestricted"
+	ApproachCurb         = "curb"
+)
+
+var (
+	errApproachesCount = errors.New("mapbox: expecting exactly one approach per coordinate")
+	errBearingsCount   = errors.New("mapbox: expecting exactly one bearing per coordinate")
+)
+
+// validateApproaches checks that there are either no
+// approaches or a valid one for each of n coordinates.
+func validateApproaches(approaches []string, n int) error {
+	if len(approaches) > 0 && len(approaches) != n {
+		return errApproachesCount
+	}
+	for i, approach := range approaches {
+		switch approach {
+		case "", ApproachUnrestricted, ApproachCurb:
+		default:
+			return fmt.Errorf("mapbox: approach #%d %q is not %q or %q", i, approach, ApproachUnrestricted, ApproachCurb)
+		}
+	}
+	return nil
+}
+
+// validateBearings checks that there are either no
+// bearings or a valid one for each of n coordinates.
+func validateBearings(bearings [][2]uint, n int) error {
+	if len(bearings) > 0 && len(bearings) != n {
+		return errBearingsCount
+	}
+	for i, bearing := range bearings {
+		if bearing[0] > 360 || bearing[1] > 180 {
+			return fmt.Errorf("mapbox: bearing #%d %v is not an angle in [0, 360] and a range in [0, 180]", i, bearing)
+		}
+	}
+	return nil
+}
+
+// joinBearings formats bearings as "angle,range;;angle,range"
+// keeping the empty slots of unconstrained coordinates.
+func joinBearings(bearings [][2]uint) string {
+	strs := make([]string, len(bearings))
+	for i, bearing := range bearings {
+		if bearing != [2]uint{} {
+			strs[i] = fmt.Sprintf("%d,%d", bearing[0], bearing[1])
+		}
+	}
+	return strings.Join(strs, ";")
+}
+
+// RadiusUnlimited is the radius that lets a coordinate
+// snap to the nearest road however far away it is.
+const RadiusUnlimited float32 = -1
+
+// validateRadiuses checks that there are either no radiuses or
+// one for each of n coordinates, each of them positive, 0 for the
+// default or RadiusUnlimited.
+func validateRadiuses(radiuses []float32, n int) error {
+	if len(radiuses) > 0 && len(radiuses) != n {
+		return errRadiusesCount
+	}
+	for i, radius := range radiuses {
+		if radius < 0 && radius != RadiusUnlimited {
+			return fmt.Errorf("mapbox: radius #%d %g is neither positive nor RadiusUnlimited", i, radius)
+		}
+	}
+	return nil
+}
+
+// joinRadiuses formats radiuses as "meters;;unlimited"
+// keeping the empty slots of the default radiuses.
+func joinRadiuses(radiuses []float32) string {
+	strs := make([]string, len(radiuses))
+	for i, radius := range radiuses {
+		switch {
+		case radius == RadiusUnlimited:
+			strs[i] = "unlimited"
+		case radius > 0:
+			strs[i] = strconv.FormatFloat(float64(radius), 'f', -1, 32)
+		}
+	}
+	return strings.Join(strs, ";")
+}
+
+// Waypoint is an input coordinate as snapped to the road network.
+type Waypoint struct {
+	Name     string      `json:"name"`
+	Location *LatLonPair `json:"location"`
+
+	// DistanceToInput is how far in meters the snapped Location
+	// is from the input coordinate, so that a large distance
+	// flags a coordinate that was likely mistyped.
+	DistanceToInput float32 `json:"distance_to_input,omitempty"`
+}
+
+// setDistancesToInput sets each waypoint's DistanceToInput from
+// its input coordinate, which is coords[indices[i]] for the i-th
+// waypoint, or coords[i] if indices is empty.
+func setDistancesToInput(waypoints []*Waypoint, coords []*LatLonPair, indices []uint) {
+	for i, wp := range waypoints {
+		index := i
+		if len(indices) > 0 {
+			if i >= len(indices) {
+				return
+			}
+			index = int(indices[i])
+		}
+		if wp == nil || index >= len(coords) {
+			continue
+		}
+		if input := coords[index]; input != nil && wp.Location != nil {
+			wp.DistanceToInput = float32(input.DistanceTo(wp.Location))
+		}
+	}
+}
+
+// CodeError is returned when a navigation API
+// responds with a code other than "Ok".
+type CodeError struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	CodeOk        = "Ok"
+	CodeNoMatch   = "NoMatch"
+	CodeNoSegment = "NoSegment"
+)
+
+func (ce *CodeError) Error() string {
+	if ce.Message == "" {
+		return fmt.Sprintf("mapbox: %s", ce.Code)
+	}
+	return fmt.Sprintf("mapbox: %s: %s", ce.Code, ce.Message)
+}
+
+// NoSegmentError is returned instead of a *CodeError with the
+// CodeNoSegment code, when a coordinate couldn't be snapped to any
+// road, typically because its radius is too small.
+type NoSegmentError struct {
+	*CodeError
+
+	// Radiuses are the request's radiuses, if it had any.
+	Radiuses []float32
+}
+
+func (nse *NoSegmentError) Unwrap() error { return nse.CodeError }
+
+// codeError returns the error for a response with code other
+// than CodeOk, for a request that had radiuses.
+func codeError(code, message string, radiuses []float32) error {
+	ce := &CodeError{Code: code, Message: message}
+	if code == CodeNoSegment {
+		return &NoSegmentError{CodeError: ce, Radiuses: radiuses}
+	}
+	return ce
+}
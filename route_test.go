@@ -0,0 +1,163 @@
+package mapbox_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/orijtech/mapbox"
+)
+
+func TestRouteUnits(t *testing.T) {
+	route := &mapbox.Route{
+		Distance: 16093.44,
+		Duration: 900,
+		Legs: []*mapbox.RouteLeg{
+			{Distance: 1609.344, Duration: 90},
+		},
+	}
+	leg := route.Legs[0]
+
+	tests := []struct {
+		name      string
+		got, want float64
+	}{
+		0: {name: "route miles", got: route.DistanceMiles(), want: 10},
+		1: {name: "route kilometers", got: route.DistanceKilometers(), want: 16.09344},
+		2: {name: "route minutes", got: route.DurationMinutes(), want: 15},
+		3: {name: "leg miles", got: leg.DistanceMiles(), want: 1},
+		4: {name: "leg kilometers", got: leg.DistanceKilometers(), want: 1.609344},
+		5: {name: "leg minutes", got: leg.DurationMinutes(), want: 1.5},
+	}
+
+	for i, tt := range tests {
+		if math.Abs(tt.got-tt.want) > 1e-3 {
+			t.Errorf("#%d %s: got %v want %v", i, tt.name, tt.got, tt.want)
+		}
+	}
+
+	// The raw fields are left untouched.
+	if route.Distance != 16093.44 || route.Duration != 900 {
+		t.Errorf("raw fields changed: %+v", route)
+	}
+}
+
+func TestProfileValid(t *testing.T) {
+	tests := []struct {
+		profile mapbox.Profile
+		want    bool
+	}{
+		0: {profile: mapbox.ProfileDriving, want: true},
+		1: {profile: mapbox.ProfileDrivingTraffic, want: true},
+		2: {profile: mapbox.ProfileWalking, want: true},
+		3: {profile: mapbox.ProfileCycling, want: true},
+		4: {profile: ""},
+		5: {profile: "Driving"},
+		6: {profile: "bicycle"},
+	}
+
+	for i, tt := range tests {
+		if g, w := tt.profile.Valid(), tt.want; g != w {
+			t.Errorf("#%d: %q.Valid() got %t want %t", i, tt.profile, g, w)
+		}
+	}
+}
+
+func TestProfileInPath(t *testing.T) {
+	profiles := []mapbox.Profile{
+		mapbox.ProfileDriving,
+		mapbox.ProfileDrivingTraffic,
+		mapbox.ProfileWalking,
+		mapbox.ProfileCycling,
+	}
+
+	for i, profile := range profiles {
+		var gotPath string
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				gotPath = req.URL.Path
+				return respFromFileContents("./testdata/directions-SF.json")
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = client.Directions(context.Background(), &mapbox.DirectionsRequest{
+			Profile:     profile,
+			Coordinates: []*mapbox.LatLonPair{{-122.42, 37.78}, {-122.45, 37.91}},
+		})
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if g, w := gotPath, "/directions/v5/mapbox/"+profile.String()+"/"; !strings.HasPrefix(g, w) {
+			t.Errorf("#%d: path got %q want prefix %q", i, g, w)
+		}
+	}
+}
+
+func TestUnknownProfile(t *testing.T) {
+	const unknown mapbox.Profile = "bicycle"
+	pair := []*mapbox.LatLonPair{{-122.42, 37.78}, {-122.45, 37.91}}
+	tests := []struct {
+		call func(*mapbox.Client) error
+	}{
+		0: {call: func(client *mapbox.Client) error {
+			_, err := client.Directions(context.Background(), &mapbox.DirectionsRequest{Profile: unknown, Coordinates: pair})
+			return err
+		}},
+		1: {call: func(client *mapbox.Client) error {
+			_, err := client.Matrix(context.Background(), &mapbox.MatrixRequest{Profile: unknown, Coordinates: pair})
+			return err
+		}},
+		2: {call: func(client *mapbox.Client) error {
+			_, err := client.RequestDuration(context.Background(), &mapbox.DurationRequest{Profile: unknown, Coordinates: pair})
+			return err
+		}},
+		3: {call: func(client *mapbox.Client) error {
+			_, err := client.MapMatch(context.Background(), &mapbox.MapMatchRequest{Profile: unknown, Coordinates: pair})
+			return err
+		}},
+		4: {call: func(client *mapbox.Client) error {
+			_, err := client.Optimize(context.Background(), &mapbox.OptimizationRequest{Profile: unknown, Coordinates: pair})
+			return err
+		}},
+		5: {call: func(client *mapbox.Client) error {
+			_, err := client.Isochrone(context.Background(), &mapbox.IsochroneRequest{
+				Profile: unknown, Center: pair[0], ContourMinutes: []uint{5},
+			})
+			return err
+		}},
+		// Isochrones don't support traffic.
+		6: {call: func(client *mapbox.Client) error {
+			_, err := client.Isochrone(context.Background(), &mapbox.IsochroneRequest{
+				Profile: mapbox.ProfileDrivingTraffic, Center: pair[0], ContourMinutes: []uint{5},
+			})
+			return err
+		}},
+	}
+
+	for i, tt := range tests {
+		sent := false
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				sent = true
+				return nil, errors.New("unexpected request")
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := tt.call(client); err == nil {
+			t.Errorf("#%d: want non-nil error", i)
+		}
+		if sent {
+			t.Errorf("#%d: the request was sent", i)
+		}
+	}
+}
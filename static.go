@@ -0,0 +1,246 @@
+package mapbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	defaultStaticUsername = "mapbox"
+	defaultStaticStyleID  = "streets-v11"
+
+	maxStaticImageDimension = 1280
+	maxStaticImageZoom      = 22
+)
+
+type StaticImageRequest struct {
+	// Username owns the style, if unset it defaults to "mapbox".
+	Username string `json:"username,omitempty"`
+	// StyleID if unset defaults to "streets-v11".
+	StyleID string `json:"style_id,omitempty"`
+
+	// Center is the [lon, lat] pair that the image is
+	// centered on, and it is required unless Auto is set.
+	Center *LatLonPair `json:"center,omitempty"`
+	Zoom   float32     `json:"zoom,omitempty"`
+
+	// Auto if set fits the image to its Overlays
+	// instead of using Center and Zoom.
+	Auto bool `json:"auto,omitempty"`
+
+	// Width and Height are in pixels, in the range [1, 1280].
+	Width  uint `json:"width"`
+	Height uint `json:"height"`
+
+	// Retina if set doubles the image's resolution.
+	Retina bool `json:"retina,omitempty"`
+
+	// Overlays are markers, paths or GeoJSON drawn on the
+	// image, formatted as Mapbox expects e.g. "pin-s+555555(-77.03,38.89)".
+	Overlays []string `json:"overlays,omitempty"`
+
+	// Shapes are markers and paths drawn after any Overlays,
+	// in order, so that later shapes are drawn on top.
+	Shapes []StaticOverlay `json:"-"`
+}
+
+// StaticOverlay is a shape drawn on a static image.
+type StaticOverlay interface {
+	// Overlay renders the shape as a
+	// URL escaped overlay path segment.
+	Overlay() string
+}
+
+// Marker is a pin drawn on a static image.
+type Marker struct {
+	Lon float32 `json:"lon"`
+	Lat float32 `json:"lat"`
+
+	// Label if set is a letter, a number
+	// from 0 to 99 or a Maki icon name.
+	Label string `json:"label,omitempty"`
+
+	// Color if set is a hex color e.g. "ff0000".
+	Color string `json:"color,omitempty"`
+
+	// Size is either "s" or "l", and defaults to "s".
+	Size string `json:"size,omitempty"`
+}
+
+var _ StaticOverlay = (*Marker)(nil)
+
+// Overlay renders the marker e.g. "pin-l-a+ff0000(-77.03,38.89)",
+// with the default coordinate precision.
+func (m *Marker) Overlay() string {
+	return m.overlay(defaultCoordinatePrecision)
+}
+
+// precisionOverlay is a StaticOverlay with coordinates
+// that can be rendered with a client's precision.
+type precisionOverlay interface {
+	overlay(precision int) string
+}
+
+var _ precisionOverlay = (*Marker)(nil)
+
+func (m *Marker) overlay(precision int) string {
+	size := m.Size
+	if size == "" {
+		size = "s"
+	}
+	overlay := "pin-" + size
+	if m.Label != "" {
+		overlay += "-" + url.PathEscape(strings.ToLower(m.Label))
+	}
+	if m.Color != "" {
+		overlay += "+" + strings.TrimPrefix(m.Color, "#")
+	}
+	return fmt.Sprintf("%s(%s)", overlay, formatPair(LatLonPair{m.Lon, m.Lat}, precision))
+}
+
+// PathOverlay is a line drawn on a static image.
+type PathOverlay struct {
+	// Polyline is the path encoded with precision 5,
+	// see EncodePolyline.
+	Polyline string `json:"polyline"`
+
+	// StrokeWidth if set is the line's width in pixels.
+	StrokeWidth uint `json:"stroke_width,omitempty"`
+
+	// StrokeColor and FillColor if set are hex colors, with
+	// FillColor filling the path as a polygon. Setting
+	// FillColor requires also setting StrokeColor.
+	StrokeColor string `json:"stroke_color,omitempty"`
+	FillColor   string `json:"fill_color,omitempty"`
+}
+
+var _ StaticOverlay = (*PathOverlay)(nil)
+
+// Overlay renders the path e.g. "path-5+f44+f449(...)",
+// escaping the polyline for use in the URL's path.
+func (po *PathOverlay) Overlay() string {
+	overlay := "path"
+	if po.StrokeWidth > 0 {
+		overlay += "-" + strconv.FormatUint(uint64(po.StrokeWidth), 10)
+	}
+	if po.StrokeColor != "" {
+		overlay += "+" + strings.TrimPrefix(po.StrokeColor, "#")
+		if po.FillColor != "" {
+			overlay += "+" + strings.TrimPrefix(po.FillColor, "#")
+		}
+	}
+	return fmt.Sprintf("%s(%s)", overlay, url.PathEscape(po.Polyline))
+}
+
+// StaticImageResponse is the stream of the image's bytes, which
+// the caller must close, along with its Content-Type. It is an
+// io.ReadCloser so it can be used wherever the stream is expected.
+type StaticImageResponse struct {
+	io.ReadCloser
+
+	// ContentType is the image's MIME type e.g. "image/png".
+	ContentType string
+}
+
+var _ io.ReadCloser = (*StaticImageResponse)(nil)
+
+var (
+	errNilStaticImageRequest = errors.New("mapbox: expecting a non-nil StaticImageRequest")
+	errStaticImageDimensions = fmt.Errorf("mapbox: width and height must be in the range [1, %d]", maxStaticImageDimension)
+	errStaticImageAuto       = errors.New("mapbox: Auto requires at least one overlay to fit")
+	errStaticImageZoom       = fmt.Errorf("mapbox: zoom must be in the range [0, %d]", maxStaticImageZoom)
+)
+
+func (sreq *StaticImageRequest) validate() error {
+	if sreq == nil {
+		return errNilStaticImageRequest
+	}
+	if sreq.Width == 0 || sreq.Width > maxStaticImageDimension || sreq.Height == 0 || sreq.Height > maxStaticImageDimension {
+		return errStaticImageDimensions
+	}
+	for i, shape := range sreq.Shapes {
+		if shape == nil {
+			return fmt.Errorf("mapbox: shape #%d is nil", i)
+		}
+		if po, ok := shape.(*PathOverlay); ok && po.Polyline == "" {
+			return fmt.Errorf("mapbox: path #%d has no polyline", i)
+		}
+	}
+	if sreq.Auto {
+		if len(sreq.Overlays) == 0 && len(sreq.Shapes) == 0 {
+			return errStaticImageAuto
+		}
+		return nil
+	}
+	if sreq.Center == nil || len(*sreq.Center) != 2 {
+		return errInvalidCenter
+	}
+	if sreq.Zoom < 0 || sreq.Zoom > maxStaticImageZoom {
+		return errStaticImageZoom
+	}
+	return nil
+}
+
+// StaticImage renders a map as an image, returned as an
+// io.ReadCloser that also carries the image's Content-Type.
+// Request format:
+// GET /styles/v1/{username}/{style_id}/static/{overlay}/{lon},{lat},{zoom}/{width}x{height}
+func (c *Client) StaticImage(ctx context.Context, sreq *StaticImageRequest) (*StaticImageResponse, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).StaticImage")
+	defer span.End()
+
+	if err := sreq.validate(); err != nil {
+		span.Annotate(nil, "Invalid request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
+		return nil, err
+	}
+
+	username, styleID := sreq.Username, sreq.StyleID
+	if username == "" {
+		username = defaultStaticUsername
+	}
+	if styleID == "" {
+		styleID = defaultStaticStyleID
+	}
+
+	segments := []string{"styles", "v1", username, styleID, "static"}
+	overlays := append([]string(nil), sreq.Overlays...)
+	for _, shape := range sreq.Shapes {
+		if po, ok := shape.(precisionOverlay); ok {
+			overlays = append(overlays, po.overlay(c.coordinatePrecision()))
+		} else {
+			overlays = append(overlays, shape.Overlay())
+		}
+	}
+	if len(overlays) > 0 {
+		segments = append(segments, strings.Join(overlays, ","))
+	}
+	if sreq.Auto {
+		segments = append(segments, "auto")
+	} else {
+		zoom := strconv.FormatFloat(float64(sreq.Zoom), 'f', -1, 32)
+		segments = append(segments, c.formatPair(*sreq.Center)+","+zoom)
+	}
+	size := fmt.Sprintf("%dx%d", sreq.Width, sreq.Height)
+	if sreq.Retina {
+		size += "@2x"
+	}
+	segments = append(segments, size)
+
+	values := make(url.Values)
+	values.Add("access_token", c.apiKeyFor(ctx))
+	outURL := fmt.Sprintf("%s/%s?%s", c._baseURL(), strings.Join(segments, "/"), values.Encode())
+
+	res, err := c.doHTTPRequest(ctx, span, "GET", outURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &StaticImageResponse{ReadCloser: res.Body, ContentType: res.Header.Get("Content-Type")}, nil
+}
@@ -0,0 +1,212 @@
+package mapbox_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/orijtech/mapbox"
+)
+
+var pngMagic = []byte("\x89PNG\r\n\x1a\n")
+
+func TestStaticImage(t *testing.T) {
+	tests := []struct {
+		req      *mapbox.StaticImageRequest
+		wantPath string
+	}{
+		0: {
+			req: &mapbox.StaticImageRequest{
+				Center: &mapbox.LatLonPair{-77.0366, 38.8971},
+				Zoom:   14.5,
+				Width:  600,
+				Height: 400,
+			},
+			wantPath: "/styles/v1/mapbox/streets-v11/static/-77.0366,38.8971,14.5/600x400",
+		},
+		1: {
+			req: &mapbox.StaticImageRequest{
+				Username: "orijtech",
+				StyleID:  "dark-v10",
+				Auto:     true,
+				Width:    1280,
+				Height:   1280,
+				Retina:   true,
+				Overlays: []string{"pin-s+555555(-77.0366,38.8971)", "pin-l-a+ff0000(-77.03,38.89)"},
+			},
+			wantPath: "/styles/v1/orijtech/dark-v10/static/pin-s+555555(-77.0366,38.8971),pin-l-a+ff0000(-77.03,38.89)/auto/1280x1280@2x",
+		},
+	}
+
+	for i, tt := range tests {
+		var gotPath string
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				gotPath = req.URL.Path
+				res := makeResp("200 OK", http.StatusOK, ioutil.NopCloser(bytes.NewReader(pngMagic)))
+				res.Header.Set("Content-Type", "image/png")
+				return res, nil
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		img, err := client.StaticImage(context.Background(), tt.req)
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		blob, err := ioutil.ReadAll(img)
+		_ = img.Close()
+		if err != nil {
+			t.Errorf("#%d: reading image: %v", i, err)
+			continue
+		}
+
+		if gotPath != tt.wantPath {
+			t.Errorf("#%d: path\ngot:  %q\nwant: %q", i, gotPath, tt.wantPath)
+		}
+		if g, w := img.ContentType, "image/png"; g != w {
+			t.Errorf("#%d: Content-Type got %q want %q", i, g, w)
+		}
+		if !bytes.Equal(blob, pngMagic) {
+			t.Errorf("#%d: got image bytes %q want %q", i, blob, pngMagic)
+		}
+	}
+}
+
+func TestStaticImageValidation(t *testing.T) {
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			t.Errorf("unexpected request to %q", req.URL)
+			return makeResp("Bad Request", http.StatusBadRequest, http.NoBody), nil
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	center := &mapbox.LatLonPair{-77.0366, 38.8971}
+	tests := []*mapbox.StaticImageRequest{
+		0: nil,
+		1: {Center: center, Width: 1281, Height: 400},
+		2: {Center: center, Width: 400, Height: 1281},
+		3: {Center: center, Width: 0, Height: 400},
+		4: {Width: 400, Height: 400},
+		5: {Auto: true, Width: 400, Height: 400},
+		6: {Center: center, Zoom: 23, Width: 400, Height: 400},
+		7: {Auto: true, Width: 400, Height: 400, Shapes: []mapbox.StaticOverlay{&mapbox.PathOverlay{StrokeWidth: 5}}},
+	}
+
+	for i, sreq := range tests {
+		if _, err := client.StaticImage(context.Background(), sreq); err == nil {
+			t.Errorf("#%d: want non-nil error", i)
+		}
+	}
+}
+
+func TestStaticImageShapes(t *testing.T) {
+	pin := &mapbox.Marker{Lon: -122.4194, Lat: 37.7749, Label: "A", Color: "#ff0000", Size: "l"}
+	path := &mapbox.PathOverlay{
+		Polyline:    "asseFvvajVkFuGcAsAmF{G",
+		StrokeWidth: 5,
+		StrokeColor: "f44",
+		FillColor:   "f448",
+	}
+
+	tests := []struct {
+		shapes          []mapbox.StaticOverlay
+		overlays        []string
+		opts            []mapbox.Option
+		wantEscapedPath string
+	}{
+		0: {
+			shapes:          []mapbox.StaticOverlay{pin},
+			wantEscapedPath: "/styles/v1/mapbox/streets-v11/static/pin-l-a+ff0000(-122.4194,37.7749)/auto/600x400",
+		},
+		1: {
+			shapes: []mapbox.StaticOverlay{path, pin},
+			wantEscapedPath: "/styles/v1/mapbox/streets-v11/static/" +
+				"path-5+f44+f448(asseFvvajVkFuGcAsAmF%7BG),pin-l-a+ff0000(-122.4194,37.7749)/auto/600x400",
+		},
+		// Raw overlays come first, then the shapes in order.
+		2: {
+			overlays: []string{"pin-s+555555(-77.0366,38.8971)"},
+			shapes:   []mapbox.StaticOverlay{&mapbox.Marker{Lon: -77.03, Lat: 38.89}},
+			wantEscapedPath: "/styles/v1/mapbox/streets-v11/static/" +
+				"pin-s+555555(-77.0366,38.8971),pin-s(-77.03,38.89)/auto/600x400",
+		},
+		// Markers use the client's coordinate precision.
+		3: {
+			shapes:          []mapbox.StaticOverlay{&mapbox.Marker{Lon: -122.419416, Lat: 37.774929}},
+			opts:            []mapbox.Option{mapbox.WithCoordinatePrecision(3)},
+			wantEscapedPath: "/styles/v1/mapbox/streets-v11/static/pin-s(-122.419,37.775)/auto/600x400",
+		},
+	}
+
+	for i, tt := range tests {
+		var gotPath string
+		client, err := mapbox.NewClient(append(tt.opts, mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				gotPath = req.URL.EscapedPath()
+				return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(bytes.NewReader(pngMagic))), nil
+			}),
+		}))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		img, err := client.StaticImage(context.Background(), &mapbox.StaticImageRequest{
+			Auto:     true,
+			Width:    600,
+			Height:   400,
+			Overlays: tt.overlays,
+			Shapes:   tt.shapes,
+		})
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		_ = img.Close()
+
+		if gotPath != tt.wantEscapedPath {
+			t.Errorf("#%d: path\ngot:  %q\nwant: %q", i, gotPath, tt.wantEscapedPath)
+		}
+	}
+}
+
+func TestStaticImageAsReadCloser(t *testing.T) {
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(bytes.NewReader(pngMagic))), nil
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Callers that only want the stream can keep it as an io.ReadCloser.
+	var rc io.ReadCloser
+	rc, err = client.StaticImage(context.Background(), &mapbox.StaticImageRequest{
+		Center: &mapbox.LatLonPair{-77.0366, 38.8971},
+		Zoom:   14,
+		Width:  64,
+		Height: 64,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	blob, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(blob, pngMagic) {
+		t.Errorf("got image bytes %q want %q", blob, pngMagic)
+	}
+}
@@ -0,0 +1,106 @@
+package mapbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+var errStyleID = errors.New("mapbox: expecting a non-empty username and style ID")
+
+// GetStyle returns the raw Mapbox GL style document, to be
+// passed as is to a renderer. An unknown style returns an error
+// matching ErrNotFound, and a private style that the access
+// token can't read returns one matching ErrUnauthorized.
+// Request format:
+// GET /styles/v1/{username}/{style_id}
+func (c *Client) GetStyle(ctx context.Context, username, styleID string) (json.RawMessage, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).GetStyle")
+	defer span.End()
+
+	if username == "" || styleID == "" {
+		span.Annotate(nil, "Invalid request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: errStyleID.Error()})
+		return nil, errStyleID
+	}
+
+	values := make(url.Values)
+	values.Add("access_token", c.apiKeyFor(ctx))
+	outURL := fmt.Sprintf("%s/styles/v1/%s/%s?%s",
+		c._baseURL(), url.PathEscape(username), url.PathEscape(styleID), values.Encode())
+
+	var style json.RawMessage
+	if err := c.doRequest(ctx, span, "GET", outURL, nil, &style); err != nil {
+		return nil, err
+	}
+	span.SetStatus(trace.Status{Code: trace.StatusCodeOK})
+	return style, nil
+}
+
+// StyleMeta describes a style without its layers and sources.
+type StyleMeta struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Owner    string    `json:"owner"`
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+}
+
+type ListStylesOptions struct {
+	// Start if set is the token returned
+	// by ListStyles for the next page.
+	Start string `json:"start,omitempty"`
+
+	// Limit if set is the maximum
+	// number of styles per page.
+	Limit uint `json:"limit,omitempty"`
+}
+
+var errNoUsername = errors.New("mapbox: expecting a non-empty username")
+
+// ListStyles returns a page of the account's styles, along with
+// the Start token for the next page, which is empty for the last page.
+// Request format:
+// GET /styles/v1/{username}
+func (c *Client) ListStyles(ctx context.Context, username string, opts *ListStylesOptions) ([]StyleMeta, string, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).ListStyles")
+	defer span.End()
+
+	if username == "" {
+		span.Annotate(nil, "Invalid request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: errNoUsername.Error()})
+		return nil, "", errNoUsername
+	}
+
+	values := make(url.Values)
+	if opts != nil {
+		if opts.Start != "" {
+			values.Add("start", opts.Start)
+		}
+		if opts.Limit > 0 {
+			values.Add("limit", strconv.FormatUint(uint64(opts.Limit), 10))
+		}
+	}
+	values.Add("access_token", c.apiKeyFor(ctx))
+	outURL := fmt.Sprintf("%s/styles/v1/%s?%s", c._baseURL(), url.PathEscape(username), values.Encode())
+
+	res, err := c.doHTTPRequest(ctx, span, "GET", outURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer drainAndClose(res.Body)
+
+	var styles []StyleMeta
+	if err := decodeResponse(span, res, res.Body, &styles); err != nil {
+		return nil, "", err
+	}
+	span.SetStatus(trace.Status{Code: trace.StatusCodeOK})
+	next, _ := parseNextLink(res.Header)
+	return styles, next, nil
+}
@@ -0,0 +1,195 @@
+package mapbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/orijtech/mapbox"
+)
+
+func TestGetStyle(t *testing.T) {
+	var gotPath, gotToken string
+	client, err := mapbox.NewClient(
+		mapbox.WithAPIKey("test-key"),
+		mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				gotPath = req.URL.Path
+				gotToken = req.URL.Query().Get("access_token")
+				return respFromFileContents("./testdata/style-streets.json")
+			}),
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	style, err := client.GetStyle(context.Background(), "mapbox", "streets-v11")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, w := gotPath, "/styles/v1/mapbox/streets-v11"; g != w {
+		t.Errorf("path got %q want %q", g, w)
+	}
+	if g, w := gotToken, "test-key"; g != w {
+		t.Errorf("access_token got %q want %q", g, w)
+	}
+
+	want, err := ioutil.ReadFile("./testdata/style-streets.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, w := strings.TrimSpace(string(style)), strings.TrimSpace(string(want)); g != w {
+		t.Errorf("the style was not returned as is\ngot:  %s\nwant: %s", g, w)
+	}
+
+	recv := new(struct {
+		Version int               `json:"version"`
+		Layers  []json.RawMessage `json:"layers"`
+	})
+	if err := json.Unmarshal(style, recv); err != nil {
+		t.Fatal(err)
+	}
+	if recv.Version != 8 || len(recv.Layers) != 2 {
+		t.Errorf("got version %d with %d layers, want version 8 with 2 layers", recv.Version, len(recv.Layers))
+	}
+}
+
+func TestGetStyleErrors(t *testing.T) {
+	tests := []struct {
+		username, styleID string
+		code              int
+		body              string
+		wantErr           error
+	}{
+		0: {
+			username: "mapbox", styleID: "no-such-style",
+			code: http.StatusNotFound, body: `{"message": "Style not found"}`,
+			wantErr: mapbox.ErrNotFound,
+		},
+		1: {
+			username: "someone-else", styleID: "private-style",
+			code: http.StatusUnauthorized, body: `{"message": "Not Authorized - Invalid Token"}`,
+			wantErr: mapbox.ErrUnauthorized,
+		},
+		2: {username: "", styleID: "streets-v11"},
+		3: {username: "mapbox", styleID: ""},
+	}
+
+	for i, tt := range tests {
+		requests := 0
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				requests++
+				body := ioutil.NopCloser(strings.NewReader(tt.body))
+				return makeResp(http.StatusText(tt.code), tt.code, body), nil
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		style, err := client.GetStyle(context.Background(), tt.username, tt.styleID)
+		if err == nil {
+			t.Errorf("#%d: want non-nil error", i)
+			continue
+		}
+		if style != nil {
+			t.Errorf("#%d: got a style along with the error", i)
+		}
+		if tt.wantErr == nil {
+			if requests != 0 {
+				t.Errorf("#%d: made %d requests for an invalid style", i, requests)
+			}
+			continue
+		}
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("#%d: got err %v want one matching %v", i, err, tt.wantErr)
+		}
+		for _, other := range []error{mapbox.ErrNotFound, mapbox.ErrUnauthorized} {
+			if other != tt.wantErr && errors.Is(err, other) {
+				t.Errorf("#%d: err %v unexpectedly matches %v", i, err, other)
+			}
+		}
+	}
+}
+
+func TestListStylesPagination(t *testing.T) {
+	var gotQueries []url.Values
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			if g, w := req.URL.Path, "/styles/v1/orijtech"; g != w {
+				return makeResp("404 Not Found", http.StatusNotFound, http.NoBody), nil
+			}
+			query := req.URL.Query()
+			gotQueries = append(gotQueries, query)
+			if query.Get("start") == "" {
+				res, err := respFromFileContents("./testdata/styles-page1.json")
+				if err == nil {
+					res.Header.Set("Link", `<https://api.mapbox.com/styles/v1/orijtech?limit=2&start=cjdelivery00002>; rel="next"`)
+				}
+				return res, err
+			}
+			return respFromFileContents("./testdata/styles-page2.json")
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var styles []mapbox.StyleMeta
+	opts := &mapbox.ListStylesOptions{Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > 2 {
+			t.Fatal("pagination did not terminate")
+		}
+		page, next, err := client.ListStyles(context.Background(), "orijtech", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		styles = append(styles, page...)
+		if next == "" {
+			break
+		}
+		opts.Start = next
+	}
+
+	wantStarts := []string{"", "cjdelivery00002"}
+	if g, w := len(gotQueries), len(wantStarts); g != w {
+		t.Fatalf("got %d requests want %d", g, w)
+	}
+	for i, query := range gotQueries {
+		if g, w := query.Get("start"), wantStarts[i]; g != w {
+			t.Errorf("request #%d: start got %q want %q", i, g, w)
+		}
+		if g, w := query.Get("limit"), "2"; g != w {
+			t.Errorf("request #%d: limit got %q want %q", i, g, w)
+		}
+	}
+
+	var ids []string
+	for _, style := range styles {
+		ids = append(ids, style.ID)
+	}
+	if w := []string{"cjstores0000001", "cjdelivery00002", "cjnight00000003"}; !reflect.DeepEqual(ids, w) {
+		t.Errorf("ids got %q want %q", ids, w)
+	}
+	want := mapbox.StyleMeta{
+		ID:       "cjstores0000001",
+		Name:     "Stores",
+		Owner:    "orijtech",
+		Created:  time.Date(2019, 3, 1, 10, 0, 0, 0, time.UTC),
+		Modified: time.Date(2019, 3, 5, 12, 30, 0, 0, time.UTC),
+	}
+	if g := styles[0]; g.ID != want.ID || g.Name != want.Name || g.Owner != want.Owner ||
+		!g.Created.Equal(want.Created) || !g.Modified.Equal(want.Modified) {
+		t.Errorf("first style\ngot:  %+v\nwant: %+v", g, want)
+	}
+}
@@ -0,0 +1,185 @@
+package mapbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	TilequeryPoint      = "point"
+	TilequeryLineString = "linestring"
+	TilequeryPolygon    = "polygon"
+)
+
+const (
+	defaultTilesetID = "mapbox.mapbox-streets-v8"
+
+	maxTilequeryLimit = 50
+)
+
+type TilequeryRequest struct {
+	// TilesetID if unset defaults to "mapbox.mapbox-streets-v8".
+	TilesetID string `json:"tileset_id,omitempty"`
+
+	// TilesetIDs if set instead of TilesetID are several
+	// tilesets, such as custom ones, to query together.
+	TilesetIDs []string `json:"tileset_ids,omitempty"`
+
+	// Dedupe if explicitly set to false keeps the duplicate
+	// features that Mapbox removes by default, hence it
+	// being a pointer.
+	Dedupe *bool `json:"dedupe,omitempty"`
+
+	// Point is the [lon, lat] pair to query.
+	Point *LatLonPair `json:"point"`
+
+	// Radius is in meters, with 0 only returning
+	// the features that contain Point.
+	Radius uint `json:"radius,omitempty"`
+
+	// Limit is the maximum number of results in the range [1, 50].
+	// If unset, Mapbox returns 5 results.
+	Limit uint `json:"limit,omitempty"`
+
+	// Geometry if set is one of TilequeryPoint,
+	// TilequeryLineString or TilequeryPolygon.
+	Geometry string `json:"geometry,omitempty"`
+
+	// Layers if set are the only layers queried.
+	Layers []string `json:"layers,omitempty"`
+}
+
+var (
+	errNilTilequeryRequest = errors.New("mapbox: expecting a non-nil TilequeryRequest")
+	errTilequeryPoint      = errors.New("mapbox: expecting Point as a [lon, lat] pair")
+	errTilequeryLimit      = fmt.Errorf("mapbox: limit must be at most %d", maxTilequeryLimit)
+	errTilequeryGeometry   = errors.New(`mapbox: geometry must be one of "point", "linestring" or "polygon"`)
+	errTilesetIDs          = errors.New("mapbox: expecting either TilesetID or TilesetIDs, not both")
+)
+
+func (treq *TilequeryRequest) validate() error {
+	if treq == nil {
+		return errNilTilequeryRequest
+	}
+	if treq.Point == nil || len(*treq.Point) != 2 {
+		return errTilequeryPoint
+	}
+	if treq.Limit > maxTilequeryLimit {
+		return errTilequeryLimit
+	}
+	switch treq.Geometry {
+	case "", TilequeryPoint, TilequeryLineString, TilequeryPolygon:
+	default:
+		return errTilequeryGeometry
+	}
+	if treq.TilesetID != "" && len(treq.TilesetIDs) > 0 {
+		return errTilesetIDs
+	}
+	for i, id := range treq.TilesetIDs {
+		if id == "" || strings.Contains(id, ",") {
+			return fmt.Errorf("mapbox: tileset id #%d %q is not a single tileset", i, id)
+		}
+	}
+	return nil
+}
+
+// tilesetPath returns the tilesets queried,
+// path escaped and comma joined.
+func (treq *TilequeryRequest) tilesetPath() string {
+	ids := treq.TilesetIDs
+	if len(ids) == 0 {
+		ids = []string{treq.TilesetID}
+		if treq.TilesetID == "" {
+			ids = []string{defaultTilesetID}
+		}
+	}
+	escaped := make([]string, len(ids))
+	for i, id := range ids {
+		escaped[i] = url.PathEscape(id)
+	}
+	return strings.Join(escaped, ",")
+}
+
+// TilequeryMatch is the "tilequery" property of each
+// of a Tilequery's features, for how it matched.
+type TilequeryMatch struct {
+	// Distance is in meters from the queried point,
+	// with 0 for the features that contain it.
+	Distance float64
+
+	// Geometry is one of TilequeryPoint,
+	// TilequeryLineString or TilequeryPolygon.
+	Geometry string
+
+	Layer string
+
+	// Tileset is the id of the tileset that the feature
+	// came from, set when several tilesets were queried.
+	Tileset string
+}
+
+// Tilequery returns the feature's "tilequery" property,
+// and false if it isn't a feature from a Tilequery.
+func (gp *GeocodeProperty) Tilequery() (*TilequeryMatch, bool) {
+	if gp == nil {
+		return nil, false
+	}
+	props, ok := (*gp)["tilequery"].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	tm := new(TilequeryMatch)
+	tm.Distance, _ = props["distance"].(float64)
+	tm.Geometry, _ = props["geometry"].(string)
+	tm.Layer, _ = props["layer"].(string)
+	tm.Tileset, _ = props["tileset"].(string)
+	return tm, true
+}
+
+// Tilequery returns the features of one or more tilesets at or
+// within a radius of a point, with the distance from the point
+// in each feature's "tilequery" property, see GeocodeProperty.Tilequery.
+// Request format:
+// GET /v4/{tileset_id},.../tilequery/{lon},{lat}.json
+func (c *Client) Tilequery(ctx context.Context, treq *TilequeryRequest) (*GeocodeResponse, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).Tilequery")
+	defer span.End()
+
+	if err := treq.validate(); err != nil {
+		span.Annotate(nil, "Invalid request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
+		return nil, err
+	}
+
+	values := make(url.Values)
+	if treq.Radius > 0 {
+		values.Add("radius", fmt.Sprintf("%d", treq.Radius))
+	}
+	if treq.Limit > 0 {
+		values.Add("limit", fmt.Sprintf("%d", treq.Limit))
+	}
+	if treq.Geometry != "" {
+		values.Add("geometry", treq.Geometry)
+	}
+	if len(treq.Layers) > 0 {
+		values.Add("layers", strings.Join(treq.Layers, ","))
+	}
+	if treq.Dedupe != nil {
+		values.Add("dedupe", fmt.Sprintf("%t", *treq.Dedupe))
+	}
+	values.Add("access_token", c.apiKeyFor(ctx))
+
+	outURL := fmt.Sprintf("%s/v4/%s/tilequery/%s.json?%s",
+		c._baseURL(), treq.tilesetPath(), c.formatPair(*treq.Point), values.Encode())
+
+	gres := new(GeocodeResponse)
+	if err := c.doRequest(ctx, span, "GET", outURL, nil, gres); err != nil {
+		return nil, err
+	}
+	return gres, nil
+}
@@ -0,0 +1,143 @@
+package mapbox_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/orijtech/mapbox"
+)
+
+func TestTilequery(t *testing.T) {
+	var gotPath string
+	var gotQuery url.Values
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			gotPath = req.URL.Path
+			gotQuery = req.URL.Query()
+			return respFromFileContents("./testdata/tilequery-DC.json")
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gres, err := client.Tilequery(context.Background(), &mapbox.TilequeryRequest{
+		Point:  &mapbox.LatLonPair{-77.0366, 38.8971},
+		Radius: 100,
+		Limit:  2,
+		Layers: []string{"landuse", "poi_label"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g, w := gotPath, "/v4/mapbox.mapbox-streets-v8/tilequery/-77.0366,38.8971.json"; g != w {
+		t.Errorf("path\ngot:  %q\nwant: %q", g, w)
+	}
+	gotQuery.Del("access_token")
+	wantQuery := url.Values{
+		"radius": {"100"},
+		"limit":  {"2"},
+		"layers": {"landuse,poi_label"},
+	}
+	if !reflect.DeepEqual(gotQuery, wantQuery) {
+		t.Errorf("query\ngot:  %v\nwant: %v", gotQuery, wantQuery)
+	}
+
+	if g, w := len(gres.Features), 2; g != w {
+		t.Fatalf("got %d features want %d", g, w)
+	}
+	feat := gres.Features[1]
+	if g, w := feat.Id, "3030001"; g != w {
+		t.Errorf("Id: got %q want %q", g, w)
+	}
+	if g, w := (*feat.Properties)["name"], "The White House"; g != w {
+		t.Errorf("name: got %v want %q", g, w)
+	}
+	if g, w := feat.Geometry.Coordinates, []float32{-77.0359, 38.8977}; !reflect.DeepEqual(g, w) {
+		t.Errorf("coordinates: got %v want %v", g, w)
+	}
+}
+
+func TestTilequeryValidation(t *testing.T) {
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			t.Errorf("unexpected request to %q", req.URL)
+			return makeResp("Bad Request", http.StatusBadRequest, http.NoBody), nil
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	point := &mapbox.LatLonPair{-77.0366, 38.8971}
+	tests := []*mapbox.TilequeryRequest{
+		0: nil,
+		1: {},
+		2: {Point: point, Limit: 51},
+		3: {Point: point, Geometry: "multipolygon"},
+		4: {Point: point, TilesetID: "mapbox.mapbox-streets-v8", TilesetIDs: []string{"orijtech.stores-dc"}},
+		5: {Point: point, TilesetIDs: []string{"mapbox.mapbox-streets-v8", ""}},
+		6: {Point: point, TilesetIDs: []string{"mapbox.mapbox-streets-v8,orijtech.stores-dc"}},
+	}
+
+	for i, treq := range tests {
+		if _, err := client.Tilequery(context.Background(), treq); err == nil {
+			t.Errorf("#%d: want non-nil error", i)
+		}
+	}
+}
+
+func TestTilequeryTilesets(t *testing.T) {
+	var gotPath string
+	var gotQuery url.Values
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			gotPath = req.URL.Path
+			gotQuery = req.URL.Query()
+			return respFromFileContents("./testdata/tilequery-combined.json")
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dedupe := false
+	gres, err := client.Tilequery(context.Background(), &mapbox.TilequeryRequest{
+		TilesetIDs: []string{"mapbox.mapbox-streets-v8", "orijtech.stores-dc"},
+		Point:      &mapbox.LatLonPair{-77.0366, 38.8971},
+		Radius:     100,
+		Dedupe:     &dedupe,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g, w := gotPath, "/v4/mapbox.mapbox-streets-v8,orijtech.stores-dc/tilequery/-77.0366,38.8971.json"; g != w {
+		t.Errorf("path\ngot:  %q\nwant: %q", g, w)
+	}
+	if g, w := gotQuery.Get("dedupe"), "false"; g != w {
+		t.Errorf("dedupe got %q want %q", g, w)
+	}
+
+	want := []*mapbox.TilequeryMatch{
+		{Distance: 91.3, Geometry: "point", Layer: "poi_label", Tileset: "mapbox.mapbox-streets-v8"},
+		{Distance: 12.5, Geometry: "point", Layer: "stores", Tileset: "orijtech.stores-dc"},
+	}
+	if g, w := len(gres.Features), len(want); g != w {
+		t.Fatalf("got %d features want %d", g, w)
+	}
+	for i, feat := range gres.Features {
+		got, ok := feat.Properties.Tilequery()
+		if !ok {
+			t.Errorf("#%d: no tilequery property", i)
+			continue
+		}
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("#%d: got %+v want %+v", i, got, want[i])
+		}
+	}
+}
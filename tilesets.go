@@ -0,0 +1,219 @@
+package mapbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	TilesetVector = "vector"
+	TilesetRaster = "raster"
+
+	VisibilityPublic  = "public"
+	VisibilityPrivate = "private"
+
+	SortByCreated  = "created"
+	SortByModified = "modified"
+
+	maxTilesetsLimit = 500
+)
+
+// Tileset is an entry of the account's tilesets.
+type Tileset struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Visibility  string `json:"visibility"`
+
+	// Center is the [lon, lat, zoom] to initially show.
+	Center []float32 `json:"center,omitempty"`
+
+	// Filesize is in bytes.
+	Filesize int64 `json:"filesize"`
+
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+}
+
+// TilesetMeta is the TileJSON metadata of a tileset.
+type TilesetMeta struct {
+	ID          string `json:"id"`
+	Type        string `json:"type,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// Center is the [lon, lat, zoom] to initially show.
+	Center []float32 `json:"center,omitempty"`
+
+	// Bounds is the [minLon, minLat, maxLon, maxLat]
+	// box that the tileset's data covers.
+	Bounds []float32 `json:"bounds,omitempty"`
+
+	MinZoom int `json:"minzoom"`
+	MaxZoom int `json:"maxzoom"`
+
+	// Filesize is in bytes.
+	Filesize int64 `json:"filesize,omitempty"`
+}
+
+type TilesetOptions struct {
+	// Type if set is one of TilesetVector or TilesetRaster.
+	Type string `json:"type,omitempty"`
+
+	// Visibility if set is one of VisibilityPublic or VisibilityPrivate.
+	Visibility string `json:"visibility,omitempty"`
+
+	// SortBy if set is one of SortByCreated or SortByModified,
+	// in which case the newest tilesets are first.
+	SortBy string `json:"sortby,omitempty"`
+
+	// Limit if set is the number of tilesets per page, in the
+	// range [1, 500], with all the pages being returned.
+	Limit uint `json:"limit,omitempty"`
+}
+
+var errTilesetID = errors.New("mapbox: expecting a non-empty tileset ID")
+
+func (topts *TilesetOptions) validate() error {
+	if topts == nil {
+		return nil
+	}
+	switch topts.Type {
+	case "", TilesetVector, TilesetRaster:
+	default:
+		return fmt.Errorf("mapbox: unknown tileset type %q", topts.Type)
+	}
+	switch topts.Visibility {
+	case "", VisibilityPublic, VisibilityPrivate:
+	default:
+		return fmt.Errorf("mapbox: unknown visibility %q", topts.Visibility)
+	}
+	switch topts.SortBy {
+	case "", SortByCreated, SortByModified:
+	default:
+		return fmt.Errorf("mapbox: unknown sortby %q", topts.SortBy)
+	}
+	if topts.Limit > maxTilesetsLimit {
+		return fmt.Errorf("mapbox: limit must be in the range [1, %d]", maxTilesetsLimit)
+	}
+	return nil
+}
+
+// ListTilesets returns all the account's tilesets, following
+// the Link header through each of the pages.
+// Request format:
+// GET /tilesets/v1/{username}
+func (c *Client) ListTilesets(ctx context.Context, username string, topts *TilesetOptions) ([]Tileset, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).ListTilesets")
+	defer span.End()
+
+	err := topts.validate()
+	if err == nil && username == "" {
+		err = errNoUsername
+	}
+	if err != nil {
+		span.Annotate(nil, "Invalid request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
+		return nil, err
+	}
+
+	values := make(url.Values)
+	if topts != nil {
+		if topts.Type != "" {
+			values.Add("type", topts.Type)
+		}
+		if topts.Visibility != "" {
+			values.Add("visibility", topts.Visibility)
+		}
+		if topts.SortBy != "" {
+			values.Add("sortby", topts.SortBy)
+		}
+		if topts.Limit > 0 {
+			values.Add("limit", strconv.FormatUint(uint64(topts.Limit), 10))
+		}
+	}
+	values.Add("access_token", c.apiKeyFor(ctx))
+
+	var tilesets []Tileset
+	var lastToken string
+	for page := 0; ; page++ {
+		span.Annotate([]trace.Attribute{
+			trace.Int64Attribute("page", int64(page)),
+		}, "Requesting tilesets")
+
+		outURL := fmt.Sprintf("%s/tilesets/v1/%s?%s", c._baseURL(), url.PathEscape(username), values.Encode())
+		next, err := c.listTilesetsPage(ctx, span, outURL, &tilesets)
+		if err != nil {
+			return nil, err
+		}
+		// Stop on the last page, or if the next page is
+		// unknown or the same one again, which would loop.
+		token := next.Encode()
+		if token == "" || token == lastToken {
+			break
+		}
+		lastToken = token
+		for _, key := range pageParams {
+			if v := next.Get(key); v != "" {
+				values.Set(key, v)
+			} else {
+				values.Del(key)
+			}
+		}
+	}
+	span.SetStatus(trace.Status{Code: trace.StatusCodeOK})
+	return tilesets, nil
+}
+
+// listTilesetsPage appends the page of tilesets at outURL to
+// tilesets, returning the page parameters of the next page,
+// which are nil on the last page.
+func (c *Client) listTilesetsPage(ctx context.Context, span *trace.Span, outURL string, tilesets *[]Tileset) (url.Values, error) {
+	res, err := c.doHTTPRequest(ctx, span, "GET", outURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer drainAndClose(res.Body)
+
+	var page []Tileset
+	if err := decodeResponse(span, res, res.Body, &page); err != nil {
+		return nil, err
+	}
+	*tilesets = append(*tilesets, page...)
+	next, _ := nextPage(res.Header)
+	return next, nil
+}
+
+// TilesetMetadata returns the TileJSON metadata of the tileset
+// whose id is of the form "{username}.{tileset_name}". An unknown
+// tileset returns an error matching ErrNotFound.
+// Request format:
+// GET /v4/{tileset_id}.json
+func (c *Client) TilesetMetadata(ctx context.Context, id string) (*TilesetMeta, error) {
+	ctx, span := c.startSpan(ctx, "mapbox.(*Client).TilesetMetadata")
+	defer span.End()
+
+	if id == "" {
+		span.Annotate(nil, "Invalid request")
+		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: errTilesetID.Error()})
+		return nil, errTilesetID
+	}
+
+	values := make(url.Values)
+	values.Add("access_token", c.apiKeyFor(ctx))
+	outURL := fmt.Sprintf("%s/v4/%s.json?%s", c._baseURL(), url.PathEscape(id), values.Encode())
+
+	meta := new(TilesetMeta)
+	if err := c.doRequest(ctx, span, "GET", outURL, nil, meta); err != nil {
+		return nil, err
+	}
+	span.SetStatus(trace.Status{Code: trace.StatusCodeOK})
+	return meta, nil
+}
@@ -0,0 +1,226 @@
+package mapbox_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/orijtech/mapbox"
+)
+
+func TestListTilesets(t *testing.T) {
+	var gotQueries []url.Values
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			if g, w := req.URL.Path, "/tilesets/v1/orijtech"; g != w {
+				return makeResp("404 Not Found", http.StatusNotFound, http.NoBody), nil
+			}
+			query := req.URL.Query()
+			gotQueries = append(gotQueries, query)
+			if query.Get("start") == "" {
+				res, err := respFromFileContents("./testdata/tilesets-page1.json")
+				if err == nil {
+					res.Header.Set("Link", `<https://api.mapbox.com/tilesets/v1/orijtech?limit=1&start=orijtech.stores>; rel="next"`)
+				}
+				return res, err
+			}
+			return respFromFileContents("./testdata/tilesets-page2.json")
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tilesets, err := client.ListTilesets(context.Background(), "orijtech", &mapbox.TilesetOptions{
+		Visibility: mapbox.VisibilityPrivate,
+		SortBy:     mapbox.SortByModified,
+		Limit:      1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g, w := len(gotQueries), 2; g != w {
+		t.Fatalf("got %d requests want %d", g, w)
+	}
+	for i, wantStart := range []string{"", "orijtech.stores"} {
+		query := gotQueries[i]
+		if g := query.Get("start"); g != wantStart {
+			t.Errorf("request #%d: start got %q want %q", i, g, wantStart)
+		}
+		if query.Get("visibility") != "private" || query.Get("sortby") != "modified" || query.Get("limit") != "1" {
+			t.Errorf("request #%d: missing filters in %v", i, query)
+		}
+	}
+
+	want := []mapbox.Tileset{
+		{
+			ID:          "orijtech.stores",
+			Type:        mapbox.TilesetVector,
+			Name:        "stores",
+			Description: "Store locations",
+			Visibility:  mapbox.VisibilityPrivate,
+			Center:      []float32{-77.0369, 38.9072, 9},
+			Filesize:    204800,
+			Created:     time.Date(2019, 6, 1, 15, 0, 0, 0, time.UTC),
+			Modified:    time.Date(2019, 6, 3, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			ID:         "orijtech.sf-imagery",
+			Type:       mapbox.TilesetRaster,
+			Name:       "sf-imagery",
+			Visibility: mapbox.VisibilityPublic,
+			Center:     []float32{-122.4194, 37.7749, 12},
+			Filesize:   10485760,
+			Created:    time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC),
+			Modified:   time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC),
+		},
+	}
+	if !reflect.DeepEqual(tilesets, want) {
+		t.Errorf("tilesets\ngot:  %+v\nwant: %+v", tilesets, want)
+	}
+}
+
+func TestListTilesetsErrors(t *testing.T) {
+	tests := []struct {
+		username string
+		opts     *mapbox.TilesetOptions
+	}{
+		0: {username: ""},
+		1: {username: "orijtech", opts: &mapbox.TilesetOptions{Type: "mbtiles"}},
+		2: {username: "orijtech", opts: &mapbox.TilesetOptions{Visibility: "secret"}},
+		3: {username: "orijtech", opts: &mapbox.TilesetOptions{SortBy: "name"}},
+		4: {username: "orijtech", opts: &mapbox.TilesetOptions{Limit: 501}},
+	}
+
+	for i, tt := range tests {
+		requests := 0
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				requests++
+				return respFromFileContents("./testdata/tilesets-page2.json")
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.ListTilesets(context.Background(), tt.username, tt.opts); err == nil {
+			t.Errorf("#%d: want non-nil error", i)
+		}
+		if requests != 0 {
+			t.Errorf("#%d: made %d requests for an invalid listing", i, requests)
+		}
+	}
+}
+
+func TestTilesetMetadata(t *testing.T) {
+	var gotPath string
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			gotPath = req.URL.Path
+			if gotPath != "/v4/orijtech.stores.json" {
+				return makeResp("404 Not Found", http.StatusNotFound, http.NoBody), nil
+			}
+			return respFromFileContents("./testdata/tileset-metadata.json")
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := client.TilesetMetadata(context.Background(), "orijtech.stores")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &mapbox.TilesetMeta{
+		ID:          "orijtech.stores",
+		Type:        mapbox.TilesetVector,
+		Name:        "stores",
+		Description: "Store locations",
+		Center:      []float32{-77.0369, 38.9072, 9},
+		Bounds:      []float32{-77.1198, 38.7916, -76.9094, 38.9955},
+		MinZoom:     0,
+		MaxZoom:     14,
+		Filesize:    204800,
+	}
+	if !reflect.DeepEqual(meta, want) {
+		t.Errorf("metadata\ngot:  %+v\nwant: %+v", meta, want)
+	}
+
+	if _, err := client.TilesetMetadata(context.Background(), "orijtech.missing"); !errors.Is(err, mapbox.ErrNotFound) {
+		t.Errorf("unknown tileset: got err %v want one matching %v", err, mapbox.ErrNotFound)
+	}
+	if _, err := client.TilesetMetadata(context.Background(), ""); err == nil {
+		t.Errorf("empty id: want non-nil error")
+	}
+}
+
+func TestListTilesetsPaging(t *testing.T) {
+	tests := []struct {
+		links     []string
+		wantPages []string
+	}{
+		// A page parameter is sent as page, not as start.
+		0: {
+			links:     []string{`<https://api.mapbox.com/tilesets/v1/orijtech?page=2>; rel="next"`, ""},
+			wantPages: []string{"", "page=2"},
+		},
+		1: {
+			links:     []string{`<https://api.mapbox.com/tilesets/v1/orijtech?start=b>; rel="next"`, ""},
+			wantPages: []string{"", "start=b"},
+		},
+		// The same next link again stops instead of looping.
+		2: {
+			links: []string{
+				`<https://api.mapbox.com/tilesets/v1/orijtech?start=b>; rel="next"`,
+				`<https://api.mapbox.com/tilesets/v1/orijtech?start=b>; rel="next"`,
+				`<https://api.mapbox.com/tilesets/v1/orijtech?start=c>; rel="next"`,
+			},
+			wantPages: []string{"", "start=b"},
+		},
+		// A next link without a page token can't be followed.
+		3: {
+			links:     []string{`<https://api.mapbox.com/tilesets/v1/orijtech>; rel="next"`},
+			wantPages: []string{""},
+		},
+	}
+
+	for i, tt := range tests {
+		var gotPages []string
+		client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+			Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+				query := req.URL.Query()
+				page := make(url.Values)
+				for _, key := range []string{"start", "page"} {
+					if v := query.Get(key); v != "" {
+						page.Set(key, v)
+					}
+				}
+				gotPages = append(gotPages, page.Encode())
+				if len(gotPages) > len(tt.links) {
+					t.Fatalf("#%d: made %d requests", i, len(gotPages))
+				}
+				res, err := respFromFileContents("./testdata/tilesets-page2.json")
+				if err == nil && tt.links[len(gotPages)-1] != "" {
+					res.Header.Set("Link", tt.links[len(gotPages)-1])
+				}
+				return res, err
+			}),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := client.ListTilesets(context.Background(), "orijtech", nil); err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(gotPages, tt.wantPages) {
+			t.Errorf("#%d: pages got %q want %q", i, gotPages, tt.wantPages)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package mapbox
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TypeaheadSession geocodes a query as it is typed, one keystroke
+// at a time, only sending the latest query once typing pauses for
+// its debounce interval and cancelling any earlier one in flight.
+// It is safe for concurrent use.
+type TypeaheadSession struct {
+	client   *Client
+	debounce time.Duration
+	request  GeocodeRequest
+
+	mu     sync.Mutex
+	seq    uint64
+	cancel context.CancelFunc
+}
+
+// ErrSuperseded is returned by TypeaheadSession.Search when
+// a later search replaced it before it could complete.
+var ErrSuperseded = errors.New("mapbox: superseded by a later search")
+
+// NewTypeaheadSession returns a session that waits for debounce
+// after each Search before geocoding. greq if set is used for every
+// query, with AutoComplete always enabled.
+func (c *Client) NewTypeaheadSession(debounce time.Duration, greq *GeocodeRequest) *TypeaheadSession {
+	ts := &TypeaheadSession{client: c, debounce: debounce}
+	if greq != nil {
+		ts.request = *greq
+	}
+	ts.request.AutoComplete = true
+	return ts
+}
+
+// Search forward geocodes query unless another Search is made
+// before the debounce interval elapses or before the response
+// arrives, in which case it returns ErrSuperseded. An empty query
+// only cancels any pending search and returns no results.
+func (ts *TypeaheadSession) Search(ctx context.Context, query string) (*GeocodeResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ts.mu.Lock()
+	if ts.cancel != nil {
+		ts.cancel()
+	}
+	ts.seq++
+	seq := ts.seq
+	ts.cancel = cancel
+	ts.mu.Unlock()
+
+	if strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+
+	timer := time.NewTimer(ts.debounce)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		return nil, ts.searchErr(seq, ctx.Err())
+	case <-timer.C:
+	}
+
+	greq := ts.request
+	gres, err := ts.client.ForwardGeocode(ctx, &ForwardGeocodeRequest{Query: query, Request: &greq})
+	if ts.superseded(seq) {
+		return nil, ErrSuperseded
+	}
+	return gres, err
+}
+
+// Close cancels any pending search.
+func (ts *TypeaheadSession) Close() error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.cancel != nil {
+		ts.cancel()
+		ts.cancel = nil
+	}
+	return nil
+}
+
+func (ts *TypeaheadSession) superseded(seq uint64) bool {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.seq != seq
+}
+
+func (ts *TypeaheadSession) searchErr(seq uint64, err error) error {
+	if ts.superseded(seq) {
+		return ErrSuperseded
+	}
+	return err
+}
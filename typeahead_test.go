@@ -0,0 +1,115 @@
+package mapbox_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orijtech/mapbox"
+)
+
+func TestTypeaheadSessionDebounce(t *testing.T) {
+	var mu sync.Mutex
+	var gotPaths, gotAutoComplete []string
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			gotPaths = append(gotPaths, req.URL.Path)
+			gotAutoComplete = append(gotAutoComplete, req.URL.Query().Get("autocomplete"))
+			mu.Unlock()
+			return respFromFileContents(geocodeResponsePath("SF"))
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := client.NewTypeaheadSession(50*time.Millisecond, &mapbox.GeocodeRequest{Limit: 5})
+	defer ts.Close()
+
+	keystrokes := []string{"S", "Sa", "San", "San F"}
+	errs := make([]error, len(keystrokes))
+	results := make([]*mapbox.GeocodeResponse, len(keystrokes))
+	var wg sync.WaitGroup
+	for i, query := range keystrokes {
+		wg.Add(1)
+		go func(i int, query string) {
+			defer wg.Done()
+			results[i], errs[i] = ts.Search(context.Background(), query)
+		}(i, query)
+		// Type faster than the debounce interval.
+		time.Sleep(5 * time.Millisecond)
+	}
+	wg.Wait()
+
+	last := len(keystrokes) - 1
+	for i, err := range errs[:last] {
+		if err != mapbox.ErrSuperseded {
+			t.Errorf("#%d: got err %v want %v", i, err, mapbox.ErrSuperseded)
+		}
+		if results[i] != nil {
+			t.Errorf("#%d: got a result for a superseded search", i)
+		}
+	}
+	if errs[last] != nil {
+		t.Fatalf("last search: %v", errs[last])
+	}
+	if results[last] == nil || len(results[last].Features) == 0 {
+		t.Errorf("last search: expected features")
+	}
+
+	wantPaths := []string{"/geocoding/v5/mapbox.places/San F.json"}
+	if len(gotPaths) != len(wantPaths) || gotPaths[0] != wantPaths[0] {
+		t.Errorf("backend got %q want %q", gotPaths, wantPaths)
+	}
+	if len(gotAutoComplete) > 0 && gotAutoComplete[0] != "true" {
+		t.Errorf("autocomplete got %q want %q", gotAutoComplete[0], "true")
+	}
+}
+
+func TestTypeaheadSessionCancelsInFlight(t *testing.T) {
+	started := make(chan bool, 1)
+	cancelled := make(chan bool, 1)
+	client, err := mapbox.NewClient(mapbox.WithHTTPClient(&http.Client{
+		Transport: roundTrip(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path == "/geocoding/v5/mapbox.places/Sa.json" {
+				started <- true
+				<-req.Context().Done()
+				cancelled <- true
+				return nil, req.Context().Err()
+			}
+			return respFromFileContents(geocodeResponsePath("SF"))
+		}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := client.NewTypeaheadSession(0, nil)
+	defer ts.Close()
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := ts.Search(context.Background(), "Sa")
+		errc <- err
+	}()
+	<-started
+
+	gres, err := ts.Search(context.Background(), "San")
+	if err != nil {
+		t.Fatalf("latest search: %v", err)
+	}
+	if len(gres.Features) == 0 {
+		t.Errorf("latest search: expected features")
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the in-flight request was not cancelled")
+	}
+	if err := <-errc; err != mapbox.ErrSuperseded {
+		t.Errorf("earlier search: got err %v want %v", err, mapbox.ErrSuperseded)
+	}
+}